@@ -12,9 +12,31 @@ import (
 
 type ConfigFile struct {
 	Server struct {
-		Host           string
-		Port           int
-		GinReleaseMode string
+		Host            string
+		Port            int
+		GinReleaseMode  string
+		TrustedProxies  []string
+		RemoteIPHeaders []string
+		// Served over HTTPS when both are set
+		TLSCertFile string
+		TLSKeyFile  string
+		// CA verifying the client certificates, presented ones only unless
+		// ThirdPartyClientCert requires them from the third-party clients
+		TLSClientCAFile      string
+		ThirdPartyClientCert bool
+		SecurityHeaders      bool
+		HSTSMaxAge           int
+		// Timeouts in seconds of the requests, of the exports, and of the
+		// graceful shutdown
+		RequestTimeout  int
+		ExportTimeout   int
+		ShutdownTimeout int
+	}
+	// CORS policies of the route groups
+	CORS struct {
+		Backoffice  CORSPolicy
+		InternalAPI CORSPolicy
+		ThirdParty  CORSPolicy
 	}
 	Database struct {
 		Host     string
@@ -26,24 +48,112 @@ type ConfigFile struct {
 		Prefix   string
 	}
 	Valkey struct {
-		Host    string
-		Port    int
-		Channel string
+		Host           string
+		Port           int
+		Channel        string
+		CommandChannel string
+		Stream         string
+		StreamMaxLen   int64
+		StreamGroups   []string
+		HealthInterval int
+		SignRetries    int
+		SignRetryDelay int
 	}
 	App struct {
 		JSecret             string
 		ExtraLog            string
 		SaveXml             string
-		TkTime              string
 		SwaggerBasePath     string
 		TokenPrefBackoffice string
 		TokenPref3rdParty   string
 		TokenCheck          string
 	}
+	Session struct {
+		AccessTTL          int
+		RefreshTTL         int
+		MaxLifetime        int
+		TwoFactorIssuer    string
+		TwoFactorChallenge int
+	}
+	OAuth struct {
+		TokenFormat  string
+		AccessTTL    int
+		RefreshTTL   int
+		IssueRefresh string
+		SecretGrace  int
+	}
+	Fuzzy struct {
+		MinScore      float64
+		MaxResults    int
+		MaxLengthDiff int
+		// Rows scored per search, preselected on the trigram similarity of
+		// their normalized plate
+		MaxCandidates int
+		MinSimilarity float64
+	}
+	Image struct {
+		CacheSize   int
+		MaxWidth    int
+		MaxAge      int
+		JpegQuality int
+	}
+	// Limits of the third-party clients without their own
+	RateLimit struct {
+		DefaultRate  int
+		DefaultQuota int
+		FailClosed   bool
+	}
+	Kiosk struct {
+		MinLength     int
+		MaxCandidates int
+		CandidateTTL  int
+		DeviceLimit   int
+		IPLimit       int
+		ClientLimit   int
+		Window        int
+		Lockout       int
+	}
+	Webhook struct {
+		Group         string
+		ExitZones     []int
+		MaxAttempts   int
+		RetryDelay    int
+		MaxRetryDelay int
+		Timeout       int
+		PollInterval  int
+		Workers       int
+	}
 	AdminUser struct {
 		Username string
 		Password string
 	}
+	Camera struct {
+		CheckClientIP bool
+		MaxBodySize   int64
+		HMACMaxSkew   int
+		DigestRealm   string
+	}
+	InternalAPI struct {
+		Enabled       bool
+		ServiceTokens []string
+		ServiceRole   string
+	}
+	Password struct {
+		MinLength     int
+		RequireUpper  bool
+		RequireLower  bool
+		RequireDigit  bool
+		RequireSymbol bool
+	}
+}
+
+// CORSPolicy lists the origins, methods and headers allowed for a route group.
+// An origin "*" allows any origin, without credentials, and no origin
+// disables CORS.
+type CORSPolicy struct {
+	Origins []string
+	Methods []string
+	Headers []string
 }
 
 var Configvar ConfigFile
@@ -63,6 +173,53 @@ func (c *ConfigFile) Load() error {
 		return fmt.Errorf("invalid server port: %v", err)
 	}
 	c.Server.GinReleaseMode = c.getEnv("GIN_RELEASE_MODE", "false")
+	// Client IPs are read from the proxy headers only for requests coming
+	// through one of the trusted proxies (IPs or CIDRs)
+	c.Server.TrustedProxies = splitList(c.getEnv("TRUSTED_PROXIES", ""))
+	c.Server.RemoteIPHeaders = splitList(c.getEnv("REMOTE_IP_HEADERS", "X-Forwarded-For,X-Real-IP"))
+	c.Server.TLSCertFile = c.getEnv("TLS_CERT_FILE", "")
+	c.Server.TLSKeyFile = c.getEnv("TLS_KEY_FILE", "")
+	c.Server.TLSClientCAFile = c.getEnv("TLS_CLIENT_CA_FILE", "")
+	c.Server.ThirdPartyClientCert, err = strconv.ParseBool(c.getEnv("TLS_THIRD_PARTY_CLIENT_CERT", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid third-party client certificate switch: %v", err)
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		return fmt.Errorf("invalid TLS configuration: both TLS_CERT_FILE and TLS_KEY_FILE are required")
+	}
+	if c.Server.ThirdPartyClientCert && (c.Server.TLSCertFile == "" || c.Server.TLSClientCAFile == "") {
+		return fmt.Errorf("invalid TLS configuration: third-party client certificates require TLS and TLS_CLIENT_CA_FILE")
+	}
+	c.Server.SecurityHeaders, err = strconv.ParseBool(c.getEnv("SECURITY_HEADERS", "true"))
+	if err != nil {
+		return fmt.Errorf("invalid security headers switch: %v", err)
+	}
+	c.Server.HSTSMaxAge, err = strconv.Atoi(c.getEnv("HSTS_MAX_AGE", "31536000"))
+	if err != nil {
+		return fmt.Errorf("invalid HSTS max age: %v", err)
+	}
+	c.Server.RequestTimeout, err = strconv.Atoi(c.getEnv("REQUEST_TIMEOUT", "30"))
+	if err != nil {
+		return fmt.Errorf("invalid request timeout: %v", err)
+	}
+	c.Server.ExportTimeout, err = strconv.Atoi(c.getEnv("EXPORT_TIMEOUT", "300"))
+	if err != nil {
+		return fmt.Errorf("invalid export timeout: %v", err)
+	}
+	c.Server.ShutdownTimeout, err = strconv.Atoi(c.getEnv("SHUTDOWN_TIMEOUT", "30"))
+	if err != nil {
+		return fmt.Errorf("invalid shutdown timeout: %v", err)
+	}
+
+	// CORS, each route group defaulting to the shared CORS_ALLOW_* lists
+	shared := CORSPolicy{
+		Origins: splitList(c.getEnv("CORS_ALLOW_ORIGINS", "*")),
+		Methods: splitList(c.getEnv("CORS_ALLOW_METHODS", "GET,POST,PUT,DELETE,OPTIONS")),
+		Headers: splitList(c.getEnv("CORS_ALLOW_HEADERS", "Origin,Content-Type,Accept,Authorization")),
+	}
+	c.CORS.Backoffice = c.getCORSPolicy("CORS_BACKOFFICE", shared)
+	c.CORS.InternalAPI = c.getCORSPolicy("CORS_API", shared, "X-Service-Token")
+	c.CORS.ThirdParty = c.getCORSPolicy("CORS_THIRD_PARTY", shared, "X-Device-ID")
 
 	// Database configuration
 	c.Database.Host = c.getEnv("DB_HOST", "127.0.0.1")
@@ -80,15 +237,223 @@ func (c *ConfigFile) Load() error {
 	c.App.JSecret = c.getEnv("JWT_Secret", "0")
 	c.App.ExtraLog = c.getEnv("ExtraLog", "false")
 	c.App.SaveXml = c.getEnv("SaveXml", "true")
-	c.App.TkTime = c.getEnv("ExpireTokenTime", "1")
 	c.App.TokenPrefBackoffice = c.getEnv("TokenPrefBackoffice", "false")
 	c.App.TokenPref3rdParty = c.getEnv("TokenPref3rdParty", "false")
 	c.App.TokenCheck = c.getEnv("TokenCheck", "false")
 
+	// Backoffice sessions configuration, lifetimes in seconds
+	c.Session.AccessTTL, err = strconv.Atoi(c.getEnv("BACKOFFICE_ACCESS_TTL", "900"))
+	if err != nil {
+		return fmt.Errorf("invalid backoffice access token lifetime: %v", err)
+	}
+	c.Session.RefreshTTL, err = strconv.Atoi(c.getEnv("BACKOFFICE_REFRESH_TTL", "604800"))
+	if err != nil {
+		return fmt.Errorf("invalid backoffice refresh token lifetime: %v", err)
+	}
+	c.Session.MaxLifetime, err = strconv.Atoi(c.getEnv("BACKOFFICE_SESSION_MAX_TTL", "2592000"))
+	if err != nil {
+		return fmt.Errorf("invalid backoffice session lifetime: %v", err)
+	}
+	c.Session.TwoFactorIssuer = c.getEnv("BACKOFFICE_2FA_ISSUER", "FYC")
+	c.Session.TwoFactorChallenge, err = strconv.Atoi(c.getEnv("BACKOFFICE_2FA_CHALLENGE_TTL", "300"))
+	if err != nil {
+		return fmt.Errorf("invalid backoffice two-factor challenge lifetime: %v", err)
+	}
+
+	// OAuth2 client credentials configuration
+	c.OAuth.TokenFormat = c.getEnv("OAUTH_TOKEN_FORMAT", "opaque")
+	c.OAuth.AccessTTL, err = strconv.Atoi(c.getEnv("OAUTH_ACCESS_TTL", "3600"))
+	if err != nil {
+		return fmt.Errorf("invalid OAuth access token lifetime: %v", err)
+	}
+	c.OAuth.RefreshTTL, err = strconv.Atoi(c.getEnv("OAUTH_REFRESH_TTL", "2592000"))
+	if err != nil {
+		return fmt.Errorf("invalid OAuth refresh token lifetime: %v", err)
+	}
+	c.OAuth.IssueRefresh = c.getEnv("OAUTH_ISSUE_REFRESH", "false")
+	c.OAuth.SecretGrace, err = strconv.Atoi(c.getEnv("CLIENT_SECRET_GRACE", "86400"))
+	if err != nil {
+		return fmt.Errorf("invalid client secret grace period: %v", err)
+	}
+
+	// Fuzzy plate matching configuration
+	c.Fuzzy.MinScore, err = strconv.ParseFloat(c.getEnv("FUZZY_MIN_SCORE", "0.7"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid fuzzy minimum score: %v", err)
+	}
+	c.Fuzzy.MaxResults, err = strconv.Atoi(c.getEnv("FUZZY_MAX_RESULTS", "5"))
+	if err != nil {
+		return fmt.Errorf("invalid fuzzy max results: %v", err)
+	}
+	c.Fuzzy.MaxLengthDiff, err = strconv.Atoi(c.getEnv("FUZZY_MAX_LENGTH_DIFF", "2"))
+	if err != nil {
+		return fmt.Errorf("invalid fuzzy max length difference: %v", err)
+	}
+	c.Fuzzy.MaxCandidates, err = strconv.Atoi(c.getEnv("FUZZY_MAX_CANDIDATES", "200"))
+	if err != nil {
+		return fmt.Errorf("invalid fuzzy max candidates: %v", err)
+	}
+	c.Fuzzy.MinSimilarity, err = strconv.ParseFloat(c.getEnv("FUZZY_MIN_SIMILARITY", "0.1"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid fuzzy minimum similarity: %v", err)
+	}
+
+	// Zone picture delivery configuration
+	c.Image.CacheSize, err = strconv.Atoi(c.getEnv("IMAGE_CACHE_MB", "64"))
+	if err != nil {
+		return fmt.Errorf("invalid image cache size: %v", err)
+	}
+	c.Image.MaxWidth, err = strconv.Atoi(c.getEnv("IMAGE_MAX_WIDTH", "2048"))
+	if err != nil {
+		return fmt.Errorf("invalid image max width: %v", err)
+	}
+	c.Image.MaxAge, err = strconv.Atoi(c.getEnv("IMAGE_MAX_AGE", "86400"))
+	if err != nil {
+		return fmt.Errorf("invalid image max age: %v", err)
+	}
+	c.Image.JpegQuality, err = strconv.Atoi(c.getEnv("IMAGE_JPEG_QUALITY", "85"))
+	if err != nil {
+		return fmt.Errorf("invalid image JPEG quality: %v", err)
+	}
+
+	// Third-party rate limit configuration
+	c.RateLimit.DefaultRate, err = strconv.Atoi(c.getEnv("RATE_LIMIT_DEFAULT", "20"))
+	if err != nil {
+		return fmt.Errorf("invalid default rate limit: %v", err)
+	}
+	c.RateLimit.DefaultQuota, err = strconv.Atoi(c.getEnv("RATE_LIMIT_DEFAULT_DAILY_QUOTA", "0"))
+	if err != nil {
+		return fmt.Errorf("invalid default daily quota: %v", err)
+	}
+	c.RateLimit.FailClosed, err = strconv.ParseBool(c.getEnv("RATE_LIMIT_FAIL_CLOSED", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid rate limit fail closed flag: %v", err)
+	}
+
+	// Kiosk partial plate search configuration
+	c.Kiosk.MinLength, err = strconv.Atoi(c.getEnv("KIOSK_MIN_LENGTH", "3"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk minimum length: %v", err)
+	}
+	c.Kiosk.MaxCandidates, err = strconv.Atoi(c.getEnv("KIOSK_MAX_CANDIDATES", "5"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk max candidates: %v", err)
+	}
+	c.Kiosk.CandidateTTL, err = strconv.Atoi(c.getEnv("KIOSK_CANDIDATE_TTL", "300"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk candidate lifetime: %v", err)
+	}
+	c.Kiosk.DeviceLimit, err = strconv.Atoi(c.getEnv("KIOSK_DEVICE_LIMIT", "10"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk device limit: %v", err)
+	}
+	c.Kiosk.IPLimit, err = strconv.Atoi(c.getEnv("KIOSK_IP_LIMIT", "50"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk IP limit: %v", err)
+	}
+	c.Kiosk.ClientLimit, err = strconv.Atoi(c.getEnv("KIOSK_CLIENT_LIMIT", "500"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk client limit: %v", err)
+	}
+	c.Kiosk.Window, err = strconv.Atoi(c.getEnv("KIOSK_WINDOW", "600"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk window: %v", err)
+	}
+	c.Kiosk.Lockout, err = strconv.Atoi(c.getEnv("KIOSK_LOCKOUT", "900"))
+	if err != nil {
+		return fmt.Errorf("invalid kiosk lockout: %v", err)
+	}
+
+	// Outbound webhooks configuration
+	c.Webhook.Group = c.getEnv("WEBHOOK_GROUP", "webhooks")
+	c.Webhook.ExitZones = nil
+	for _, zone := range strings.Split(c.getEnv("WEBHOOK_EXIT_ZONES", "0"), ",") {
+		if zone = strings.TrimSpace(zone); zone == "" {
+			continue
+		}
+		zoneID, err := strconv.Atoi(zone)
+		if err != nil {
+			return fmt.Errorf("invalid webhook exit zone: %v", err)
+		}
+		c.Webhook.ExitZones = append(c.Webhook.ExitZones, zoneID)
+	}
+	c.Webhook.MaxAttempts, err = strconv.Atoi(c.getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook max attempts: %v", err)
+	}
+	c.Webhook.RetryDelay, err = strconv.Atoi(c.getEnv("WEBHOOK_RETRY_DELAY", "30"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook retry delay: %v", err)
+	}
+	c.Webhook.MaxRetryDelay, err = strconv.Atoi(c.getEnv("WEBHOOK_MAX_RETRY_DELAY", "3600"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook max retry delay: %v", err)
+	}
+	c.Webhook.Timeout, err = strconv.Atoi(c.getEnv("WEBHOOK_TIMEOUT", "10"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook timeout: %v", err)
+	}
+	c.Webhook.PollInterval, err = strconv.Atoi(c.getEnv("WEBHOOK_POLL_INTERVAL", "5"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook poll interval: %v", err)
+	}
+	c.Webhook.Workers, err = strconv.Atoi(c.getEnv("WEBHOOK_WORKERS", "4"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook workers: %v", err)
+	}
+
 	// Backoffice Admin user data
 	c.AdminUser.Username = c.getEnv("USERNAME", "admin")
 	c.AdminUser.Password = c.getEnv("PASSWORD", "admin")
 
+	// Backoffice password policy
+	c.Password.MinLength, err = strconv.Atoi(c.getEnv("PASSWORD_MIN_LENGTH", "10"))
+	if err != nil {
+		return fmt.Errorf("invalid password minimum length: %v", err)
+	}
+	c.Password.RequireUpper, err = strconv.ParseBool(c.getEnv("PASSWORD_REQUIRE_UPPER", "true"))
+	if err != nil {
+		return fmt.Errorf("invalid password upper case requirement: %v", err)
+	}
+	c.Password.RequireLower, err = strconv.ParseBool(c.getEnv("PASSWORD_REQUIRE_LOWER", "true"))
+	if err != nil {
+		return fmt.Errorf("invalid password lower case requirement: %v", err)
+	}
+	c.Password.RequireDigit, err = strconv.ParseBool(c.getEnv("PASSWORD_REQUIRE_DIGIT", "true"))
+	if err != nil {
+		return fmt.Errorf("invalid password digit requirement: %v", err)
+	}
+	c.Password.RequireSymbol, err = strconv.ParseBool(c.getEnv("PASSWORD_REQUIRE_SYMBOL", "false"))
+	if err != nil {
+		return fmt.Errorf("invalid password symbol requirement: %v", err)
+	}
+
+	// Internal /fyc management API, disabled in production with FYC_API_ENABLED=false.
+	// Callers use a backoffice token, or one of the service tokens acting with
+	// the permissions of the service role.
+	c.InternalAPI.Enabled, err = strconv.ParseBool(c.getEnv("FYC_API_ENABLED", "true"))
+	if err != nil {
+		return fmt.Errorf("invalid internal API switch: %v", err)
+	}
+	c.InternalAPI.ServiceTokens = splitList(c.getEnv("FYC_API_SERVICE_TOKENS", ""))
+	c.InternalAPI.ServiceRole = c.getEnv("FYC_API_SERVICE_ROLE", "Admin")
+
+	// Camera ingestion on /cam
+	c.Camera.CheckClientIP, err = strconv.ParseBool(c.getEnv("CAM_CHECK_CLIENT_IP", "true"))
+	if err != nil {
+		return fmt.Errorf("invalid camera client IP check: %v", err)
+	}
+	maxBodyMB, err := strconv.ParseInt(c.getEnv("CAM_MAX_BODY_MB", "16"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid camera max body size: %v", err)
+	}
+	c.Camera.MaxBodySize = maxBodyMB << 20
+	c.Camera.HMACMaxSkew, err = strconv.Atoi(c.getEnv("CAM_HMAC_MAX_SKEW", "300"))
+	if err != nil {
+		return fmt.Errorf("invalid camera HMAC max skew: %v", err)
+	}
+	c.Camera.DigestRealm = c.getEnv("CAM_DIGEST_REALM", "fyc-cam")
+
 	// Valkey Config
 	c.Valkey.Host = c.getEnv("VALKEY_HOST", "127.0.0.1")
 	c.Valkey.Port, err = strconv.Atoi(c.getEnv("VALKEY_PORT", "6379"))
@@ -96,6 +461,25 @@ func (c *ConfigFile) Load() error {
 		return fmt.Errorf("invalid Valkey port: %v", err)
 	}
 	c.Valkey.Channel = c.getEnv("VALKEY_CHANNEL", "fyc")
+	c.Valkey.CommandChannel = c.getEnv("VALKEY_COMMAND_CHANNEL", "fyc_commands")
+	c.Valkey.Stream = c.getEnv("VALKEY_STREAM", "fyc:events")
+	c.Valkey.StreamMaxLen, err = strconv.ParseInt(c.getEnv("VALKEY_STREAM_MAXLEN", "100000"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Valkey stream max length: %v", err)
+	}
+	c.Valkey.StreamGroups = strings.Split(c.getEnv("VALKEY_STREAM_GROUPS", "signs,integrations"), ",")
+	c.Valkey.HealthInterval, err = strconv.Atoi(c.getEnv("VALKEY_HEALTH_INTERVAL", "10"))
+	if err != nil {
+		return fmt.Errorf("invalid Valkey health interval: %v", err)
+	}
+	c.Valkey.SignRetries, err = strconv.Atoi(c.getEnv("SIGN_RETRIES", "3"))
+	if err != nil {
+		return fmt.Errorf("invalid sign retries: %v", err)
+	}
+	c.Valkey.SignRetryDelay, err = strconv.Atoi(c.getEnv("SIGN_RETRY_DELAY_MS", "500"))
+	if err != nil {
+		return fmt.Errorf("invalid sign retry delay: %v", err)
+	}
 
 	// Swagger BasePath configuration
 	BasePath := c.getEnv("SwaggerBasePath", "/")
@@ -120,3 +504,26 @@ func (c *ConfigFile) getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getCORSPolicy reads the <prefix>_ORIGINS, <prefix>_METHODS and
+// <prefix>_HEADERS lists, defaulting to the shared policy with the extra
+// headers of the route group.
+func (c *ConfigFile) getCORSPolicy(prefix string, shared CORSPolicy, extraHeaders ...string) CORSPolicy {
+	defaultHeaders := append(append([]string{}, shared.Headers...), extraHeaders...)
+	return CORSPolicy{
+		Origins: splitList(c.getEnv(prefix+"_ORIGINS", strings.Join(shared.Origins, ","))),
+		Methods: splitList(c.getEnv(prefix+"_METHODS", strings.Join(shared.Methods, ","))),
+		Headers: splitList(c.getEnv(prefix+"_HEADERS", strings.Join(defaultHeaders, ","))),
+	}
+}
+
+// splitList returns the non-empty items of a comma-separated list.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"reflect"
 	"regexp"
 	"strings"
 	"time"
@@ -48,6 +49,35 @@ func CreateTables(ctx context.Context, db *bun.DB, models []interface{}) error {
 	return nil
 }
 
+// AddMissingColumns adds columns declared on the models that do not exist yet
+// in their tables, so new fields reach databases created by older versions.
+func AddMissingColumns(ctx context.Context, db *bun.DB, models []interface{}) error {
+	if db == nil {
+		return fmt.Errorf("db connection is nil")
+	}
+
+	for _, model := range models {
+		table := db.Table(reflect.TypeOf(model))
+
+		for _, field := range table.Fields {
+			if field.IsPK {
+				continue
+			}
+
+			column := fmt.Sprintf("%s %s", field.SQLName, field.CreateTableSQLType)
+			if field.SQLDefault != "" {
+				column += " DEFAULT " + field.SQLDefault
+			}
+
+			_, err := db.NewAddColumn().Model(model).ColumnExpr(column).IfNotExists().Exec(ctx)
+			if err != nil {
+				log.Err(err).Str("Table", table.Name).Str("Column", field.Name).Msg("Error adding missing column")
+			}
+		}
+	}
+	return nil
+}
+
 func ByteaToBase64(data []byte) (string, error) {
 	mimeType := http.DetectContentType(data)
 
@@ -3,7 +3,13 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
@@ -13,9 +19,17 @@ import (
 	"fyc/config"
 	"fyc/docs"
 	"fyc/functions"
+	"fyc/middleware"
+	"fyc/pkg/apierror"
 	"fyc/pkg/backoffice"
+	"fyc/pkg/commands"
+	"fyc/pkg/counting"
 	"fyc/pkg/cron"
 	"fyc/pkg/db"
+	"fyc/pkg/events"
+	"fyc/pkg/hikvision"
+	"fyc/pkg/valkey"
+	"fyc/pkg/webhooks"
 	"fyc/routes"
 )
 
@@ -29,6 +43,10 @@ import (
 // @in							header
 // @name						Authorization
 // @description				Authorization token for back-office section (Ensure the token is in this format: Bearer token)
+// @securityDefinitions.apikey	ServiceToken
+// @in							header
+// @name						X-Service-Token
+// @description				Service token of the internal /fyc API, one of FYC_API_SERVICE_TOKENS
 // @server.url					https://fyc.asteroidea.co/api
 // @server.description			Preprod
 // @server.url					https://fyc.asteroidea.co/prod
@@ -37,7 +55,9 @@ import (
 // @server.description			Staging
 func main() {
 	config.InitLogger()
-	ctx := context.Background()
+	// Cancelled on SIGINT or SIGTERM, stopping the background workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	log.Debug().Msg("------------------------------ # STARTING FMC APPLICATION # ------------------------------")
 
@@ -48,6 +68,7 @@ func main() {
 	}
 
 	docs.SwaggerInfo.BasePath = config.Configvar.App.SwaggerBasePath
+	middleware.LoadJwtKey()
 
 	log.Info().Msgf("Server running on %s:%d ", config.Configvar.Server.Host, config.Configvar.Server.Port)
 	log.Info().Msgf("Database connecting to %s:%d", config.Configvar.Database.Host, config.Configvar.Database.Port)
@@ -71,7 +92,9 @@ func main() {
 
 	models := []interface{}{
 		&db.UserAudit{},
+		&db.AuditLog{},
 		&db.User{},
+		&db.Role{},
 		&db.ApiKey{},
 
 		&db.Settings{},
@@ -84,6 +107,13 @@ func main() {
 		&db.Camera{},
 		&db.CarDetail{},
 		&db.Sign{},
+		&db.SignStatus{},
+		&db.OAuthToken{},
+		&db.BackofficeSession{},
+		&db.AuthLog{},
+		&db.SecurityLog{},
+		&db.WebhookSubscription{},
+		&db.WebhookDelivery{},
 	}
 
 	if err := functions.CreateTables(ctx, db.Db_GlobalVar, models); err != nil {
@@ -93,8 +123,49 @@ func main() {
 
 	}
 
+	if err := functions.AddMissingColumns(ctx, db.Db_GlobalVar, models); err != nil {
+		log.Error().Err(err).Msg("Failed to add missing columns")
+	}
+
+	if err := db.IndexPresentCars(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to index present cars")
+	}
+
+	if err := db.MigrateClientSecrets(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to migrate client secrets")
+	}
+	if err := db.MigrateUserPasswords(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to migrate user passwords")
+	}
+	if err := db.HashZoneImages(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to hash the zone pictures")
+	}
+	if err := db.MigrateSessionExpiry(ctx, time.Duration(config.Configvar.Session.MaxLifetime)*time.Second); err != nil {
+		log.Error().Err(err).Msg("Failed to set the end of the sessions")
+	}
+	if err := db.ProtectAuditLog(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to make the audit log append-only")
+	}
+
+	// Error codes of the v2 API missing from the errors table
+	if err := apierror.Seed(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to seed the error catalog")
+	}
+
+	// Shared Valkey client
+	valkey.InitValkey()
+	go valkey.Valkey_GlobalVar.HealthCheck(ctx, time.Duration(config.Configvar.Valkey.HealthInterval)*time.Second)
+	go commands.StartCommandListener(ctx)
+	events.InitStream(ctx)
+
 	// Startup Data Processing
-	backoffice.StartUpData()
+	backoffice.StartUpData(ctx)
+
+	// Outbound webhooks, started once the clients are loaded
+	webhooks.Start(ctx)
+
+	// Sign values of the camera ingest, delivered in the background
+	counting.StartSignDelivery(ctx)
 
 	// Router Setup
 	r := routes.SetupRouter()
@@ -105,17 +176,59 @@ func main() {
 	// migrations.Validation_Shema()
 
 	// CRONN JOB
-	go cron.CronFyc()
-	go cron.CronCounting()
+	cron.Start(ctx)
 
 	// Server Setup
-	var host = fmt.Sprintf("%s:%d", config.Configvar.Server.Host, config.Configvar.Server.Port)
+	server, err := routes.NewServer(r)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up server")
+	}
+
+	log.Info().Bool("TLS", routes.TLSEnabled()).Msgf("-------------------------------- # Server running on %s # ------------------------------", server.Addr)
 
-	log.Info().Msgf("-------------------------------- # Server running on %s # ------------------------------", host)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- routes.Serve(server)
+	}()
 
-	if err := r.Run(host); err != nil {
-		log.Err(err).Msgf("Failed to run server: %v", err)
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Err(err).Msgf("Failed to run server: %v", err)
+		}
+	case <-ctx.Done():
+		log.Info().Msg("-------------------------------- # SHUTDOWN SIGNAL RECEIVED # ------------------------------")
 	}
+	stop()
+
+	shutdown(server)
 
 	log.Debug().Msgf("-------------------------------- # END PROGRAM # ------------------------------")
 }
+
+// shutdown stops accepting requests and waits for the in-flight ones, drains
+// the ingestion and the background workers, stops the cron jobs, then closes
+// Valkey and the database, all within the shutdown timeout.
+func shutdown(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Configvar.Server.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Err(err).Msg("Requests still in flight at shutdown")
+	}
+	if err := hikvision.Drain(ctx); err != nil {
+		log.Err(err).Msg("Captures still being processed at shutdown")
+	}
+	if err := counting.WaitSignDelivery(ctx); err != nil {
+		log.Err(err).Msg("Sign values still being delivered at shutdown")
+	}
+	if err := webhooks.Wait(ctx); err != nil {
+		log.Err(err).Msg("Webhook dispatcher still running at shutdown")
+	}
+	cron.Stop(ctx)
+
+	valkey.Valkey_GlobalVar.Valkey_Close()
+	if err := db.Db_GlobalVar.Close(); err != nil {
+		log.Err(err).Msg("Error closing database")
+	}
+}
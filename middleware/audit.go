@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/audit"
+	"fyc/pkg/db"
+	"fyc/pkg/rbac"
+)
+
+// Audit records the action on the module in the audit log once the handler
+// succeeded. keys name the path or query parameters, or else the body fields,
+// identifying the entity, its state being captured before and after the
+// handler. The acting user is the backoffice user of the token, if any.
+func Audit(module string, action string, keys ...string) gin.HandlerFunc {
+	return AuditEntity(module, module, action, keys...)
+}
+
+// AuditEntity records the action like Audit, for an entity of another kind
+// than the module whose permission it falls under, one of the audit.Entity
+// kinds.
+func AuditEntity(module string, entity string, action string, keys ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The change is recorded even when the client went away meanwhile
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		var body map[string]interface{}
+		if c.Request.Body != nil {
+			raw, err := io.ReadAll(c.Request.Body)
+			if err == nil {
+				c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+				decoder := json.NewDecoder(bytes.NewReader(raw))
+				decoder.UseNumber()
+				_ = decoder.Decode(&body)
+			}
+		}
+
+		entityID := auditKey(c, body, keys)
+
+		before, err := audit.Snapshot(ctx, entity, entityID)
+		if err != nil {
+			log.Warn().Err(err).Str("Module", module).Msg("Error taking audit snapshot")
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		var after map[string]interface{}
+		if action != rbac.ActionDelete {
+			after, err = audit.Snapshot(ctx, entity, entityID)
+			if err != nil {
+				log.Warn().Err(err).Str("Module", module).Msg("Error taking audit snapshot")
+			}
+			// Created entities without a known key are recorded as submitted
+			if after == nil && body != nil {
+				audit.Redact(body)
+				after = body
+			}
+		}
+
+		entry := &db.AuditLog{
+			Username: c.GetString(ContextUsername),
+			Role:     c.GetString(ContextRole),
+			ClientIP: c.ClientIP(),
+			Module:   module,
+			Action:   action,
+			EntityID: entityID,
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Status:   c.Writer.Status(),
+			OldValue: before,
+			NewValue: after,
+		}
+		if err := db.CreateAuditLog(ctx, entry); err != nil {
+			log.Error().Err(err).Str("Module", module).Str("Action", action).Str("Entity", entityID).Msg("Error writing audit log")
+		}
+	}
+}
+
+// auditKey returns the first key found in the path or query, else in the body.
+func auditKey(c *gin.Context, body map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if value := c.Param(key); value != "" {
+			return value
+		}
+		if value := c.Query(key); value != "" {
+			return value
+		}
+	}
+	for _, key := range keys {
+		if value, ok := body[key]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/apierror"
+	"fyc/pkg/db"
+)
+
+// Context keys with the subject and the SHA-256 fingerprint of the verified
+// client certificate
+const (
+	ContextClientCert            = "client_cert"
+	ContextClientCertFingerprint = "client_cert_fingerprint"
+)
+
+// RequireClientCert rejects the third-party requests without a client
+// certificate verified by the configured CA, when client certificates are
+// required. TLS must terminate on this server for the certificate to be seen.
+func RequireClientCert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.Configvar.Server.ThirdPartyClientCert {
+			c.Next()
+			return
+		}
+
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			log.Warn().Str("IP", c.ClientIP()).Str("Path", c.Request.URL.Path).Msg("Third-party request without a client certificate")
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Unauthorized, gin.H{
+				"success": false,
+				"code":    -3,
+				"message": "Unauthorized, client certificate required!",
+			})
+			return
+		}
+
+		leaf := c.Request.TLS.VerifiedChains[0][0]
+		c.Set(ContextClientCert, leaf.Subject.String())
+		c.Set(ContextClientCertFingerprint, db.CertFingerprint(leaf.Raw))
+		c.Next()
+	}
+}
+
+// BindClientCert rejects the requests of a client authenticated by
+// TokenMiddlewareThirdParty with a certificate other than the one registered
+// for it, when client certificates are required, so that a certificate issued
+// to one client cannot be used with the token of another.
+func BindClientCert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.Configvar.Server.ThirdPartyClientCert {
+			c.Next()
+			return
+		}
+
+		clientID := c.GetString(ContextClientID)
+		client, _ := db.GetClientDetails(clientID)
+		fingerprint := c.GetString(ContextClientCertFingerprint)
+		if client.CertFingerprint == "" || subtle.ConstantTimeCompare([]byte(client.CertFingerprint), []byte(fingerprint)) != 1 {
+			log.Warn().Str("Client ID", clientID).Str("Subject", c.GetString(ContextClientCert)).Str("Fingerprint", fingerprint).Msg("Client certificate not registered for the client")
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Unauthorized, gin.H{
+				"success": false,
+				"code":    -3,
+				"message": "Unauthorized, client certificate not registered for this client!",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/functions"
+	"fyc/pkg/apierror"
+	"fyc/pkg/db"
+)
+
+// Context keys set by TokenMiddlewareThirdParty with the scopes and zones the
+// request is allowed to use. A request without them is allowed none.
+const (
+	ContextScopes   = "scopes"
+	ContextZones    = "zones"
+	ContextAllZones = "all_zones"
+)
+
+// ClientScopes returns the scopes the client may request, all of them when the
+// client is not restricted.
+func ClientScopes(client db.ClientDetails) []string {
+	if len(client.Scopes) == 0 {
+		return db.AllScopes
+	}
+	return client.Scopes
+}
+
+// GrantedScope checks the space separated scopes requested for a token against
+// the client scopes. When nothing is requested every client scope is granted.
+func GrantedScope(client db.ClientDetails, requested string) (string, bool) {
+	allowed := ClientScopes(client)
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return strings.Join(allowed, " "), true
+	}
+
+	for _, scope := range scopes {
+		if !functions.ContainsStr(allowed, scope) {
+			return "", false
+		}
+	}
+	return strings.Join(scopes, " "), true
+}
+
+// setClientAccess stores in the context the scopes and zones of the request:
+// those of the token restricted to what the client is currently allowed.
+func setClientAccess(c *gin.Context, client db.ClientDetails, tokenScope string, tokenZones []int) {
+	scopes := ClientScopes(client)
+	if tokenScopes := strings.Fields(tokenScope); len(tokenScopes) > 0 {
+		var granted []string
+		for _, scope := range tokenScopes {
+			if functions.ContainsStr(scopes, scope) {
+				granted = append(granted, scope)
+			}
+		}
+		scopes = granted
+	}
+	c.Set(ContextScopes, scopes)
+
+	zones := client.AllowedZones
+	if len(zones) > 0 && len(tokenZones) > 0 {
+		granted := []int{}
+		for _, zone := range tokenZones {
+			if functions.Contains(zones, zone) {
+				granted = append(granted, zone)
+			}
+		}
+		zones = granted
+	} else if len(zones) == 0 {
+		zones = tokenZones
+	}
+
+	// A nil list means every zone is allowed
+	if zones == nil {
+		c.Set(ContextAllZones, true)
+		return
+	}
+	c.Set(ContextZones, zones)
+}
+
+// RequireScope rejects the request when the client is not allowed to use the
+// third-party operation.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, _ := c.Get(ContextScopes)
+		if list, _ := scopes.([]string); !functions.ContainsStr(list, scope) {
+			log.Warn().Str("Client ID", c.GetString(ContextClientID)).Str("Scope", scope).Msg("Client not allowed to use this operation")
+			apierror.Abort(c, http.StatusForbidden, apierror.ForbiddenScope, gin.H{
+				"success": false,
+				"code":    -6,
+				"message": "Client is not allowed to use this operation",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ZoneAllowed reports whether the client of the request may see the zone.
+func ZoneAllowed(c *gin.Context, zoneID int) bool {
+	if c.GetBool(ContextAllZones) {
+		return true
+	}
+
+	zones, _ := c.Get(ContextZones)
+	list, _ := zones.([]int)
+	return functions.Contains(list, zoneID)
+}
+
+// AllowedZones returns the zones the client of the request may see, nil when
+// every zone is allowed.
+func AllowedZones(c *gin.Context) []int {
+	if c.GetBool(ContextAllZones) {
+		return nil
+	}
+
+	zones, _ := c.Get(ContextZones)
+	if list, _ := zones.([]int); list != nil {
+		return list
+	}
+	return []int{}
+}
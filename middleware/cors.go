@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+
+	"fyc/config"
+)
+
+// CORS applies the CORS policy of the route group of the request: the
+// backoffice, the internal /fyc API, or else the third-party API. It is
+// installed on the engine so that preflight requests, which match no route,
+// are answered too.
+func CORS() gin.HandlerFunc {
+	backoffice := corsHandler(config.Configvar.CORS.Backoffice)
+	internalAPI := corsHandler(config.Configvar.CORS.InternalAPI)
+	thirdParty := corsHandler(config.Configvar.CORS.ThirdParty)
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		switch {
+		case strings.HasPrefix(path, "/backoffice"):
+			backoffice(c)
+		case strings.HasPrefix(path, "/fyc"):
+			internalAPI(c)
+		default:
+			thirdParty(c)
+		}
+	}
+}
+
+func corsHandler(policy config.CORSPolicy) gin.HandlerFunc {
+	if len(policy.Origins) == 0 {
+		return func(c *gin.Context) {}
+	}
+
+	corsConfig := cors.Config{
+		AllowMethods:  policy.Methods,
+		AllowHeaders:  policy.Headers,
+		ExposeHeaders: []string{"Content-Length", "Content-Disposition", "Retry-After"},
+		MaxAge:        12 * time.Hour,
+	}
+	for _, origin := range policy.Origins {
+		if origin == "*" {
+			corsConfig.AllowAllOrigins = true
+		}
+	}
+	// Credentials are never allowed for any origin
+	if !corsConfig.AllowAllOrigins {
+		corsConfig.AllowOrigins = policy.Origins
+		corsConfig.AllowCredentials = true
+	}
+	return cors.New(corsConfig)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/rbac"
+)
+
+// RequirePermission rejects the request when the role of the backoffice user
+// does not allow the action on the module, or requires two-factor
+// authentication the session was opened without. It must follow
+// TokenMiddlewareBackOffice.
+func RequirePermission(module string, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString(ContextRole)
+		if !rbac.Allowed(role, module, action) {
+			log.Warn().Str("Username", c.GetString(ContextUsername)).Str("Role", role).Str("Permission", rbac.Permission(module, action)).Msg("User not allowed to use this operation")
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"code":    -6,
+				"message": "You are not allowed to use this operation",
+			})
+			c.Abort()
+			return
+		}
+
+		if rbac.RequiresTwoFactor(role) && !c.GetBool(ContextTwoFactor) {
+			log.Warn().Str("Username", c.GetString(ContextUsername)).Str("Role", role).Msg("Two-factor authentication required")
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"code":    -7,
+				"message": "Two-factor authentication is required for your role",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
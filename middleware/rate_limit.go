@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/apierror"
+	"fyc/pkg/db"
+	"fyc/pkg/ratelimit"
+)
+
+// RateLimitThirdParty enforces the requests per second limit and the daily quota
+// of the client authenticated by TokenMiddlewareThirdParty.
+func RateLimitThirdParty() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID := c.GetString(ContextClientID)
+		client, exists := db.GetClientDetails(clientID)
+		if clientID == "" || !exists {
+			c.Next()
+			return
+		}
+
+		rateLimit, dailyQuota := clientLimits(client)
+		decision := ratelimit.Allow(c.Request.Context(), clientID, rateLimit, dailyQuota)
+
+		if dailyQuota > 0 {
+			remaining := decision.Remaining
+			if remaining < 0 {
+				remaining = 0
+			}
+			c.Header("X-RateLimit-Limit", strconv.Itoa(dailyQuota))
+			c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+		}
+
+		if !decision.Allowed {
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+
+			log.Warn().Str("Client ID", clientID).Str("Reason", decision.Reason).Int("Retry After", retryAfter).Msg("Client request rejected")
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			if decision.Unavailable {
+				apierror.Abort(c, http.StatusServiceUnavailable, apierror.ServiceUnavailable, gin.H{
+					"success": false,
+					"code":    -500,
+					"message": "Service temporarily unavailable, please try again later",
+				})
+				return
+			}
+			apierror.Abort(c, http.StatusTooManyRequests, apierror.RateLimited, gin.H{
+				"success": false,
+				"code":    -9,
+				"message": "Too many requests, " + decision.Reason,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientLimits returns the requests per second limit and the daily quota of the
+// client. A limit of 0 applies the configured default, and a negative one
+// leaves the client unlimited.
+func clientLimits(client db.ClientDetails) (int, int) {
+	rateLimit, dailyQuota := client.RateLimit, client.DailyQuota
+	if rateLimit == 0 {
+		rateLimit = config.Configvar.RateLimit.DefaultRate
+	}
+	if dailyQuota == 0 {
+		dailyQuota = config.Configvar.RateLimit.DefaultQuota
+	}
+	return rateLimit, dailyQuota
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"fyc/config"
+)
+
+// RequestTimeout bounds the context of the request, the queries still running
+// once the request timeout expires, or the export timeout for the exports,
+// being cancelled.
+func RequestTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := time.Duration(config.Configvar.Server.RequestTimeout) * time.Second
+		if strings.HasPrefix(c.Request.URL.Path, "/backoffice/export") {
+			timeout = time.Duration(config.Configvar.Server.ExportTimeout) * time.Second
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
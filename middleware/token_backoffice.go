@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -11,27 +10,50 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"fyc/config"
+	"fyc/functions"
+	"fyc/pkg/db"
 )
 
 var JwtKey = []byte(config.Configvar.App.JSecret)
 
+// LoadJwtKey sets the signing key once the configuration has been loaded.
+func LoadJwtKey() {
+	JwtKey = []byte(config.Configvar.App.JSecret)
+}
+
+// Context keys set by TokenMiddlewareBackOffice for the authenticated user
+const (
+	ContextUsername  = "username"
+	ContextRole      = "role"
+	ContextSessionID = "session_id"
+	ContextTwoFactor = "two_factor"
+)
+
+// Routes a session opened with a password to be changed is restricted to
+var passwordChangeRoutes = []string{
+	"/backoffice/changePassword",
+	"/backoffice/logout",
+	"/backoffice/passwordPolicy",
+}
+
 // Claims struct to store JWT claims
 type ClaimsBackOffice struct {
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
 	jwt.StandardClaims
 }
 
-func GenerateToken(username string, role string) (string, int, error) {
-	var tk string = config.Configvar.App.TkTime
-
-	// Convert string to int
-	hours, _ := strconv.Atoi(tk)
-	expirationTime := time.Now().Add(time.Duration(hours) * time.Hour)
+// GenerateToken issues an access token of the session, valid for the
+// configured access lifetime in seconds.
+func GenerateToken(username string, role string, sessionID string) (string, int, error) {
+	ttl := config.Configvar.Session.AccessTTL
+	expirationTime := time.Now().Add(time.Duration(ttl) * time.Second)
 
 	claims := &ClaimsBackOffice{
-		Username: username,
-		Role:     role,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationTime.Unix(),
 		},
@@ -44,7 +66,7 @@ func GenerateToken(username string, role string) (string, int, error) {
 		return "", 0, err
 	}
 
-	return tokenString, hours, nil
+	return tokenString, ttl, nil
 }
 
 // TokenMiddleware checks the token validity and expiration
@@ -91,6 +113,45 @@ func TokenMiddlewareBackOffice() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens are only accepted while their session is active
+		session, err := db.GetBackofficeSession(c.Request.Context(), claims.SessionID)
+		if err != nil {
+			log.Err(err).Str("Username", claims.Username).Msg("Error retrieving backoffice session")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"code":    -500,
+				"message": "An unexpected error occurred. Please try again later.",
+			})
+			c.Abort()
+			return
+		}
+		if session == nil || session.Username != claims.Username || !session.IsActive() {
+			log.Warn().Str("Username", claims.Username).Msg("Unauthorized, session revoked or expired!")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"code":    -3,
+				"message": "Unauthorized, session revoked or expired!",
+			})
+			c.Abort()
+			return
+		}
+
+		if session.MustChangePassword && !functions.ContainsStr(passwordChangeRoutes, c.FullPath()) {
+			log.Warn().Str("Username", claims.Username).Str("Path", c.Request.URL.Path).Msg("Password change required")
+			c.JSON(http.StatusForbidden, gin.H{
+				"success":              false,
+				"code":                 -6,
+				"message":              "You must change your password first",
+				"must_change_password": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextUsername, claims.Username)
+		c.Set(ContextRole, claims.Role)
+		c.Set(ContextSessionID, claims.SessionID)
+		c.Set(ContextTwoFactor, session.TwoFactor)
 		c.Next()
 	}
 }
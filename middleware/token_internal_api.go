@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+)
+
+// ServiceTokenHeader carries the service token of the internal API callers
+// that are not backoffice users.
+const ServiceTokenHeader = "X-Service-Token"
+
+// ServiceUsername is the user recorded for the requests of a service token
+const ServiceUsername = "service"
+
+// TokenMiddlewareInternalAPI authenticates the internal /fyc API, either with a
+// configured service token, acting with the permissions of the service role,
+// or else with a backoffice token.
+func TokenMiddlewareInternalAPI() gin.HandlerFunc {
+	backoffice := TokenMiddlewareBackOffice()
+
+	return func(c *gin.Context) {
+		token := c.GetHeader(ServiceTokenHeader)
+		if token == "" {
+			backoffice(c)
+			return
+		}
+
+		if !validServiceToken(token) {
+			log.Warn().Str("IP", c.ClientIP()).Str("Path", c.Request.URL.Path).Msg("Invalid service token")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"code":    -3,
+				"message": "Unauthorized, invalid service token!",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextUsername, ServiceUsername)
+		c.Set(ContextRole, config.Configvar.InternalAPI.ServiceRole)
+		// Service tokens are not subject to the two-factor policy of users
+		c.Set(ContextTwoFactor, true)
+		c.Next()
+	}
+}
+
+func validServiceToken(token string) bool {
+	hash := []byte(HashToken(token))
+	valid := false
+	for _, configured := range config.Configvar.InternalAPI.ServiceTokens {
+		if subtle.ConstantTimeCompare(hash, []byte(HashToken(configured))) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"fyc/config"
+)
+
+// ClaimsOAuth are the claims of JWT access tokens issued by /oauth/token.
+// They never carry the client secret.
+type ClaimsOAuth struct {
+	ClientID   string `json:"client_id"`
+	FuzzyLogic bool   `json:"fuzzy_logic"`
+	Scope      string `json:"scope,omitempty"`
+	Zones      []int  `json:"zones,omitempty"`
+	jwt.StandardClaims
+}
+
+// HashToken returns the hex encoded SHA-256 of a token, which is what gets stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateOpaqueToken returns a random URL-safe token.
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// GenerateOAuthAccessToken issues an access token for the client in the
+// configured format (opaque or jwt).
+func GenerateOAuthAccessToken(clientID string, fuzzyLogic bool, scope string, zones []int, issuedAt, expiresAt time.Time) (string, string, error) {
+	if config.Configvar.OAuth.TokenFormat != "jwt" {
+		token, err := GenerateOpaqueToken()
+		return token, "opaque", err
+	}
+
+	jti, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := &ClaimsOAuth{
+		ClientID:   clientID,
+		FuzzyLogic: fuzzyLogic,
+		Scope:      scope,
+		Zones:      zones,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   clientID,
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(JwtKey)
+	if err != nil {
+		return "", "", err
+	}
+	return token, "jwt", nil
+}
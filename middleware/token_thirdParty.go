@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"fyc/config"
+	"fyc/pkg/apierror"
 	"fyc/pkg/db"
 	"net/http"
 	"strings"
@@ -13,22 +14,30 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// Context keys set by TokenMiddlewareThirdParty for the authenticated client
+const (
+	ContextClientID   = "client_id"
+	ContextFuzzyLogic = "fuzzy_logic"
+)
+
 type ClaimsThirdParty struct {
 	ClientID        string `json:"client_id"`
-	ClientSecret    string `json:"client_secret"`
 	ClientGrantType string `json:"grant_type"`
 	FuzzyLogic      bool   `json:"fuzzy_logic"`
+	Scope           string `json:"scope,omitempty"`
+	Zones           []int  `json:"zones,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateTokenThirdParty(client_id, client_secret, client_grantType string, client_fuzzy_logic bool) (string, error) {
+func GenerateTokenThirdParty(client_id, client_grantType string, client_fuzzy_logic bool, scope string, zones []int) (string, error) {
 	expirationTime := time.Now().Add(1 * time.Hour)
 
 	claims := &ClaimsThirdParty{
 		ClientID:        client_id,
-		ClientSecret:    client_secret,
 		ClientGrantType: client_grantType,
 		FuzzyLogic:      client_fuzzy_logic,
+		Scope:           scope,
+		Zones:           zones,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationTime.Unix(),
 		},
@@ -64,16 +73,46 @@ func TokenMiddlewareThirdParty() gin.HandlerFunc {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" || !strings.HasPrefix(tokenString, "Bearer ") {
 			log.Warn().Msg("Authorization required")
-			c.JSON(http.StatusUnauthorized, gin.H{
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Unauthorized, gin.H{
 				"code":    -3,
 				"error":   "Token is required",
 				"success": false,
 			})
-			c.Abort()
 			return
 		}
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
+		// Tokens issued by /oauth/token are stored and checked in the database
+		stored, err := db.GetOAuthTokenByHash(c.Request.Context(), HashToken(tokenString))
+		if err != nil {
+			log.Err(err).Msg("Error checking OAuth token")
+			apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
+				"success": false,
+				"code":    -500,
+				"message": "An unexpected error occurred. Please try again later.",
+			})
+			return
+		}
+
+		if stored != nil {
+			client, exists := db.GetClientDetails(stored.ClientID)
+			if stored.TokenType != db.TokenTypeAccess || !stored.IsActive() || !exists || !client.ClientActive {
+				log.Warn().Str("Client ID", stored.ClientID).Msg("Unauthorized, token revoked or expired!")
+				apierror.Abort(c, http.StatusUnauthorized, apierror.InvalidToken, gin.H{
+					"success": false,
+					"code":    -3,
+					"message": "Unauthorized, Token has expired or was revoked!",
+				})
+				return
+			}
+
+			c.Set(ContextClientID, stored.ClientID)
+			c.Set(ContextFuzzyLogic, client.FuzzyLogic)
+			setClientAccess(c, client, stored.Scope, nil)
+			c.Next()
+			return
+		}
+
 		claims := &ClaimsThirdParty{}
 
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -84,12 +123,11 @@ func TokenMiddlewareThirdParty() gin.HandlerFunc {
 			log.Warn().Str("Client ID", claims.ClientID).Msg("Unauthorized, you need to connect first!")
 			//fmt.Printf("pre %v", tokenPref)
 
-			c.JSON(http.StatusUnauthorized, gin.H{
+			apierror.Abort(c, http.StatusUnauthorized, apierror.InvalidToken, gin.H{
 				"success": false,
 				"code":    -3,
 				"message": "Unauthorized, you need to connect first!",
 			})
-			c.Abort()
 			return
 		}
 
@@ -98,27 +136,39 @@ func TokenMiddlewareThirdParty() gin.HandlerFunc {
 			ctx := c.Request.Context()
 			if !CheckTokenInDB(ctx, claims.ClientID, tokenString) {
 				log.Warn().Str("Client ID", claims.ClientID).Msg("Unauthorized, invalid token in DB!")
-				c.JSON(http.StatusUnauthorized, gin.H{
+				apierror.Abort(c, http.StatusUnauthorized, apierror.InvalidToken, gin.H{
 					"success": false,
 					"code":    -3,
 					"message": "Unauthorized, invalid token!",
 				})
-				c.Abort()
 				return
 			}
 		}
 
 		if claims.ExpiresAt < time.Now().Unix() {
 			log.Warn().Str("Client ID", claims.ClientID).Msg("Unauthorized, Token has expired!")
-			c.JSON(http.StatusUnauthorized, gin.H{
+			apierror.Abort(c, http.StatusUnauthorized, apierror.InvalidToken, gin.H{
 				"success": false,
 				"code":    -3,
 				"message": "Unauthorized, Token has expired!",
 			})
-			c.Abort()
 			return
 		}
 
+		client, exists := db.GetClientDetails(claims.ClientID)
+		if !exists || !client.ClientActive {
+			log.Warn().Str("Client ID", claims.ClientID).Msg("Unauthorized, client unknown or disabled!")
+			apierror.Abort(c, http.StatusUnauthorized, apierror.InvalidToken, gin.H{
+				"success": false,
+				"code":    -3,
+				"message": "Unauthorized, client unknown or disabled!",
+			})
+			return
+		}
+
+		c.Set(ContextClientID, claims.ClientID)
+		c.Set(ContextFuzzyLogic, claims.FuzzyLogic)
+		setClientAccess(c, client, claims.Scope, claims.Zones)
 		c.Next()
 	}
 }
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
@@ -22,10 +21,12 @@ import (
 //	@Failure	404		{object}	map[string]interface{}	"No cameras found"
 //	@Failure	400		{object}	map[string]interface{}	"Bad request: Invalid camera ID"
 //	@Param		extra	query		string					false	"Include extra information if 'yes'"
+//	@Security	BearerAuthBackOffice
+//	@Security	ServiceToken
 //	@Router		/fyc/cameras [get]
 func GetCameraAPI(c *gin.Context) {
 	log.Debug().Msg("Get Camera API request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Extract query parameters
 	idStr := c.Query("id")
@@ -124,17 +125,19 @@ func GetCameraAPI(c *gin.Context) {
 //	@Tags			Cameras
 //	@Accept			json
 //	@Produce		json
-//	@Param			Camera	body		db.Camera				true	"Camera data"
-//	@Success		201		{object}	db.Camera				"Camera created successfully"
+//	@Param			Camera	body		db.CameraRequest		true	"Camera data, the ingest secret being generated when needed and not given"
+//	@Success		201		{object}	db.CameraRequest		"Camera created successfully, with the ingest secret issued, returned only in this response"
 //	@Failure		400		{object}	map[string]interface{}	"Invalid request payload"
 //	@Failure		500		{object}	map[string]interface{}	"Failed to create a new camera"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/cameras [post]
 func CreateCameraAPI(c *gin.Context) {
 	log.Debug().Msg("Create Camera API request")
-	ctx := context.Background()
-	var newCam db.Camera
+	ctx := c.Request.Context()
+	var request db.CameraRequest
 
-	if err := c.ShouldBindJSON(&newCam); err != nil {
+	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Err(err).Msg("Invalid input for new camera")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request payload",
@@ -144,6 +147,19 @@ func CreateCameraAPI(c *gin.Context) {
 		return
 	}
 
+	newCam := request.Camera
+	secret, generated, err := db.IssueIngestSecret(newCam.IngestAuth, request.IngestSecret)
+	if err != nil {
+		log.Err(err).Msg("Error issuing camera ingest secret")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create a new camera",
+			"message": err.Error(),
+			"code":    10,
+		})
+		return
+	}
+	newCam.IngestSecret = secret
+
 	log.Info().Msg("Creating new camera")
 	log.Debug().Msg("Checkin zones")
 
@@ -168,7 +184,12 @@ func CreateCameraAPI(c *gin.Context) {
 	db.LoadCameralist()
 	db.CamStartup()
 	log.Info().Int("camera_id", newCam.ID).Msg("Camera created successfully")
-	c.JSON(http.StatusCreated, newCam)
+	// A generated ingest secret is only returned once, here
+	response := db.CameraRequest{Camera: newCam}
+	if generated {
+		response.IngestSecret = secret
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // UpdateCamera godoc
@@ -179,16 +200,18 @@ func CreateCameraAPI(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id		query		int						true	"Camera ID"
-//	@Param			Camera	body		db.Camera				true	"Updated camera data"
+//	@Param			Camera	body		db.CameraUpdateRequest	true	"Updated camera data, the ingest secret being kept when not given"
 //	@Success		200		{object}	map[string]interface{}	"Camera updated successfully"
 //	@Failure		400		{object}	map[string]interface{}	"Invalid request payload or ID mismatch"
 //	@Failure		404		{object}	map[string]interface{}	"Camera not found"
 //	@Failure		500		{object}	map[string]interface{}	"Failed to update camera"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/cameras [put]
 func UpdateCameraAPI(c *gin.Context) {
 	idStr := c.Query("id")
-	var updates db.CameraNoBind
-	ctx := context.Background()
+	var request db.CameraUpdateRequest
+	ctx := c.Request.Context()
 	log.Info().Str("camera_id", idStr).Msg("Updating camera in progress")
 
 	id, err := strconv.Atoi(idStr)
@@ -202,7 +225,7 @@ func UpdateCameraAPI(c *gin.Context) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&updates); err != nil {
+	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Err(err).Msg("Invalid request payload for camera update")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request payload",
@@ -211,6 +234,8 @@ func UpdateCameraAPI(c *gin.Context) {
 		})
 		return
 	}
+	updates := request.CameraNoBind
+	updates.IngestSecret = request.IngestSecret
 
 	if updates.CamID != id {
 		log.Warn().Msg("The ID in the request body does not match the query ID")
@@ -243,15 +268,26 @@ func UpdateCameraAPI(c *gin.Context) {
 		return
 	}
 
+	// A camera switched to an authentication without a secret gets one, only
+	// returned here
+	secret, err := db.EnsureCameraIngestSecret(ctx, id)
+	if err != nil {
+		log.Err(err).Int("Camera ID", id).Msg("Error issuing camera ingest secret")
+	}
+
 	db.LoadCameralist()
 	db.CamStartup()
 	log.Info().Str("camera_id", idStr).Msg("Camera updated successfully")
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message":       "Camera updated successfully",
 		"rows_affected": rowsAffected,
 		"response":      updates,
 		"code":          8,
-	})
+	}
+	if secret != "" {
+		response["ingest_secret"] = secret
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteCameraAPI godoc
@@ -263,10 +299,12 @@ func UpdateCameraAPI(c *gin.Context) {
 //	@Success		200	{object}	map[string]interface{}	"Camera deleted successfully"
 //	@Failure		400	{object}	map[string]interface{}	"Invalid camera ID"
 //	@Failure		500	{object}	map[string]interface{}	"Failed to delete camera"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/cameras [delete]
 func DeleteCameraAPI(c *gin.Context) {
 	idStr := c.Query("id")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if idStr == "" {
 		log.Error().Msg("No camera ID provided for deletion")
@@ -334,10 +372,12 @@ func DeleteCameraAPI(c *gin.Context) {
 //	@Failure		500		{object}	map[string]interface{}	"Internal server error"
 //	@Failure		404		{object}	map[string]interface{}	"No cameras found"
 //	@Failure		400		{object}	map[string]interface{}	"Bad request: Invalid camera ID or state"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/cameraState [put]
 func ChangeCameraStateAPI(c *gin.Context) {
 	log.Debug().Msg("ChangeStateAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"strings"
@@ -22,11 +21,13 @@ import (
 //	@Param			id		query	int		false	"CarDetail ID"
 //	@Param			extra	query	string	false	"Include extra information if 'yes'"
 //	@Success		200		{array}	db.CarDetail
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/carDetails [get]
 func GetCarDetailsAPI(c *gin.Context) {
 	log.Debug().Msg("GetCarDetailsAPI request")
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 	extraReq := strings.ToLower(c.DefaultQuery("extra", "")) // Normalize extra request
 
@@ -179,6 +180,8 @@ func GetCarDetailsAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			CarDetail	body		db.CarDetail	true	"Car detail data"
 //	@Success		201			{object}	db.CarDetail
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/carDetails [post]
 func CreateCarDetailAPI(c *gin.Context) {
 	var carDetail db.CarDetail
@@ -218,7 +221,7 @@ func CreateCarDetailAPI(c *gin.Context) {
 
 	log.Info().Int("image1_size", len(Image1Enc)).Int("image2_size", len(Image2Enc)).Msg("Images decoded successfully")
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	if err := db.CreateCarDetail(ctx, &carDetail); err != nil {
 		log.Err(err).Msg("Error creating new car detail")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -245,6 +248,8 @@ func CreateCarDetailAPI(c *gin.Context) {
 //	@Success		200			{object}	db.CarDetail
 //	@Failure		400			{object}	map[string]interface{}	"Invalid request"
 //	@Failure		404			{object}	map[string]interface{}	"Car detail not found"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/carDetails [put]
 func UpdateCarDetailByIdAPI(c *gin.Context) {
 	log.Debug().Msg("Updating CarDetail")
@@ -306,7 +311,7 @@ func UpdateCarDetailByIdAPI(c *gin.Context) {
 
 	log.Info().Int("image1_size", len(Image1Enc)).Int("image2_size", len(Image2Enc)).Msg("Images decoded successfully")
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.UpdateCarDetail(ctx, id, &updates)
 	if err != nil {
 		log.Err(err).Msg("Error updating car detail by ID")
@@ -346,6 +351,8 @@ func UpdateCarDetailByIdAPI(c *gin.Context) {
 //	@Success		200	{object}	map[string]interface{}	"Car detail deleted successfully"
 //	@Failure		400	{object}	map[string]interface{}	"Invalid request"
 //	@Failure		404	{object}	map[string]interface{}	"Car detail not found"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/carDetails [delete]
 func DeleteCarDetailAPI(c *gin.Context) {
 
@@ -362,7 +369,7 @@ func DeleteCarDetailAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeleteCarDetail(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("Error deleting car detail")
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"fyc/pkg/apierror"
 	"fyc/pkg/db"
 )
 
@@ -21,9 +21,11 @@ import (
 //	@Param			code	query		string	false	"Error code to fetch specific error message"
 //	@Param			lang	query		string	false	"Language of the error message"
 //	@Success		200		{object}	[]db.ErrorMessage
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/errors [get]
 func GetAllErrorCode(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	code := c.Query("code")
 	lang := c.Query("lang")
 
@@ -94,6 +96,8 @@ func GetAllErrorCode(c *gin.Context) {
 //	@Produce		json
 //	@Param			errMsg	body		db.ErrorMessage	true	"Error message object"
 //	@Success		201		{object}	db.ErrorMessage
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/errors [post]
 func CreateErrorMessageAPI(c *gin.Context) {
 	var errMsg db.ErrorMessage
@@ -108,7 +112,7 @@ func CreateErrorMessageAPI(c *gin.Context) {
 	}
 
 	// Create context
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Check if the error code already exists in the database
 	existingErrorMsg, err := db.GetErrorMessageByCode(ctx, errMsg.Code)
@@ -140,6 +144,10 @@ func CreateErrorMessageAPI(c *gin.Context) {
 		return
 	}
 
+	if err := apierror.Load(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload the error catalog")
+	}
+
 	log.Info().Int("code", errMsg.Code).Msg("Error Message created successfully")
 	c.JSON(http.StatusCreated, errMsg)
 }
@@ -154,6 +162,8 @@ func CreateErrorMessageAPI(c *gin.Context) {
 //	@Param			code	query		string			true	"Error message code"
 //	@Param			errMsg	body		db.ErrorMessage	true	"Updated error message object"
 //	@Success		200		{object}	db.ErrorMessage
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/errors [put]
 func UpdateErrorMessageAPI(c *gin.Context) {
 	codeStr := c.Query("code")
@@ -189,7 +199,7 @@ func UpdateErrorMessageAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Check if the error code exists in the database
 	existingErrorMsg, err := db.GetErrorMessageByCode(ctx, errMsg.Code)
@@ -220,6 +230,10 @@ func UpdateErrorMessageAPI(c *gin.Context) {
 		return
 	}
 
+	if err := apierror.Load(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload the error catalog")
+	}
+
 	log.Info().Int("code", errMsg.Code).Msg("Error message updated successfully")
 	c.JSON(http.StatusOK, errMsg)
 }
@@ -231,6 +245,8 @@ func UpdateErrorMessageAPI(c *gin.Context) {
 //	@Tags			Errors
 //	@Param			code	query	string	true	"Error message code"
 //	@Param			lang	query	string	true	"Language of the error message"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/errors [delete]
 func DeleteErrorMessageAPI(c *gin.Context) {
 	codeStr := c.Query("code")
@@ -260,7 +276,7 @@ func DeleteErrorMessageAPI(c *gin.Context) {
 	}
 
 	// Call the DeleteErrorMessage function to remove the specific language entry
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeleteErrorMessage(ctx, code, langQuery)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to delete error message")
@@ -283,6 +299,10 @@ func DeleteErrorMessageAPI(c *gin.Context) {
 		return
 	}
 
+	if err := apierror.Load(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload the error catalog")
+	}
+
 	// Success response
 	log.Info().Int("code", code).Str("lang", langQuery).Msg("Error message language deleted successfully")
 	c.JSON(http.StatusOK, gin.H{
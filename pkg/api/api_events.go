@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/events"
+)
+
+// GetEventsAPI godoc
+//
+//	@Summary		Replay events
+//	@Description	Replay the vehicle movement, capacity change and sign update events published after a stream ID
+//	@Tags			Events
+//	@Produce		json
+//	@Param			from	query		string					false	"Return events published after this stream ID (default: from the beginning)"
+//	@Param			count	query		int						false	"Maximum number of events to return (default 100, max 1000)"
+//	@Param			type	query		string					false	"Event type: vehicle.movement, zone.capacity_changed or sign.updated"
+//	@Success		200		{array}		events.Event			"List of events"
+//	@Header			200		{string}	X-Events-Cursor			"ID of the last event scanned, to pass as from to read the next events"
+//	@Failure		400		{object}	map[string]interface{}	"Invalid from or count"
+//	@Failure		500		{object}	map[string]interface{}	"Internal server error"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
+//	@Router			/fyc/events [get]
+func GetEventsAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	from := c.DefaultQuery("from", "0")
+	eventType := c.Query("type")
+
+	if from != "" && !events.ValidID(from) {
+		log.Warn().Str("from", from).Msg("Invalid events cursor")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid from",
+			"message": "from must be a stream ID such as 1700000000000-0",
+			"code":    12,
+		})
+		return
+	}
+
+	count, err := strconv.ParseInt(c.DefaultQuery("count", "100"), 10, 64)
+	if err != nil || count <= 0 {
+		log.Warn().Str("count", c.Query("count")).Msg("Invalid events count")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid count",
+			"message": "count must be a positive integer",
+			"code":    12,
+		})
+		return
+	}
+	if count > 1000 {
+		count = 1000
+	}
+
+	evts, cursor, err := events.Replay(ctx, from, count, eventType)
+	if err != nil {
+		log.Err(err).Str("from", from).Msg("Error replaying events")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "An unexpected error occurred",
+			"message": "Error replaying events",
+			"code":    10,
+		})
+		return
+	}
+
+	c.Header("X-Events-Cursor", cursor)
+	c.JSON(http.StatusOK, evts)
+}
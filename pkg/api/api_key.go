@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -21,10 +20,12 @@ import (
 //	@Failure		500			{object}	map[string]interface{}	"Internal server error"
 //	@Failure		404			{object}	map[string]interface{}	"No Client found"
 //	@Failure		400			{object}	map[string]interface{}	"Bad request: Invalid client ID"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/apikey [get]
 func GetAllClientCredsApi(c *gin.Context) {
 	log.Debug().Msg("Get Client API request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 
 	if idStr == "" {
@@ -61,12 +62,14 @@ func GetAllClientCredsApi(c *gin.Context) {
 // AddClientCred godoc
 //
 //	@Summary		Add a new client credential
-//	@Description	Add a new client credential to the database
+//	@Description	Add a new client credential to the database. The client secret is generated when not given, and returned only in this response.
 //	@Tags			Client API
 //	@Accept			json
 //	@Produce		json
 //	@Param			clientCred	body		db.ApiKey	true	"Client credential data"
 //	@Success		201			{object}	db.ApiKey
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/apikey [post]
 func AddClientCredAPI(c *gin.Context) {
 	var clientCred db.ApiKey
@@ -82,8 +85,29 @@ func AddClientCredAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	if err := db.AddClientCred(ctx, &clientCred); err != nil {
+	if err := db.ValidateClientScopes(clientCred.Scopes); err != nil {
+		log.Warn().Err(err).Msg("Invalid client scopes")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"message": err.Error(),
+			"code":    12,
+		})
+		return
+	}
+
+	if err := db.NormalizeCertFingerprint(&clientCred.CertFingerprint); err != nil {
+		log.Warn().Err(err).Msg("Invalid client certificate fingerprint")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"message": err.Error(),
+			"code":    12,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	secret, err := db.AddClientCred(ctx, &clientCred)
+	if err != nil {
 		log.Error().Err(err).Msg("Error creating Client API KEY")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create Client API KEY",
@@ -93,6 +117,8 @@ func AddClientCredAPI(c *gin.Context) {
 		return
 	}
 
+	// The secret is only stored hashed, this is the only time it is returned
+	clientCred.ClientSecret = secret
 	log.Info().Str("client_id", clientCred.ClientID).Msg("Client API KEY created successfully")
 	c.JSON(http.StatusCreated, clientCred)
 }
@@ -107,6 +133,8 @@ func AddClientCredAPI(c *gin.Context) {
 //	@Param			client_id	query		string		true	"Client ID"
 //	@Param			clientCred	body		db.ApiKey	true	"Updated client credential data"
 //	@Success		200			{object}	db.ApiKey
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/apikey [put]
 func UpdateClientCredAPI(c *gin.Context) {
 	idStr := c.Query("client_id")
@@ -124,6 +152,26 @@ func UpdateClientCredAPI(c *gin.Context) {
 		return
 	}
 
+	if err := db.ValidateClientScopes(clientCred.Scopes); err != nil {
+		log.Warn().Err(err).Msg("Invalid client scopes")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"message": err.Error(),
+			"code":    12,
+		})
+		return
+	}
+
+	if err := db.NormalizeCertFingerprint(&clientCred.CertFingerprint); err != nil {
+		log.Warn().Err(err).Msg("Invalid client certificate fingerprint")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"message": err.Error(),
+			"code":    12,
+		})
+		return
+	}
+
 	if clientCred.ClientID != idStr {
 		log.Warn().Str("id_param", idStr).Str("id_body", clientCred.ClientID).Msg("ID mismatch between Query and body")
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -134,7 +182,7 @@ func UpdateClientCredAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.UpdateClientCred(ctx, idStr, &clientCred)
 	if err != nil {
 		log.Error().Err(err).Str("client_id", idStr).Msg("Error updating Client API KEY")
@@ -156,6 +204,7 @@ func UpdateClientCredAPI(c *gin.Context) {
 		return
 	}
 
+	clientCred.ClientSecret = ""
 	log.Info().Str("client_id", idStr).Msg("Client API KEY updated successfully")
 	c.JSON(http.StatusOK, clientCred)
 }
@@ -167,13 +216,15 @@ func UpdateClientCredAPI(c *gin.Context) {
 //	@Tags			Client API
 //	@Param			id	query		string	true	"Client ID"
 //	@Success		200	{string}	string	"Client credential deleted successfully"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/apikey [delete]
 func DeleteClientCredAPI(c *gin.Context) {
 	idStr := c.Query("id")
 
 	log.Info().Str("client_id", idStr).Msg("Attempting to delete Client API KEY")
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeleteClientCred(ctx, idStr)
 	if err != nil {
 		log.Error().Err(err).Str("client_id", idStr).Msg("Error deleting Client API KEY")
@@ -211,10 +262,12 @@ func DeleteClientCredAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			id		query		string	false	"Client ID"
 //	@Success		200		{object}	db.ApiKey		"List of enabled clients or a single client"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/clientEnabled [get]
 func GetClientEnabledAPI(c *gin.Context) {
 	log.Debug().Msg("Get Enabled API request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 
 	if idStr != "" {
@@ -268,10 +321,12 @@ func GetClientEnabledAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			id		query		string	false	"Client ID"
 //	@Success		200		{object}	ApiKey		"List of deleted Clients or a Client Client"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/clientsDeleted [get]
 func GetClientDeletedAPI(c *gin.Context) {
 	log.Debug().Msg("Get Client Deleted API request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 
 	if idStr != "" {
@@ -326,10 +381,12 @@ func GetClientDeletedAPI(c *gin.Context) {
 //	@Param			state	query		bool	false	"Client State"
 //	@Param			id		query		int 	false	"Client ID"
 //	@Success		200		{object}	int64		"Number of rows affected by the state change"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/clientState [put]
 func ChangeClientStateAPI(c *gin.Context) {
 	log.Debug().Msg("ChangeStateAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	id := c.Query("id")
 
 	stateStr := c.Query("state")
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -24,9 +23,11 @@ import (
 //	@Param			extra	query	string	false	"Include extra information if 'yes'"
 //	@Param			lpn		query	string	false	"License Plate Number"
 //	@Success		200		{array}	db.PresentCar
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/presentcars [get]
 func GetPresentCarsAPI(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	extra_req := c.DefaultQuery("extra", "false")
 	lpn := c.DefaultQuery("lpn", "")
 
@@ -116,10 +117,12 @@ func GetPresentCarsAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			presentCar	body		db.PresentCar	true	"Present Car data"
 //	@Success		201			{object}	db.PresentCar
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/presentcars [post]
 func CreatePresentCarAPI(c *gin.Context) {
 	var car db.PresentCar
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if err := c.ShouldBindJSON(&car); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -201,6 +204,8 @@ func CreatePresentCarAPI(c *gin.Context) {
 //	@Param			id			path		int				true	"Present Car ID"
 //	@Param			presentCar	body		db.PresentCar	true	"Updated present car data"
 //	@Success		200			{object}	db.PresentCar
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/presentcars/{id} [put]
 func UpdatePresentCarByIdAPI(c *gin.Context) {
 	// Convert ID param to integer
@@ -282,7 +287,7 @@ func UpdatePresentCarByIdAPI(c *gin.Context) {
 
 	updates.TransactionDate = formattedDateTime
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.UpdatePresentCar(ctx, id, &updates)
 	if err != nil {
 		log.Err(err).Msg("Error updating present car by ID")
@@ -322,6 +327,8 @@ func UpdatePresentCarByIdAPI(c *gin.Context) {
 //	@Param			lpn			query		string			true	"string default"	default(A)
 //	@Param			presentCar	body		db.PresentCar	true	"Updated present car data by lpn"
 //	@Success		200			{object}	db.PresentCar
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/presentcars [put]
 func UpdatePresentCarBylpnAPI(c *gin.Context) {
 
@@ -379,7 +386,7 @@ func UpdatePresentCarBylpnAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.UpdatePresentCarByLpn(ctx, lpn, &updates)
 	if err != nil {
 		log.Err(err).Msg("Error updating present car")
@@ -431,6 +438,8 @@ func UpdatePresentCarBylpnAPI(c *gin.Context) {
 //	@Success		200	{object}	string	"Success"
 //	@Failure		400	{object}	string	"Bad Request"
 //	@Failure		404	{object}	string	"Not Found"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/presentcars/{id} [delete]
 func DeletePresentCarAPI(c *gin.Context) {
 
@@ -445,7 +454,7 @@ func DeletePresentCarAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeletePresentCar(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("Error deleting present car")
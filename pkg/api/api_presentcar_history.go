@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -25,6 +24,8 @@ import (
 //	@Success		200		{array}		db.PresentCarHistory	"List of history records"
 //	@Failure		500		{object}	map[string]interface{}	"Internal server error"
 //	@Failure		404		{object}	map[string]interface{}	"No history records found"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/history [get]
 func GetHistoryAPI(c *gin.Context) {
 	extraReq := strings.ToLower(c.DefaultQuery("extra", "false"))
@@ -32,7 +33,7 @@ func GetHistoryAPI(c *gin.Context) {
 	if extraReq == "true" || extraReq == "1" || extraReq == "yes" {
 		log.Info().Msg("Fetching history with extra data")
 
-		ctx := context.Background()
+		ctx := c.Request.Context()
 		hist, err := db.GetAllPresentHistoryExtra(ctx)
 		if err != nil {
 			log.Err(err).Msg("Error fetching history with extra data")
@@ -57,7 +58,7 @@ func GetHistoryAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	hist, err := db.GetAllPresentCarsHistory(ctx)
 	if err != nil {
 		log.Err(err).Msg("Error fetching all history records")
@@ -91,12 +92,14 @@ func GetHistoryAPI(c *gin.Context) {
 //	@Success		200	{object}	db.PresentCarHistory
 //	@Failure		400	{object}	map[string]interface{}	"Invalid LPN format"
 //	@Failure		404	{object}	map[string]interface{}	"History record not found"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/history/{lpn} [get]
 func GetHistoryByLPNAPI(c *gin.Context) {
 	lpn := c.Param("lpn")
 	extraReq := c.Query("extra")
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	hist, err := db.GetPresentCarByLPNHistory(ctx, lpn)
 	if err != nil {
 		log.Err(err).Str("lpn", lpn).Msg("Error retrieving history by LPN")
@@ -139,6 +142,8 @@ func GetHistoryByLPNAPI(c *gin.Context) {
 //	@Success		201		{object}	db.PresentCarHistory	"History record created successfully"
 //	@Failure		400		{object}	map[string]interface{}	"Invalid request payload"
 //	@Failure		500		{object}	map[string]interface{}	"Failed to create a new history record"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/history [post]
 func CreateHistoryAPI(c *gin.Context) {
 	var hist db.PresentCarHistory
@@ -168,7 +173,7 @@ func CreateHistoryAPI(c *gin.Context) {
 	}
 	hist.TransactionDate = formattedDateTime
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	if err := db.CreatePresentCarHistory(ctx, &hist); err != nil {
 		log.Err(err).Msg("Error creating new history")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -196,6 +201,8 @@ func CreateHistoryAPI(c *gin.Context) {
 //	@Failure		400		{object}	map[string]interface{}	"Invalid request payload or ID mismatch"
 //	@Failure		404		{object}	map[string]interface{}	"History record not found"
 //	@Failure		500		{object}	map[string]interface{}	"Failed to update history record"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/history/{id} [put]
 func UpdateHistoryAPI(c *gin.Context) {
 	idStr := c.Param("id")
@@ -245,7 +252,7 @@ func UpdateHistoryAPI(c *gin.Context) {
 	}
 	updates.TransactionDate = formattedDateTime
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.UpdatePresentCarHistory(ctx, id, &updates)
 	if err != nil {
 		log.Err(err).Msg("Error updating history by ID")
@@ -284,6 +291,8 @@ func UpdateHistoryAPI(c *gin.Context) {
 //	@Failure		400	{object}	map[string]interface{}	"Invalid ID format"
 //	@Failure		404	{object}	map[string]interface{}	"History record not found"
 //	@Failure		500	{object}	map[string]interface{}	"Failed to delete history record"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/history/{id} [delete]
 func DeleteHistoryAPI(c *gin.Context) {
 	idStr := c.Param("id")
@@ -298,7 +307,7 @@ func DeleteHistoryAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeletePresentCarHistory(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("Error deleting history")
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"strings"
@@ -21,10 +20,12 @@ import (
 //	@Produce		json
 //	@Param			carpark_id	query		int	false	"CarPark ID"
 //	@Success		200			{object}	db.Settings
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/settings [get]
 func GetSettingsAPI(c *gin.Context) {
 	carParkIDStr := c.Query("carpark_id")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if carParkIDStr != "" {
 		carParkID, err := strconv.Atoi(carParkIDStr)
@@ -38,7 +39,7 @@ func GetSettingsAPI(c *gin.Context) {
 			return
 		}
 		log.Info().Int("carpark_id", carParkID).Msg("Fetching settings by CarPark ID")
-		ctx := context.Background()
+		ctx := c.Request.Context()
 		settings, err := db.GetSettings(ctx, carParkID)
 		if err != nil {
 			log.Error().Err(err).Int("carpark_id", carParkID).Msg("Error retrieving settings")
@@ -79,10 +80,12 @@ func GetSettingsAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			settings	body		db.Settings	true	"Settings data"
 //	@Success		201			{object}	db.Settings
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/settings [post]
 func AddSettingsAPI(c *gin.Context) {
 	var settings db.Settings
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	log.Info().Msg("Attempting to add new settings")
 
@@ -133,6 +136,8 @@ func AddSettingsAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			settings	body		db.SettingsNoBind	true	"Updated settings data"
 //	@Success		200			{object}	db.SettingsNoBind
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/settings [put]
 func UpdateSettingsAPI(c *gin.Context) {
 
@@ -159,7 +164,7 @@ func UpdateSettingsAPI(c *gin.Context) {
 	}
 
 	settings.DefaultLang = strings.ToLower(settings.DefaultLang)
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	err = db.UpdateSettings(ctx, settings, settings.CarParkID)
 	if err != nil {
 		if err.Error() == "no rows updated" {
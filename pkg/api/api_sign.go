@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
@@ -25,10 +24,12 @@ import (
 //	@Failure		500		{object}	map[string]interface{}	"Internal server error"
 //	@Failure		404		{object}	map[string]interface{}	"No sign found"
 //	@Failure		400		{object}	map[string]interface{}	"Bad request: Invalid sign ID"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/sign [get]
 func GetSignAPI(c *gin.Context) {
 	log.Debug().Msg("GetSignAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 	signType := c.DefaultQuery("type", "all") // 'all', 'enabled', or 'deleted'
 	extraReq := c.DefaultQuery("extra", "false")
@@ -112,10 +113,12 @@ func GetSignAPI(c *gin.Context) {
 //	@Success		201		{object}	db.Sign					"sign created successfully"
 //	@Failure		400		{object}	map[string]interface{}	"Invalid request payload"
 //	@Failure		500		{object}	map[string]interface{}	"Failed to create a new sign"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/sign [post]
 func CreateSignAPI(c *gin.Context) {
 	log.Debug().Msg("CreatesignAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	var newSign db.Sign
 
 	if err := c.ShouldBindJSON(&newSign); err != nil {
@@ -160,12 +163,14 @@ func CreateSignAPI(c *gin.Context) {
 //	@Failure		400		{object}	map[string]interface{}	"Invalid request payload or ID mismatch"
 //	@Failure		404		{object}	map[string]interface{}	"sign not found"
 //	@Failure		500		{object}	map[string]interface{}	"Failed to update sign"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/sign [put]
 func UpdateSignAPI(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
 	var updates db.SignNoBind
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	log.Info().Str("sign_id", idStr).Msg("Updating sign")
 
 	if err != nil {
@@ -242,10 +247,12 @@ func UpdateSignAPI(c *gin.Context) {
 //	@Success		200	{object}	map[string]interface{}	"sign deleted successfully"
 //	@Failure		400	{object}	map[string]interface{}	"Invalid sign ID"
 //	@Failure		500	{object}	map[string]interface{}	"Failed to delete sign"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/sign [delete]
 func DeleteSignAPI(c *gin.Context) {
 	idStr := c.Query("id")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if idStr == "" {
 		log.Error().Msg("No sign ID provided for deletion")
@@ -310,10 +317,12 @@ func DeleteSignAPI(c *gin.Context) {
 //	@Failure		500		{object}	map[string]interface{}	"Internal server error"
 //	@Failure		404		{object}	map[string]interface{}	"No sign found"
 //	@Failure		400		{object}	map[string]interface{}	"Bad request: Invalid sign ID or state"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/signState [put]
 func ChangeSigntateAPI(c *gin.Context) {
 	log.Debug().Msg("ChangeStateAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
@@ -389,10 +398,12 @@ func ChangeSigntateAPI(c *gin.Context) {
 //	@Failure		500		{object}	map[string]interface{}	"Internal server error"
 //	@Failure		404		{object}	map[string]interface{}	"No sign found"
 //	@Failure		400		{object}	map[string]interface{}	"Bad request: Invalid sign ID"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/signEnabled [get]
 func GetSignEnabledAPI(c *gin.Context) {
 	log.Debug().Msg("GetsignEnabledAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 
 	if idStr != "" {
@@ -460,10 +471,12 @@ func GetSignEnabledAPI(c *gin.Context) {
 //	@Failure		500		{object}	map[string]interface{}	"Internal server error"
 //	@Failure		404		{object}	map[string]interface{}	"No sign found"
 //	@Failure		400		{object}	map[string]interface{}	"Bad request: Invalid sign ID"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/signDeleted [get]
 func GetSignDeletedAPI(c *gin.Context) {
 	log.Debug().Msg("GetsignDeletedAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 
 	if idStr != "" {
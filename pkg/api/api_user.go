@@ -1,13 +1,16 @@
 package api
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/rs/zerolog/log"
 
+	"fyc/middleware"
 	"fyc/pkg/db"
+	"fyc/pkg/password"
+	"fyc/pkg/rbac"
 )
 
 // GetUsersAPI godoc
@@ -21,10 +24,12 @@ import (
 //	@Success		200			{array}		db.User					"List of Users or a single User"
 //	@Failure		500			{object}	map[string]interface{}	"Internal server error"
 //	@Failure		404			{object}	map[string]interface{}	"No user found"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/users [get]
 func GetUsersAPI(c *gin.Context) {
 	log.Debug().Msg("GetUsersAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	username := c.Query("username")
 
 	// Check if username is provided to fetch a specific user
@@ -79,6 +84,15 @@ func GetUsersAPI(c *gin.Context) {
 	c.JSON(http.StatusOK, users)
 }
 
+// AddUserRequest is the user to create, the password being stored hashed
+type AddUserRequest struct {
+	UserName  string `json:"username" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	FirstName string `json:"first_name" binding:"required"`
+	LastName  string `json:"last_name" binding:"required"`
+	Role      string `json:"role" binding:"required"`
+}
+
 // AddUserCred godoc
 //
 //	@Summary		Add a new User
@@ -86,15 +100,17 @@ func GetUsersAPI(c *gin.Context) {
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
-//	@Param			User	body		db.User	true	"User data"
+//	@Param			User	body		AddUserRequest	true	"User data"
 //	@Success		201		{object}	db.User
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/user [post]
 func AddUserAPI(c *gin.Context) {
-	var user db.User
+	var request AddUserRequest
 
 	log.Info().Msg("Attempting to add new user")
 
-	if err := c.ShouldBindJSON(&user); err != nil {
+	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Error().Err(err).Msg("Invalid request payload for user creation")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request payload",
@@ -104,7 +120,39 @@ func AddUserAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	if err := password.Validate(request.UserName, request.Password); err != nil {
+		log.Warn().Str("UserName", request.UserName).Msg("Password does not meet the policy")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid password",
+			"message": err.Error(),
+			"code":    12,
+		})
+		return
+	}
+
+	if !rbac.RoleExists(request.Role) {
+		log.Warn().Str("Role", request.Role).Msg("Unknown role for user creation")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid role",
+			"message": "Unknown role " + request.Role,
+			"code":    12,
+		})
+		return
+	}
+
+	if !callerGrants(c, rbac.Permissions(request.Role)) {
+		return
+	}
+
+	user := db.User{
+		UserName:  request.UserName,
+		Password:  request.Password,
+		FirstName: request.FirstName,
+		LastName:  request.LastName,
+		Role:      request.Role,
+	}
+
+	ctx := c.Request.Context()
 	if err := db.AddUser(ctx, &user); err != nil {
 		log.Error().Err(err).Msg("Error creating user")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -122,21 +170,31 @@ func AddUserAPI(c *gin.Context) {
 // UpdateClientCred godoc
 //
 //	@Summary		Update a client credential
-//	@Description	Update an existing User by ID
+//	@Description	Update an existing User by ID, the password and the role are not changed. The optional is_enabled field enables or disables the user, disabling it revoking its sessions.
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
 //	@Param			username	query		string	true	"Client ID"
 //	@Param			clientCred	body		db.User	true	"Updated client credential data"
 //	@Success		200			{object}	db.User
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/user [put]
 func UpdateUserAPI(c *gin.Context) {
 	usernameStr := c.Query("username")
 
 	log.Info().Str("username", usernameStr).Msg("Attempting to update User")
 
+	// is_enabled is not part of the user as serialized
 	var user db.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var state struct {
+		IsEnabled *bool `json:"is_enabled"`
+	}
+	err := c.ShouldBindBodyWith(&user, binding.JSON)
+	if err == nil {
+		err = c.ShouldBindBodyWith(&state, binding.JSON)
+	}
+	if err != nil {
 		log.Error().Err(err).Msg("Invalid request payload for User update")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request payload",
@@ -156,7 +214,24 @@ func UpdateUserAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	// The role is only changed by /backoffice/assignRole, and users with more
+	// permissions than the caller are left alone
+	ctx := c.Request.Context()
+	current, err := db.GetUserByUsername(ctx, usernameStr)
+	if err != nil {
+		log.Err(err).Str("username", usernameStr).Msg("Error retrieving User by username")
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+			"code":    9,
+		})
+		return
+	}
+	if !callerGrants(c, rbac.Permissions(current.Role)) {
+		return
+	}
+	user.Role = current.Role
+
 	rowsAffected, err := db.UpdateUser(ctx, usernameStr, &user)
 	if err != nil {
 		log.Error().Err(err).Str("client_id", usernameStr).Msg("Error updating USER")
@@ -178,6 +253,18 @@ func UpdateUserAPI(c *gin.Context) {
 		return
 	}
 
+	if state.IsEnabled != nil {
+		if _, err := db.SetUserEnabled(ctx, usernameStr, *state.IsEnabled, c.GetString(middleware.ContextUsername)); err != nil {
+			log.Error().Err(err).Str("username", usernameStr).Msg("Error setting user state")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to update User",
+				"message": err.Error(),
+				"code":    10,
+			})
+			return
+		}
+	}
+
 	log.Info().Str("username", usernameStr).Msg("User updated successfully")
 	c.JSON(http.StatusOK, user)
 }
@@ -189,11 +276,13 @@ func UpdateUserAPI(c *gin.Context) {
 //	@Tags			Users
 //	@Param			username	query		string	true	"Username"
 //	@Success		200			{string}	string	"User deleted successfully"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/user [delete]
 func DeleteUserCredAPI(c *gin.Context) {
 	userStr := c.Query("username")
 	log.Info().Str("User", userStr).Msg("Attempting to delete user")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeleteUser(ctx, userStr)
 	if err != nil {
 		log.Error().Err(err).Str("user", userStr).Msg("Error deleting user")
@@ -221,3 +310,20 @@ func DeleteUserCredAPI(c *gin.Context) {
 		"code":    8,
 	})
 }
+
+// callerGrants checks that the caller holds every one of the permissions, so
+// that nobody creates or manages a user with more than it is allowed itself,
+// having answered the request otherwise.
+func callerGrants(c *gin.Context, permissions []string) bool {
+	if rbac.Grants(c.GetString(middleware.ContextRole), permissions) {
+		return true
+	}
+
+	log.Warn().Str("Username", c.GetString(middleware.ContextUsername)).Str("Role", c.GetString(middleware.ContextRole)).Strs("Permissions", permissions).Msg("Permissions beyond the role of the caller refused")
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":   "Forbidden",
+		"message": "You cannot manage a user with permissions you do not have",
+		"code":    14,
+	})
+	return false
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -23,9 +22,11 @@ import (
 //	@Param			id		query	int		false	"Zone ID"
 //	@Param			extra	query	bool	false	"Include extra information if 'true'"
 //	@Success		200		{array}	db.Zone	"List of zones or a single zone"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zones [get]
 func GetZonesAPI(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	extraReq := strings.ToLower(c.DefaultQuery("extra", "false"))
 	idStr := c.Query("id")
 
@@ -133,10 +134,12 @@ func GetZonesAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			zone	body		db.Zone	true	"Zone data"
 //	@Success		201		{object}	db.Zone
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zones [post]
 func CreateZoneAPI(c *gin.Context) {
 	var zone db.Zone
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Bind the JSON request to the zone struct
 	if err := c.ShouldBindJSON(&zone); err != nil {
@@ -199,6 +202,8 @@ func CreateZoneAPI(c *gin.Context) {
 //	@Param			id		query		int		true	"Zone ID"
 //	@Param			zone	body		db.Zone	true	"Updated zone data"
 //	@Success		200		{object}	db.Zone
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zones [put]
 func UpdateZoneIdAPI(c *gin.Context) {
 	// Convert ID param to integer
@@ -215,7 +220,7 @@ func UpdateZoneIdAPI(c *gin.Context) {
 	}
 
 	var updates db.ZoneNoBind
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if err := c.ShouldBindJSON(&updates); err != nil {
 		log.Err(err).Msg("Invalid request payload for zone update")
@@ -297,6 +302,8 @@ func UpdateZoneIdAPI(c *gin.Context) {
 //	@Success		200	{object}	map[string]interface{}	"Zone deleted successfully"
 //	@Failure		400	{object}	map[string]interface{}	"Invalid request"
 //	@Failure		404	{object}	map[string]interface{}	"Zone not found"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zones [delete]
 func DeleteZoneAPI(c *gin.Context) {
 	idStr := c.Query("id")
@@ -311,7 +318,7 @@ func DeleteZoneAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeleteZone(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("Error deleting Zone")
@@ -347,10 +354,12 @@ func DeleteZoneAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			id		query		string	false	"Zone ID"
 //	@Success		200		{array}		db.Zone		"List of enabled zones or a single zone"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zonesEnabled [get]
 func GetZoneEnabledAPI(c *gin.Context) {
 	log.Debug().Msg("Get Zone EnabledAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 
 	if idStr != "" {
@@ -415,10 +424,12 @@ func GetZoneEnabledAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			id		query		string	false	"Zone ID"
 //	@Success		200		{object}	Zone		"List of Deleted zones or a single zone"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zonesDeleted [get]
 func GetZoneDeletedAPI(c *gin.Context) {
 	log.Debug().Msg("Get Zone DeletedAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("id")
 
 	if idStr != "" {
@@ -484,10 +495,12 @@ func GetZoneDeletedAPI(c *gin.Context) {
 //	@Param			state	query		bool	false	"Zone State"
 //	@Param			id		query		int 	false	"Zone ID"
 //	@Success		200		{object}	int		"Number of rows affected by the state change"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zoneState [put]
 func ChangeZoneStateAPI(c *gin.Context) {
 	log.Debug().Msg("Change State API request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
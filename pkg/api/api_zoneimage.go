@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -24,9 +23,11 @@ import (
 //	@Param			extra		query	bool	false	"Include extra information if 'yes'"
 //	@Param			typeImage	query	string	false	"choose the image type Small or Large (small or sm for Small Images / lg or large for Large)"
 //	@Success		200			{array}	db.ImageZone
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zonesImages [get]
 func GetAllImageZonesAPI(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	log.Info().Msg("GetAllImageZonesAPI called")
 
@@ -165,6 +166,8 @@ func GetAllImageZonesAPI(c *gin.Context) {
 //	@Produce		json
 //	@Param			ImageZone	body		db.ImageZone	true	"Zone image data"
 //	@Success		201			{object}	db.ImageZone
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zonesImage [post]
 func CreateZoneImageAPI(c *gin.Context) {
 	var zoneImage db.ImageZone
@@ -214,7 +217,7 @@ func CreateZoneImageAPI(c *gin.Context) {
 	log.Debug().Msgf("Image Lenght %v", len(ImageLgEnc))
 	log.Debug().Msgf("Image Lenght %v", len(ImageSmEnc))
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if err := db.CreateZoneImage(ctx, &zoneImage); err != nil {
 		log.Err(err).Msg("Error creating new zone image")
@@ -239,6 +242,8 @@ func CreateZoneImageAPI(c *gin.Context) {
 //	@Param			id		path		int				true	"Zone ID"
 //	@Param			Image	body		db.ImageZone	true	"Updated zone image data"
 //	@Success		200		{object}	db.Zone
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zonesImage/{id} [put]
 func UpdateZoneImageByIdAPI(c *gin.Context) {
 	idStr := c.Param("id")
@@ -253,7 +258,7 @@ func UpdateZoneImageByIdAPI(c *gin.Context) {
 	}
 
 	var updates db.ImageZoneNoBind
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if err := c.ShouldBindJSON(&updates); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -338,6 +343,8 @@ func UpdateZoneImageByIdAPI(c *gin.Context) {
 //	@Success		200	{object}	map[string]interface{}	"Zone image deleted successfully"
 //	@Failure		400	{object}	map[string]interface{}	"Invalid request"
 //	@Failure		404	{object}	map[string]interface{}	"Zone image not found"
+//	@Security		BearerAuthBackOffice
+//	@Security		ServiceToken
 //	@Router			/fyc/zonesImage/{id} [delete]
 func DeleteZoneImageAPI(c *gin.Context) {
 
@@ -352,7 +359,7 @@ func DeleteZoneImageAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeleteZoneImage(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("Error deleting zone image")
@@ -0,0 +1,273 @@
+// Package apierror implements the response envelope of the v2 third-party API
+// and its catalog of stable error codes, with messages localized from the
+// errors table.
+//
+// Handlers and middlewares shared with v1 call Abort with both the v2 code and
+// the v1 body, so that v1 responses are left unchanged.
+package apierror
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/db"
+)
+
+const (
+	contextVersion  = "api_version"
+	defaultLanguage = "en"
+)
+
+// Response is the envelope of every v2 response.
+type Response struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   *Error      `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    Code   `json:"code"`
+	Key     string `json:"key"`
+	Message string `json:"message"`
+	// Detail is an untranslated explanation meant for the developer
+	Detail string `json:"detail,omitempty"`
+}
+
+// CatalogEntry documents an error code with its messages.
+type CatalogEntry struct {
+	Code     Code              `json:"code"`
+	Key      string            `json:"key"`
+	Status   int               `json:"http_status"`
+	Messages map[string]string `json:"messages"`
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = map[Code]db.ErrorMessage{}
+)
+
+// V2 marks the requests of the route group as part of the v2 API.
+func V2() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(contextVersion, 2)
+		c.Next()
+	}
+}
+
+func IsV2(c *gin.Context) bool {
+	return c.GetInt(contextVersion) == 2
+}
+
+// Language returns the language of the request, from the language parameter
+// or else, on v2, the preferred language of the Accept-Language header.
+func Language(c *gin.Context) string {
+	if language := strings.ToLower(strings.TrimSpace(c.Query("language"))); language != "" {
+		return language
+	}
+	if !IsV2(c) {
+		return defaultLanguage
+	}
+
+	if language := PreferredLanguage(c.GetHeader("Accept-Language")); language != "" {
+		return language
+	}
+	return defaultLanguage
+}
+
+// PreferredLanguage returns the primary subtag of the preferred language of an
+// Accept-Language header, empty when none is acceptable.
+func PreferredLanguage(header string) string {
+	type preference struct {
+		language string
+		quality  float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		// Only the primary subtag is used, "ar-SA" being served in "ar"
+		tag, _, _ = strings.Cut(tag, "-")
+		preferences = append(preferences, preference{tag, quality})
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	if len(preferences) > 0 && preferences[0].quality > 0 {
+		return preferences[0].language
+	}
+	return ""
+}
+
+// Message returns the message of the code in the language, falling back to
+// English and to the default catalog.
+func Message(code Code, language string) string {
+	catalogMu.RLock()
+	stored, ok := catalog[code]
+	catalogMu.RUnlock()
+
+	if ok {
+		if message := stored.Messages[language]; message != "" {
+			return message
+		}
+	}
+	if message := defaults[code].Messages[language]; message != "" {
+		return message
+	}
+	if ok {
+		if message := stored.Messages[defaultLanguage]; message != "" {
+			return message
+		}
+	}
+	return defaults[code].Messages[defaultLanguage]
+}
+
+func key(code Code) string {
+	if definition, ok := defaults[code]; ok {
+		return definition.Key
+	}
+
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	return catalog[code].Key
+}
+
+// Fail writes the v2 error envelope and aborts the request.
+func Fail(c *gin.Context, status int, code Code, detail string) {
+	c.AbortWithStatusJSON(status, Response{
+		Success: false,
+		Error: &Error{
+			Code:    code,
+			Key:     key(code),
+			Message: Message(code, Language(c)),
+			Detail:  detail,
+		},
+	})
+}
+
+// Abort answers the v2 envelope with code on v2 routes and the legacy body on
+// v1 routes. On v2 the legacy message is kept as detail for client errors.
+func Abort(c *gin.Context, status int, code Code, legacy gin.H) {
+	if !IsV2(c) {
+		c.AbortWithStatusJSON(status, legacy)
+		return
+	}
+
+	detail := ""
+	if status < http.StatusInternalServerError {
+		if message, ok := legacy["message"].(string); ok {
+			detail = message
+		} else if message, ok := legacy["error"].(string); ok {
+			detail = message
+		}
+	}
+	Fail(c, status, code, detail)
+}
+
+// Respond writes data in the v2 envelope on v2 routes and as is on v1 routes.
+func Respond(c *gin.Context, status int, data interface{}) {
+	if !IsV2(c) {
+		c.JSON(status, data)
+		return
+	}
+	c.JSON(status, Response{Success: true, Data: data})
+}
+
+// Seed adds the missing codes of the default catalog to the errors table,
+// leaving the edited ones untouched, and loads the catalog.
+func Seed(ctx context.Context) error {
+	existing, err := db.GetErrorMessage(ctx)
+	if err != nil {
+		return err
+	}
+
+	stored := make(map[int]bool, len(existing))
+	for _, message := range existing {
+		stored[message.Code] = true
+	}
+
+	for code, definition := range defaults {
+		if stored[int(code)] {
+			continue
+		}
+
+		err := db.CreateErrorMessage(ctx, &db.ErrorMessage{
+			Code:     int(code),
+			Key:      definition.Key,
+			Status:   definition.Status,
+			Messages: definition.Messages,
+		})
+		if err != nil {
+			return fmt.Errorf("error seeding error code %d: %w", code, err)
+		}
+	}
+
+	return Load(ctx)
+}
+
+// Load refreshes the catalog from the errors table.
+func Load(ctx context.Context) error {
+	messages, err := db.GetErrorMessage(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[Code]db.ErrorMessage, len(messages))
+	for _, message := range messages {
+		loaded[Code(message.Code)] = message
+	}
+
+	catalogMu.Lock()
+	catalog = loaded
+	catalogMu.Unlock()
+
+	log.Debug().Int("codes", len(loaded)).Msg("Error catalog loaded")
+	return nil
+}
+
+// Catalog lists the v2 error codes with their messages.
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(defaults))
+	for code, definition := range defaults {
+		entry := CatalogEntry{Code: code, Key: definition.Key, Status: definition.Status, Messages: map[string]string{}}
+		for language, message := range definition.Messages {
+			entry.Messages[language] = message
+		}
+
+		catalogMu.RLock()
+		if stored, ok := catalog[code]; ok {
+			for language, message := range stored.Messages {
+				entry.Messages[language] = message
+			}
+		}
+		catalogMu.RUnlock()
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+	return entries
+}
@@ -0,0 +1,79 @@
+package apierror
+
+import (
+	"testing"
+
+	"fyc/pkg/db"
+)
+
+func TestPreferredLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"empty", "", ""},
+		{"single", "fr", "fr"},
+		{"uppercase", "AR", "ar"},
+		{"region", "ar-SA", "ar"},
+		{"first of equal qualities", "fr, en", "fr"},
+		{"highest quality", "en;q=0.5, ar;q=0.9, fr;q=0.7", "ar"},
+		{"default quality", "en;q=0.8, fr", "fr"},
+		{"spaces", " fr-FR ; q=0.4 , en ; q=0.6 ", "en"},
+		{"invalid quality", "fr;q=abc, en;q=0.5", "fr"},
+		{"wildcard ignored", "*, en;q=0.1", "en"},
+		{"wildcard only", "*", ""},
+		{"nothing acceptable", "fr;q=0, en;q=0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PreferredLanguage(tt.header); got != tt.want {
+				t.Errorf("PreferredLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	catalogMu.Lock()
+	previous := catalog
+	catalog = map[Code]db.ErrorMessage{
+		CarNotFound: {Code: int(CarNotFound), Messages: map[string]string{
+			"en": "Stored car not found",
+			"fr": "Véhicule stocké introuvable",
+		}},
+		Code(9999): {Code: 9999, Messages: map[string]string{
+			"en": "Stored only",
+		}},
+	}
+	catalogMu.Unlock()
+	t.Cleanup(func() {
+		catalogMu.Lock()
+		catalog = previous
+		catalogMu.Unlock()
+	})
+
+	tests := []struct {
+		name     string
+		code     Code
+		language string
+		want     string
+	}{
+		{"stored language", CarNotFound, "fr", "Véhicule stocké introuvable"},
+		{"default language not stored", CarNotFound, "ar", defaults[CarNotFound].Messages["ar"]},
+		{"stored english for unknown language", CarNotFound, "de", "Stored car not found"},
+		{"default catalog when not stored", PictureNotFound, "fr", defaults[PictureNotFound].Messages["fr"]},
+		{"default english when not stored", PictureNotFound, "de", defaults[PictureNotFound].Messages["en"]},
+		{"stored code missing from defaults", Code(9999), "fr", "Stored only"},
+		{"unknown code", Code(9998), "en", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Message(tt.code, tt.language); got != tt.want {
+				t.Errorf("Message(%d, %q) = %q, want %q", tt.code, tt.language, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package apierror
+
+import "net/http"
+
+// Code is a stable error code of the v2 API. Codes are never reused, their
+// messages are kept in the errors table and can be translated from the API.
+type Code int
+
+const (
+	InvalidRequest     Code = 1000
+	MissingParameter   Code = 1001
+	InvalidParameter   Code = 1002
+	Unauthorized       Code = 1100
+	InvalidToken       Code = 1101
+	ClientDisabled     Code = 1102
+	ForbiddenScope     Code = 1103
+	NotFound           Code = 1200
+	CarNotFound        Code = 1201
+	PictureNotFound    Code = 1202
+	CandidateNotFound  Code = 1203
+	WebhookNotFound    Code = 1204
+	SettingsNotFound   Code = 1205
+	NotAcceptable      Code = 1300
+	RateLimited        Code = 1400
+	TooManyAttempts    Code = 1401
+	InternalError      Code = 1500
+	ServiceUnavailable Code = 1503
+)
+
+type definition struct {
+	Key      string
+	Status   int
+	Messages map[string]string
+}
+
+// Default catalog, seeded into the errors table when a code is missing
+var defaults = map[Code]definition{
+	InvalidRequest: {"invalid_request", http.StatusBadRequest, map[string]string{
+		"en": "The request is invalid",
+		"fr": "Requête invalide",
+		"ar": "الطلب غير صالح",
+	}},
+	MissingParameter: {"missing_parameter", http.StatusBadRequest, map[string]string{
+		"en": "A required parameter is missing",
+		"fr": "Un paramètre obligatoire est manquant",
+		"ar": "معامل مطلوب مفقود",
+	}},
+	InvalidParameter: {"invalid_parameter", http.StatusBadRequest, map[string]string{
+		"en": "A parameter has an invalid value",
+		"fr": "Un paramètre a une valeur invalide",
+		"ar": "قيمة معامل غير صالحة",
+	}},
+	Unauthorized: {"unauthorized", http.StatusUnauthorized, map[string]string{
+		"en": "Authentication is required",
+		"fr": "Authentification requise",
+		"ar": "المصادقة مطلوبة",
+	}},
+	InvalidToken: {"invalid_token", http.StatusUnauthorized, map[string]string{
+		"en": "The access token is invalid, expired or revoked",
+		"fr": "Le jeton d'accès est invalide, expiré ou révoqué",
+		"ar": "رمز الدخول غير صالح أو منتهي الصلاحية أو ملغى",
+	}},
+	ClientDisabled: {"client_disabled", http.StatusForbidden, map[string]string{
+		"en": "The client is disabled",
+		"fr": "Le client est désactivé",
+		"ar": "العميل معطل",
+	}},
+	ForbiddenScope: {"forbidden_scope", http.StatusForbidden, map[string]string{
+		"en": "The client is not allowed to use this operation",
+		"fr": "Le client n'est pas autorisé à utiliser cette opération",
+		"ar": "غير مسموح للعميل باستخدام هذه العملية",
+	}},
+	NotFound: {"not_found", http.StatusNotFound, map[string]string{
+		"en": "The resource was not found",
+		"fr": "Ressource introuvable",
+		"ar": "المورد غير موجود",
+	}},
+	CarNotFound: {"car_not_found", http.StatusNotFound, map[string]string{
+		"en": "No car was found for this license plate",
+		"fr": "Aucun véhicule trouvé pour cette plaque",
+		"ar": "لم يتم العثور على سيارة بهذه اللوحة",
+	}},
+	PictureNotFound: {"picture_not_found", http.StatusNotFound, map[string]string{
+		"en": "The picture was not found",
+		"fr": "Image introuvable",
+		"ar": "لم يتم العثور على الصورة",
+	}},
+	CandidateNotFound: {"candidate_not_found", http.StatusNotFound, map[string]string{
+		"en": "The candidate was not found or expired, please search again",
+		"fr": "Candidat introuvable ou expiré, veuillez relancer la recherche",
+		"ar": "المرشح غير موجود أو منتهي الصلاحية، يرجى البحث مرة أخرى",
+	}},
+	WebhookNotFound: {"webhook_not_found", http.StatusNotFound, map[string]string{
+		"en": "The webhook subscription was not found",
+		"fr": "Abonnement webhook introuvable",
+		"ar": "اشتراك الويب هوك غير موجود",
+	}},
+	SettingsNotFound: {"settings_not_found", http.StatusNotFound, map[string]string{
+		"en": "The car park settings were not found",
+		"fr": "Paramètres du parking introuvables",
+		"ar": "إعدادات الموقف غير موجودة",
+	}},
+	NotAcceptable: {"not_acceptable", http.StatusNotAcceptable, map[string]string{
+		"en": "None of the accepted formats can be produced",
+		"fr": "Aucun des formats acceptés ne peut être produit",
+		"ar": "لا يمكن إنتاج أي من التنسيقات المقبولة",
+	}},
+	RateLimited: {"rate_limited", http.StatusTooManyRequests, map[string]string{
+		"en": "Too many requests, please retry later",
+		"fr": "Trop de requêtes, veuillez réessayer plus tard",
+		"ar": "عدد كبير جدًا من الطلبات، يرجى المحاولة لاحقًا",
+	}},
+	TooManyAttempts: {"too_many_attempts", http.StatusTooManyRequests, map[string]string{
+		"en": "Too many attempts, please retry later",
+		"fr": "Trop de tentatives, veuillez réessayer plus tard",
+		"ar": "محاولات كثيرة جدًا، يرجى المحاولة لاحقًا",
+	}},
+	InternalError: {"internal_error", http.StatusInternalServerError, map[string]string{
+		"en": "An unexpected error occurred. Please try again later.",
+		"fr": "Une erreur inattendue est survenue. Veuillez réessayer plus tard.",
+		"ar": "حدث خطأ غير متوقع. يرجى المحاولة لاحقًا.",
+	}},
+	ServiceUnavailable: {"service_unavailable", http.StatusServiceUnavailable, map[string]string{
+		"en": "The service is temporarily unavailable",
+		"fr": "Le service est temporairement indisponible",
+		"ar": "الخدمة غير متاحة مؤقتًا",
+	}},
+}
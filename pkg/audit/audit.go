@@ -0,0 +1,164 @@
+// Package audit takes the snapshots of the entities recorded in the audit log.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"fyc/pkg/db"
+	"fyc/pkg/rbac"
+)
+
+const redacted = "[redacted]"
+
+// Entities audited under the permission of another module, the other ones
+// being named by their module
+const (
+	EntityZoneImage       = "zone_image"
+	EntityErrorMessage    = "error_message"
+	EntityWebhook         = "webhook"
+	EntityWebhookDelivery = "webhook_delivery"
+	EntitySessions        = "sessions"
+	EntityLoginLockout    = "login_lockout"
+)
+
+// Fields never written to the audit log
+var secretFields = []string{"password", "new_password", "current_password", "cam_password", "ingest_secret", "sign_password", "client_secret", "api_key"}
+
+// Pictures, too large for the audit log
+var imageFields = []string{"image_s", "image_l"}
+
+// Snapshot returns the current state of the entity, named by its module or one
+// of the Entity kinds, nil when it does not exist (anymore) or has no state.
+func Snapshot(ctx context.Context, module string, key string) (map[string]interface{}, error) {
+	var (
+		entity interface{}
+		err    error
+	)
+
+	switch module {
+	case EntityLoginLockout:
+		// Counters only, the unlock itself is recorded
+		return nil, nil
+	case EntitySessions:
+		if key == "" {
+			return nil, nil
+		}
+		sessions, sessionsErr := db.GetUserSessions(ctx, key)
+		entity, err = map[string]interface{}{"sessions": sessions}, sessionsErr
+	case EntityZoneImage, EntityErrorMessage, EntityWebhook, EntityWebhookDelivery:
+		id, convErr := strconv.Atoi(key)
+		if convErr != nil {
+			return nil, nil
+		}
+		switch module {
+		case EntityZoneImage:
+			entity, err = db.GetZoneImageByID(ctx, id)
+		case EntityErrorMessage:
+			entity, err = db.GetErrorMessageByCode(ctx, id)
+		case EntityWebhook:
+			entity, err = db.GetWebhookSubscriptionByID(ctx, id)
+		default:
+			entity, err = db.GetWebhookDeliveryByID(ctx, id)
+		}
+	case rbac.ModuleSettings:
+		// A single car park is configured, whatever the key
+		entity, err = db.GetAllSettings(ctx)
+	case rbac.ModuleUsers:
+		if key == "" {
+			return nil, nil
+		}
+		entity, err = db.GetUserByUsername(ctx, key)
+	case rbac.ModuleRoles:
+		if key == "" {
+			return nil, nil
+		}
+		entity, err = db.GetRoleByName(ctx, key)
+	case rbac.ModuleClients:
+		if key == "" {
+			return nil, nil
+		}
+		entity, err = db.GetClientById(ctx, key)
+	case rbac.ModuleZones, rbac.ModuleCameras, rbac.ModuleSigns:
+		id, convErr := strconv.Atoi(key)
+		if convErr != nil {
+			return nil, nil
+		}
+		switch module {
+		case rbac.ModuleZones:
+			entity, err = db.GetZoneByID(ctx, id)
+		case rbac.ModuleCameras:
+			entity, err = db.GetCameraByIDExtra(ctx, id)
+		default:
+			entity, err = db.GetSignById(ctx, id)
+		}
+	default:
+		return nil, fmt.Errorf("module %s is not audited", module)
+	}
+
+	// Missing entities are reported as errors by the getters
+	if err != nil {
+		return nil, nil
+	}
+	return ToMap(entity)
+}
+
+// ToMap converts the entity to its JSON representation, secrets redacted.
+func ToMap(entity interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audit snapshot: %w", err)
+	}
+
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, fmt.Errorf("error decoding audit snapshot: %w", err)
+	}
+	Redact(snapshot)
+	return snapshot, nil
+}
+
+// Redact hides the secrets and the pictures of the snapshot.
+func Redact(snapshot map[string]interface{}) {
+	for _, field := range append(secretFields, imageFields...) {
+		if value, ok := snapshot[field]; ok && value != nil && value != "" {
+			snapshot[field] = redacted
+		}
+	}
+}
+
+// ParseFilter reads the audit log filters of the query. Dates are UTC, either
+// 2006-01-02 or 2006-01-02 15:04:05, a date alone covering the whole day.
+func ParseFilter(c *gin.Context) (db.AuditFilter, error) {
+	filter := db.AuditFilter{
+		Username: c.Query("username"),
+		Module:   c.Query("module"),
+		Action:   c.Query("action"),
+		EntityID: c.Query("entity_id"),
+	}
+
+	for name, target := range map[string]*string{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		if t, err := time.Parse(time.DateTime, value); err == nil {
+			*target = t.Format(time.DateTime)
+			continue
+		}
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be formatted as %s or %s", name, time.DateOnly, time.DateTime)
+		}
+		if name == "to" {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		*target = t.Format(time.DateTime)
+	}
+	return filter, nil
+}
@@ -0,0 +1,102 @@
+package backoffice
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/audit"
+	"fyc/pkg/db"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// GetAuditLogAPI godoc
+//
+//	@Summary		Get the audit log
+//	@Description	Get the changes made to zones, cameras, signs, clients, users, roles and settings, most recent first, with the state before and after each change
+//	@Tags			Backoffice - Audit
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			username	query		string	false	"Acting user"
+//	@Param			module		query		string	false	"Module"	Enums(zones, cameras, signs, clients, users, roles, settings)
+//	@Param			action		query		string	false	"Action"	Enums(create, update, delete)
+//	@Param			entity_id	query		string	false	"ID of the changed entity"
+//	@Param			from		query		string	false	"From UTC date, 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			to			query		string	false	"To UTC date, 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			limit		query		int		false	"Maximum number of entries"	default(50)
+//	@Param			offset		query		int		false	"Number of entries to skip"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Router			/backoffice/getAuditLog [get]
+func GetAuditLogAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	filter, err := audit.ParseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	filter.Limit, filter.Offset, err = parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	entries, total, err := db.GetAuditLogs(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving audit log")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if entries == nil {
+		entries = []db.AuditLog{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+		"data":    entries,
+	})
+}
+
+// parsePage reads the limit and offset of the query.
+func parsePage(c *gin.Context) (int, int, error) {
+	limit, offset := defaultAuditLimit, 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, errors.New("Invalid request. limit must be a positive integer.")
+		}
+		limit = min(parsed, maxAuditLimit)
+	}
+	if value := c.Query("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, errors.New("Invalid request. offset must be a positive integer.")
+		}
+		offset = parsed
+	}
+	return limit, offset, nil
+}
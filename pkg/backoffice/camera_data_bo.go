@@ -24,7 +24,7 @@ import (
 //	@Param			extra		query	string	false	"Include extra information if 'true'"
 //	@Router			/backoffice/getCameras [get]
 func GetCameraDataAPI(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("camera_id")
 	extraData := c.DefaultQuery("extra", "false")
 	handler := functions.NewResponseHandler()
@@ -140,14 +140,15 @@ func buildCameraResponse(camera db.Camera, zoneIn *db.Zone, zoneOut *db.Zone, ex
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuthBackOffice
-//	@Param			Camera	body		db.Camera	true	"Camera data"
-//	@Success		201		{object}	db.Camera	"Camera created successfully"
+//	@Param			Camera	body		db.CameraRequest		true	"Camera data, the ingest secret being generated when needed and not given"
+//	@Success		201		{object}	map[string]interface{}	"Camera created successfully, with the ingest secret issued, returned only in this response"
+//	@Success		204		"Camera created successfully"
 //	@Router			/backoffice/addCamera [post]
 func AddCameraDataAPI(c *gin.Context) {
-	ctx := context.Background()
-	var newCam db.Camera
+	ctx := c.Request.Context()
+	var request db.CameraRequest
 
-	if err := c.ShouldBindJSON(&newCam); err != nil {
+	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Err(err).Msg("Invalid input for new camera")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request payload",
@@ -157,6 +158,19 @@ func AddCameraDataAPI(c *gin.Context) {
 		return
 	}
 
+	newCam := request.Camera
+	secret, generated, err := db.IssueIngestSecret(newCam.IngestAuth, request.IngestSecret)
+	if err != nil {
+		log.Err(err).Msg("Error issuing camera ingest secret")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	newCam.IngestSecret = secret
+
 	////////////////////////////////	log.Info().Msg(" -- -- -- -- -- Creating new camera -- -- -- -- --")
 	log.Info().Msg(" -- -- -- -- -- Checking zones -- -- -- -- --")
 
@@ -202,6 +216,16 @@ func AddCameraDataAPI(c *gin.Context) {
 	}
 
 	log.Info().Int("camera_id", newCam.CamID).Msg("Camera created successfully")
+	// A generated ingest secret is only returned once, here
+	if generated {
+		c.JSON(http.StatusCreated, gin.H{
+			"success":       true,
+			"message":       "Camera Added Successfully",
+			"cam_id":        newCam.CamID,
+			"ingest_secret": secret,
+		})
+		return
+	}
 	c.JSON(204, gin.H{
 		"success": true,
 		"message": "Camera Added Successfully",
@@ -216,13 +240,13 @@ func AddCameraDataAPI(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuthBackOffice
-//	@Param			camera_id	query	int			true	"Camera ID"
-//	@Param			Camera		body	db.Camera	true	"Updated camera data"
+//	@Param			camera_id	query	int						true	"Camera ID"
+//	@Param			Camera		body	db.CameraUpdateRequest	true	"Updated camera data, the ingest secret being kept when not given"
 //	@Router			/backoffice/updateCamera [put]
 func UpdateCameraDataAPI(c *gin.Context) {
 	idStr := c.Query("camera_id")
-	var updates db.CameraNoBind
-	ctx := context.Background()
+	var request db.CameraUpdateRequest
+	ctx := c.Request.Context()
 
 	log.Info().Msg(" -- -- -- -- -- Updating new camera -- -- -- -- --")
 	if idStr == "" {
@@ -247,7 +271,7 @@ func UpdateCameraDataAPI(c *gin.Context) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&updates); err != nil {
+	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Err(err).Msg("Invalid request payload for camera update")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -256,6 +280,8 @@ func UpdateCameraDataAPI(c *gin.Context) {
 		})
 		return
 	}
+	updates := request.CameraNoBind
+	updates.IngestSecret = request.IngestSecret
 
 	if !functions.Contains(db.CameraList, id) {
 		log.Warn().Int("Camera ID", id).Msg("Camera doesn't exists")
@@ -288,11 +314,22 @@ func UpdateCameraDataAPI(c *gin.Context) {
 		return
 	}
 
+	// A camera switched to an authentication without a secret gets one, only
+	// returned here
+	secret, err := db.EnsureCameraIngestSecret(ctx, id)
+	if err != nil {
+		log.Err(err).Int("Camera ID", id).Msg("Error issuing camera ingest secret")
+	}
+
 	log.Info().Str("camera_id", idStr).Msg("Camera updated successfully")
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"success": true,
 		"message": "Camera Updated successfully",
-	})
+	}
+	if secret != "" {
+		response["ingest_secret"] = secret
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteCameraAPI godoc
@@ -305,7 +342,7 @@ func UpdateCameraDataAPI(c *gin.Context) {
 //	@Router			/backoffice/deleteCameras [delete]
 func DeleteCameraDataAPI(c *gin.Context) {
 	idStr := c.Query("camera_id")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if idStr == "" {
 		log.Error().Str("Id provided", idStr).Msg("No camera ID provided for deletion")
@@ -357,3 +394,56 @@ func DeleteCameraDataAPI(c *gin.Context) {
 		"message": "Camera deleted successfully",
 	})
 }
+
+// RotateCameraSecretAPI godoc
+//
+//	@Summary		Rotate the ingest secret of a camera
+//	@Description	Generate a new secret authenticating the events pushed by the camera on /cam. The previous secret is invalid at once. The new secret is returned only in this response.
+//	@Tags			Backoffice - Camera
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			camera_id	query		int	true	"Camera ID"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Router			/backoffice/rotateCameraSecret [post]
+func RotateCameraSecretAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Query("camera_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. camera_id must be a valid integer.",
+			"code":    -5,
+		})
+		return
+	}
+
+	if !functions.Contains(db.CameraList, id) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"code":    -4,
+			"message": fmt.Sprintf("Camera %v not found !", id),
+		})
+		return
+	}
+
+	secret, err := db.RotateCameraIngestSecret(ctx, id)
+	if err != nil {
+		log.Err(err).Int("Camera ID", id).Msg("Error rotating camera ingest secret")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Camera ingest secret rotated successfully",
+		"cam_id":        id,
+		"ingest_secret": secret,
+	})
+}
@@ -1,15 +1,18 @@
 package backoffice
 
 import (
-	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"fyc/config"
 	"fyc/functions"
 	"fyc/pkg/db"
+	"fyc/pkg/ratelimit"
 )
 
 // GetClients godoc
@@ -23,7 +26,7 @@ import (
 //	@Success		200			{array}	db.ApiKey	"List of Clients"
 //	@Router			/backoffice/get_clients [get]
 func GetClients(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("clientId")
 	//var clientRes []*db.ApiKeyResponse
 
@@ -72,13 +75,13 @@ func GetClients(c *gin.Context) {
 // AddClient godoc
 //
 //	@Summary		Add a new client credential
-//	@Description	Add a new client credential to the database
+//	@Description	Add a new client credential to the database. The client secret is generated when not given, and returned only in this response.
 //	@Tags			Backoffice - Clients
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuthBackOffice
 //	@Param			clientCred	body		db.ApiKey	true	"Client credential data"
-//	@Success		201			{object}	db.ApiKey
+//	@Success		201			{object}	map[string]interface{}
 //	@Router			/backoffice/addClient [post]
 func AddClientAPI(c *gin.Context) {
 
@@ -95,6 +98,26 @@ func AddClientAPI(c *gin.Context) {
 		return
 	}
 
+	if err := db.ValidateClientScopes(clientCred.Scopes); err != nil {
+		log.Warn().Err(err).Msg("Invalid client scopes")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	if err := db.NormalizeCertFingerprint(&clientCred.CertFingerprint); err != nil {
+		log.Warn().Err(err).Msg("Invalid client certificate fingerprint")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
 	if clientCred.ClientID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -115,8 +138,9 @@ func AddClientAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	if err := db.AddClientCred(ctx, &clientCred); err != nil {
+	ctx := c.Request.Context()
+	secret, err := db.AddClientCred(ctx, &clientCred)
+	if err != nil {
 		log.Error().Err(err).Msg("Error creating Client API KEY")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -126,10 +150,13 @@ func AddClientAPI(c *gin.Context) {
 		return
 	}
 
+	// The secret is only stored hashed, this is the only time it is returned
 	log.Info().Str("client_id", clientCred.ClientID).Msg("Client created successfully")
 	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"message": "Client Added Successfully",
+		"success":       true,
+		"message":       "Client Added Successfully",
+		"client_id":     clientCred.ClientID,
+		"client_secret": secret,
 	})
 }
 
@@ -163,6 +190,26 @@ func UpdateClientAPI(c *gin.Context) {
 		return
 	}
 
+	if err := db.ValidateClientScopes(clientCred.Scopes); err != nil {
+		log.Warn().Err(err).Msg("Invalid client scopes")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	if err := db.NormalizeCertFingerprint(&clientCred.CertFingerprint); err != nil {
+		log.Warn().Err(err).Msg("Invalid client certificate fingerprint")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
 	if idStr == "" {
 		log.Warn().Msg("The Client ID is required")
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -184,7 +231,7 @@ func UpdateClientAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.UpdateClientCred(ctx, idStr, &clientCred)
 	if err != nil {
 		log.Error().Err(err).Msg("Error updating Client API KEY")
@@ -235,7 +282,7 @@ func DeleteClientAPI(c *gin.Context) {
 		})
 		return
 	}
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeleteClientCred(ctx, id)
 	if err != nil {
 		log.Error().Err(err).Str("client_id", id).Msg("Error deleting Client API KEY")
@@ -263,3 +310,155 @@ func DeleteClientAPI(c *gin.Context) {
 		"message": "Client deleted successfully",
 	})
 }
+
+// RotateClientSecret godoc
+//
+//	@Summary		Rotate a client secret
+//	@Description	Generate a new secret for the client. The previous secret stays valid during the grace period. The new secret is returned only in this response.
+//	@Tags			Backoffice - Clients
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			client_id		query		string	true	"Client ID"
+//	@Param			grace_period	query		int		false	"Seconds the previous secret stays valid (default CLIENT_SECRET_GRACE)"
+//	@Success		200				{object}	map[string]interface{}
+//	@Router			/backoffice/rotateClientSecret [post]
+func RotateClientSecretAPI(c *gin.Context) {
+	id := c.Query("client_id")
+	log.Info().Str("client_id", id).Msg("Attempting to rotate Client secret")
+
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. ClientID parameter is required.",
+			"code":    -5,
+		})
+		return
+	}
+
+	grace := config.Configvar.OAuth.SecretGrace
+	if graceStr := c.Query("grace_period"); graceStr != "" {
+		value, err := strconv.Atoi(graceStr)
+		if err != nil || value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid grace_period, expected a number of seconds",
+				"code":    -5,
+			})
+			return
+		}
+		grace = value
+	}
+
+	if !functions.ContainsStr(db.ClientListAPI, id) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("Client ID %v doesn't exist !", id),
+			"code":    -4,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	secret, expires, err := db.RotateClientSecret(ctx, id, time.Duration(grace)*time.Second)
+	if err != nil {
+		log.Error().Err(err).Str("client_id", id).Msg("Error rotating Client secret")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":                 true,
+		"message":                 "Client secret rotated successfully",
+		"client_id":               id,
+		"client_secret":           secret,
+		"previous_secret_expires": expires,
+	})
+}
+
+type ClientUsage struct {
+	ClientID   string                 `json:"client_id"`
+	ClientName string                 `json:"client_name"`
+	RateLimit  int                    `json:"rate_limit"`
+	DailyQuota int                    `json:"daily_quota"`
+	Usage      []ratelimit.DailyUsage `json:"usage"`
+}
+
+// GetClientUsage godoc
+//
+//	@Summary		Get client API usage
+//	@Description	Get the daily requests and rejected requests of a client, or of all clients, over the last days
+//	@Tags			Backoffice - Clients
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			client_id	query		string	false	"Client ID"
+//	@Param			days		query		int		false	"Number of days, 1 to 35"	default(7)
+//	@Success		200			{array}		ClientUsage
+//	@Router			/backoffice/getClientUsage [get]
+func GetClientUsageAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Query("client_id")
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > 35 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid days, expected a number between 1 and 35",
+			"code":    -5,
+		})
+		return
+	}
+
+	var clients []db.ApiKeyResponse
+	if id != "" {
+		client, err := db.GetClientCredByIdFalse(ctx, id)
+		if err != nil {
+			log.Warn().Err(err).Str("client_id", id).Msg("Client not found")
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("Client ID %v doesn't exist !", id),
+				"code":    -4,
+			})
+			return
+		}
+		clients = append(clients, *client)
+	} else {
+		clients, err = db.GetAllClientCred(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Error retrieving clients")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"code":    -500,
+				"message": "An unexpected error occurred. Please try again later.",
+			})
+			return
+		}
+	}
+
+	response := make([]ClientUsage, 0, len(clients))
+	for _, client := range clients {
+		usage, err := ratelimit.Usage(ctx, client.ClientID, days)
+		if err != nil {
+			log.Error().Err(err).Str("client_id", client.ClientID).Msg("Error retrieving client usage")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"code":    -500,
+				"message": "Usage counters are not available",
+			})
+			return
+		}
+
+		response = append(response, ClientUsage{
+			ClientID:   client.ClientID,
+			ClientName: client.ClientName,
+			RateLimit:  client.RateLimit,
+			DailyQuota: client.DailyQuota,
+			Usage:      usage,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -1,7 +1,6 @@
 package backoffice
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -19,7 +18,7 @@ import (
 // @Router			/backoffice/get_dashboard_data [get]
 func GetDashboardData(c *gin.Context) {
 	handler := functions.NewResponseHandler()
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	totalCameras := 0
 	totalCapacity := 0
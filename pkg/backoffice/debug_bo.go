@@ -22,9 +22,9 @@ func Debuger_BackOffice(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"ZoneList":   db.Zonelist,
 		"CameraList": db.CameraList,
-		"CamList":    db.CamList,
+		"CamList":    db.CamList(),
 		"SignsList":  db.SignList,
-		"ClientList": db.ClientDataList,
+		"ClientList": db.ClientDataList(),
 		"Clients":    db.ClientListAPI,
 	})
 
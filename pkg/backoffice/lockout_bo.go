@@ -0,0 +1,194 @@
+package backoffice
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/middleware"
+	"fyc/pkg/audit"
+	"fyc/pkg/db"
+	"fyc/pkg/ratelimit"
+)
+
+// lockoutKey returns the throttling key of the username or IP of the query.
+func lockoutKey(c *gin.Context) (string, bool) {
+	username, ip := c.Query("username"), c.Query("ip")
+	switch {
+	case username != "" && ip == "":
+		return loginUserKey(username), true
+	case ip != "" && username == "":
+		return loginIPKey(ip), true
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"success": false,
+		"message": "Invalid request. Either username or ip is required.",
+		"code":    -5,
+	})
+	return "", false
+}
+
+// GetLoginLockoutAPI godoc
+//
+//	@Summary		Get the login lockout of a user or IP
+//	@Description	Get the failed login attempts counted on the username or client IP and its remaining lockout
+//	@Tags			Backoffice - Users
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			username	query		string	false	"Username"
+//	@Param			ip			query		string	false	"Client IP"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Router			/backoffice/loginLockout [get]
+func GetLoginLockoutAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	key, ok := lockoutKey(c)
+	if !ok {
+		return
+	}
+
+	attempts, err := ratelimit.Attempts(ctx, key)
+	if err != nil {
+		log.Error().Err(err).Str("Key", key).Msg("Error reading login attempts")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	decision := ratelimit.Locked(ctx, key)
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"failed_attempts": attempts,
+		"locked":          !decision.Allowed,
+		"retry_after":     int(math.Ceil(decision.RetryAfter.Seconds())),
+	})
+}
+
+// UnlockLoginAPI godoc
+//
+//	@Summary		Unlock the login of a user or IP
+//	@Description	Lift the lockout of the username or client IP and clear its failed login attempts
+//	@Tags			Backoffice - Users
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			username	query		string	false	"Username"
+//	@Param			ip			query		string	false	"Client IP"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Router			/backoffice/loginLockout [delete]
+func UnlockLoginAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	key, ok := lockoutKey(c)
+	if !ok {
+		return
+	}
+
+	wasLocked, err := ratelimit.Reset(ctx, key)
+	if err != nil {
+		log.Error().Err(err).Str("Key", key).Msg("Error unlocking login")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	log.Info().Str("Key", key).Bool("Was Locked", wasLocked).Str("By", c.GetString(middleware.ContextUsername)).Msg("Login unlocked")
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"was_locked": wasLocked,
+		"message":    "Login unlocked successfully",
+	})
+}
+
+// GetAuthLogAPI godoc
+//
+//	@Summary		Get the auth log
+//	@Description	Get the login attempts on the backoffice, successful or not, most recent first
+//	@Tags			Backoffice - Audit
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			username	query		string	false	"Username"
+//	@Param			ip			query		string	false	"Client IP"
+//	@Param			success		query		bool	false	"Successful attempts only, or failed ones only"
+//	@Param			from		query		string	false	"From UTC date, 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			to			query		string	false	"To UTC date, 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			limit		query		int		false	"Maximum number of entries"	default(50)
+//	@Param			offset		query		int		false	"Number of entries to skip"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Router			/backoffice/getAuthLog [get]
+func GetAuthLogAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	dates, err := audit.ParseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	filter := db.AuthLogFilter{
+		Username: c.Query("username"),
+		ClientIP: c.Query("ip"),
+		From:     dates.From,
+		To:       dates.To,
+	}
+	if value := c.Query("success"); value != "" {
+		success, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid request. success must be true or false.",
+				"code":    -5,
+			})
+			return
+		}
+		filter.Success = &success
+	}
+
+	filter.Limit, filter.Offset, err = parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	entries, total, err := db.GetAuthLogs(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving auth log")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if entries == nil {
+		entries = []db.AuthLog{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+		"data":    entries,
+	})
+}
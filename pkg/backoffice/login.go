@@ -2,15 +2,14 @@ package backoffice
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
 	"fyc/config"
-	"fyc/middleware"
 	"fyc/pkg/db"
+	"fyc/pkg/rbac"
 )
 
 type User struct {
@@ -23,16 +22,16 @@ type User struct {
 // LoginUser godoc
 //
 //	@Summary		User Login
-//	@Description	Login for users to access the system
+//	@Description	Login for users to access the system. Attempts are counted per username and per client IP before the password is verified, each failure refusing the next attempts of the username for a growing delay given in Retry-After, and cleared on success. Past the thresholds of the settings the username or IP is locked out for a while. Users with two-factor authentication get an mfa_token to be completed with their code on /backoffice/loginTwoFactor.
 //	@Tags			Backoffice - Login
 //	@Accept			json
 //	@Produce		json
 //	@Param			User	body	User	true	"User credentials"
+//	@Failure		429		{object}	map[string]interface{}
 //	@Router			/backoffice/login [post]
 func Login(c *gin.Context) {
 
-	ctx := context.Background()
-	var tokenPref = config.Configvar.App.TokenPrefBackoffice
+	ctx := c.Request.Context()
 
 	var input struct {
 		Username string `json:"username" binding:"required" example:"admin"`
@@ -64,8 +63,15 @@ func Login(c *gin.Context) {
 
 	log.Debug().Str("Username", input.Username).Msg("Login Operation")
 
+	attempt := loginAttempt(ctx, c, input.Username)
+	if !attempt.Allowed {
+		loginLockedOut(ctx, c, input.Username, attempt)
+		return
+	}
+
 	userFound, err := db.GetUserByUsername(ctx, input.Username)
-	if err != nil || userFound.UserName != input.Username || userFound.Password != input.Password {
+	if err != nil || userFound.UserName != input.Username || !db.VerifyUserPassword(ctx, userFound, input.Password) {
+		loginFailed(ctx, c, input.Username, db.AuthInvalidCredentials, attempt)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid username or password",
 			"code":    -2,
@@ -76,6 +82,7 @@ func Login(c *gin.Context) {
 
 	if !userFound.IsEnabled {
 		log.Debug().Str("User Disabled", input.Username).Msg("User Disabled")
+		recordLogin(ctx, c, input.Username, false, db.AuthUserDisabled)
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
 			"message": "User is disabled",
@@ -84,7 +91,20 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token, hr, err := middleware.GenerateToken(userFound.UserName, userFound.Role)
+	if userFound.TwoFactorEnabled {
+		// The second factor is counted as an attempt of its own
+		releaseLoginAttempt(ctx, c, input.Username)
+		startTwoFactorChallenge(ctx, c, userFound)
+		return
+	}
+
+	completeLogin(ctx, c, userFound, false)
+}
+
+// completeLogin opens the session of the authenticated user and answers the
+// login with its tokens.
+func completeLogin(ctx context.Context, c *gin.Context, user *db.User, twoFactor bool) {
+	tokens, err := startSession(ctx, c, user, twoFactor)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to generate token")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -95,29 +115,28 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	log.Info().Str("User ", input.Username).Int("Time", hr).Msg("Connected successfully")
-	//db.Token = token
+	loginSucceeded(ctx, c, user.UserName)
+
+	log.Info().Str("User ", user.UserName).Int("Expires in", tokens.ExpiresIn).Msg("Connected successfully")
 
-	if tokenPref == "true" {
+	if config.Configvar.App.TokenPrefBackoffice == "true" {
 		log.Info().Msg("Passing token with BEARER Prefix")
-		c.JSON(http.StatusOK, gin.H{
-			"success":    true,
-			"first_name": userFound.FirstName,
-			"last_name":  userFound.LastName,
-			"role":       userFound.Role,
-			"token":      fmt.Sprint("Bearer " + token),
-			"message":    "Connected successfully",
-		})
-		return
+	} else {
+		log.Info().Msg("Passing token without BEARER Prefix")
 	}
-
-	log.Info().Msg("Passing token without BEARER Prefix")
 	c.JSON(http.StatusOK, gin.H{
-		"success":    true,
-		"first_name": userFound.FirstName,
-		"last_name":  userFound.LastName,
-		"role":       userFound.Role,
-		"token":      token,
-		"message":    "Connected successfully",
+		"success":                   true,
+		"first_name":                user.FirstName,
+		"last_name":                 user.LastName,
+		"role":                      user.Role,
+		"token":                     bearerToken(tokens.AccessToken),
+		"expires_in":                tokens.ExpiresIn,
+		"refresh_token":             tokens.RefreshToken,
+		"session_id":                tokens.SessionID,
+		"message":                   "Connected successfully",
+		"must_change_password":      user.MustChangePassword,
+		"two_factor_enabled":        user.TwoFactorEnabled,
+		"two_factor_setup_required": !user.TwoFactorEnabled && rbac.RequiresTwoFactor(user.Role),
+		"permissions":               rbac.Permissions(user.Role),
 	})
 }
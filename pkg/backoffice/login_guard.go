@@ -0,0 +1,169 @@
+package backoffice
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/db"
+	"fyc/pkg/ratelimit"
+)
+
+// Longest delay applied to a failed login, whatever the number of failures
+const maxLoginDelay = 30 * time.Second
+
+// loginPolicy is the brute-force protection configured in the settings
+type loginPolicy struct {
+	MaxAttempts   int
+	IPMaxAttempts int
+	Window        time.Duration
+	Lockout       time.Duration
+	Delay         time.Duration
+}
+
+func loadLoginPolicy(ctx context.Context) loginPolicy {
+	policy := loginPolicy{
+		MaxAttempts:   db.DefaultLoginMaxAttempts,
+		IPMaxAttempts: db.DefaultLoginIPMaxAttempts,
+		Window:        db.DefaultLoginWindow * time.Second,
+		Lockout:       db.DefaultLoginLockout * time.Second,
+		Delay:         db.DefaultLoginDelay * time.Second,
+	}
+
+	settings, err := db.GetAllSettings(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Login policy not found in settings, using defaults")
+		return policy
+	}
+
+	policy.MaxAttempts = settings.LoginMaxAttempts
+	policy.IPMaxAttempts = settings.LoginIPMaxAttempts
+	if settings.LoginWindow > 0 {
+		policy.Window = time.Duration(settings.LoginWindow) * time.Second
+	}
+	if settings.LoginLockout > 0 {
+		policy.Lockout = time.Duration(settings.LoginLockout) * time.Second
+	}
+	policy.Delay = time.Duration(max(settings.LoginDelay, 0)) * time.Second
+	return policy
+}
+
+func loginUserKey(username string) string {
+	return "login:user:" + username
+}
+
+func loginIPKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+func loginDelayKey(username string) string {
+	return "login:delay:" + username
+}
+
+// loginAttempt counts the login attempt against the username and the client
+// IP before the credentials are verified, so that concurrent attempts cannot
+// exceed the thresholds. A rejected decision means the username or IP is
+// locked out, or the delay after a failure of the username is not over, and
+// the credentials must not be verified.
+func loginAttempt(ctx context.Context, c *gin.Context, username string) ratelimit.Decision {
+	policy := loadLoginPolicy(ctx)
+
+	if decision := ratelimit.Locked(ctx, loginDelayKey(username)); !decision.Allowed {
+		return decision
+	}
+	decision := ratelimit.Throttle(ctx, loginIPKey(c.ClientIP()), policy.IPMaxAttempts, policy.Window, policy.Lockout)
+	if !decision.Allowed {
+		return decision
+	}
+	decision = ratelimit.Throttle(ctx, loginUserKey(username), policy.MaxAttempts, policy.Window, policy.Lockout)
+	if !decision.Allowed {
+		// Not held against the IP, the username being locked out anyway
+		releaseLoginIP(ctx, c)
+	}
+	return decision
+}
+
+// loginLockedOut answers and records a login attempt rejected by the lockout.
+func loginLockedOut(ctx context.Context, c *gin.Context, username string, decision ratelimit.Decision) {
+	log.Warn().Str("Username", username).Str("IP", c.ClientIP()).Msg("Login locked out")
+	recordLogin(ctx, c, username, false, db.AuthLockedOut)
+	abortLockedOut(c, decision)
+}
+
+// loginFailed records the failed attempt, already counted by loginAttempt,
+// and refuses the next attempts of the username for a delay doubling with each
+// failure, given in the Retry-After header of the answer. The answer is not
+// held, so that failed logins do not tie up the server.
+func loginFailed(ctx context.Context, c *gin.Context, username string, reason string, attempt ratelimit.Decision) {
+	recordLogin(ctx, c, username, false, reason)
+	policy := loadLoginPolicy(ctx)
+
+	if policy.Delay > 0 && policy.MaxAttempts > 0 {
+		failures := max(int64(policy.MaxAttempts)-attempt.Remaining, 1)
+		delay := maxLoginDelay
+		if failures < 16 {
+			delay = min(policy.Delay<<(failures-1), maxLoginDelay)
+		}
+		ratelimit.Lock(ctx, loginDelayKey(username), delay)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	}
+}
+
+// loginSucceeded clears the attempts of the username and gives back the
+// attempt counted against the client IP.
+func loginSucceeded(ctx context.Context, c *gin.Context, username string) {
+	recordLogin(ctx, c, username, true, db.AuthSuccess)
+	if _, err := ratelimit.Reset(ctx, loginUserKey(username)); err != nil {
+		log.Warn().Err(err).Str("Username", username).Msg("Error resetting login attempts")
+	}
+	releaseLoginIP(ctx, c)
+}
+
+// releaseLoginAttempt gives back the attempt counted against the username and
+// the client IP, its password being verified.
+func releaseLoginAttempt(ctx context.Context, c *gin.Context, username string) {
+	if err := ratelimit.Release(ctx, loginUserKey(username)); err != nil {
+		log.Warn().Err(err).Str("Username", username).Msg("Error releasing login attempt")
+	}
+	releaseLoginIP(ctx, c)
+}
+
+func releaseLoginIP(ctx context.Context, c *gin.Context) {
+	if err := ratelimit.Release(ctx, loginIPKey(c.ClientIP())); err != nil {
+		log.Warn().Err(err).Str("IP", c.ClientIP()).Msg("Error releasing login attempt")
+	}
+}
+
+func recordLogin(ctx context.Context, c *gin.Context, username string, success bool, reason string) {
+	err := db.CreateAuthLog(ctx, &db.AuthLog{
+		Username:  username,
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Success:   success,
+		Reason:    reason,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("Username", username).Msg("Error writing auth log")
+	}
+}
+
+// abortLockedOut answers a login attempt rejected by the lockout.
+func abortLockedOut(c *gin.Context, decision ratelimit.Decision) {
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"success":     false,
+		"code":        -9,
+		"message":     "Too many failed login attempts, try again later",
+		"retry_after": retryAfter,
+	})
+}
@@ -0,0 +1,214 @@
+package backoffice
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/middleware"
+	"fyc/pkg/db"
+	"fyc/pkg/password"
+	"fyc/pkg/rbac"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Username string `json:"username" binding:"required"`
+	// NewPassword is generated when empty
+	NewPassword string `json:"new_password"`
+}
+
+// GetPasswordPolicy godoc
+//
+//	@Summary		Get the password policy
+//	@Description	Rules that the passwords of backoffice users must meet
+//	@Tags			Backoffice - Users
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Success		200	{object}	password.Policy
+//	@Router			/backoffice/passwordPolicy [get]
+func GetPasswordPolicyAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, password.CurrentPolicy())
+}
+
+// ChangePassword godoc
+//
+//	@Summary		Change my password
+//	@Description	Change the password of the connected user, the current password being required. The other sessions of the user are revoked. A session opened with a password to be changed is restricted to this route, the password policy and logout until then.
+//	@Tags			Backoffice - Users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			password	body		ChangePasswordRequest	true	"Current and new password"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		401			{object}	map[string]interface{}
+//	@Router			/backoffice/changePassword [post]
+func ChangePasswordAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	username := c.GetString(middleware.ContextUsername)
+
+	var request ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	user, err := db.GetUserByUsername(ctx, username)
+	if err != nil || !db.VerifyUserPassword(ctx, user, request.CurrentPassword) {
+		log.Warn().Str("Username", username).Msg("Password change with wrong current password")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Current password is incorrect",
+			"code":    -1,
+		})
+		return
+	}
+
+	if request.NewPassword == request.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "New password must differ from the current one",
+			"code":    -5,
+		})
+		return
+	}
+	if err := password.Validate(username, request.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	if _, err := db.SetUserPassword(ctx, username, request.NewPassword, false); err != nil {
+		log.Err(err).Str("Username", username).Msg("Error changing password")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	// Other devices have to log in again with the new password
+	if _, err := db.RevokeUserSessions(ctx, username, c.GetString(middleware.ContextSessionID), username); err != nil {
+		log.Err(err).Str("Username", username).Msg("Error revoking sessions after password change")
+	}
+	if err := db.ClearSessionMustChangePassword(ctx, c.GetString(middleware.ContextSessionID)); err != nil {
+		log.Err(err).Str("Username", username).Msg("Error lifting the password change restriction of the session")
+	}
+
+	log.Info().Str("Username", username).Msg("Password changed")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password changed successfully",
+	})
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset the password of a user
+//	@Description	Set a new password for the user, generated when not provided, which must be changed on the next login. The password is returned only in this response and the sessions of the user are revoked.
+//	@Tags			Backoffice - Users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			password	body		ResetPasswordRequest	true	"User and optional new password"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		403			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Router			/backoffice/resetPassword [post]
+func ResetPasswordAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	admin := c.GetString(middleware.ContextUsername)
+
+	var request ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	newPassword := request.NewPassword
+	if newPassword == "" {
+		generated, err := password.Generate()
+		if err != nil {
+			log.Err(err).Msg("Error generating password")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"code":    -500,
+				"message": "An unexpected error occurred. Please try again later.",
+			})
+			return
+		}
+		newPassword = generated
+	} else if err := password.Validate(request.Username, newPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	// Resetting the password of a user opens its account, which is refused for
+	// users with more permissions than the connected one
+	user, err := db.GetUserByUsername(ctx, request.Username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "User not found",
+			"code":    -4,
+		})
+		return
+	}
+	if !callerGrants(c, rbac.Permissions(user.Role)) {
+		return
+	}
+
+	rowsAffected, err := db.SetUserPassword(ctx, request.Username, newPassword, true)
+	if err != nil {
+		log.Err(err).Str("Username", request.Username).Msg("Error resetting password")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "User not found",
+			"code":    -4,
+		})
+		return
+	}
+
+	if _, err := db.RevokeUserSessions(ctx, request.Username, "", admin); err != nil {
+		log.Err(err).Str("Username", request.Username).Msg("Error revoking sessions after password reset")
+	}
+
+	log.Info().Str("Username", request.Username).Str("Reset by", admin).Msg("Password reset")
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "Password reset successfully, it must be changed on the next login",
+		"username": request.Username,
+		"password": newPassword,
+	})
+}
@@ -1,7 +1,6 @@
 package backoffice
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -27,7 +26,7 @@ import (
 //	@Description	Date format must be YYYY-MM-DD.
 //	@Success		200	{object}	db.PresentCar
 func GetAllPresentTransactionsDataIDAPI(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	ID := c.Query("car_id")
 
 	log.Info().Str("ID", ID).Msg("Received request for present cars by ID")
@@ -131,7 +130,7 @@ func GetAllPresentTransactionsDataIDAPI(c *gin.Context) {
 //	@Success		200				{object}	PaginatedResponse
 //	@Router			/backoffice/get_present_car [get]
 func GetAllPresentTransactionsDataAPI(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Retrieve query parameters
 	startDate := c.DefaultQuery("start", time.Now().Format("2006-01-02"))
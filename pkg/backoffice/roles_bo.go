@@ -0,0 +1,379 @@
+package backoffice
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/middleware"
+	"fyc/pkg/db"
+	"fyc/pkg/rbac"
+)
+
+type RoleRequest struct {
+	Name        string   `json:"name" binding:"required" example:"Supervisor"`
+	Description string   `json:"description" example:"Zones and signs supervision"`
+	Permissions []string `json:"permissions" binding:"required" example:"zones:view,signs:update"`
+}
+
+type AssignRoleRequest struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// GetPermissionsAPI godoc
+//
+//	@Summary		Get the permissions
+//	@Description	Get the modules and actions permissions are made of, and the permissions of the connected user
+//	@Tags			Backoffice - Roles
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Success		200	{object}	map[string]interface{}
+//	@Router			/backoffice/getPermissions [get]
+func GetPermissionsAPI(c *gin.Context) {
+	role := c.GetString(middleware.ContextRole)
+	c.JSON(http.StatusOK, gin.H{
+		"modules":     rbac.Modules,
+		"actions":     rbac.Actions,
+		"role":        role,
+		"permissions": rbac.Permissions(role),
+	})
+}
+
+// GetRolesAPI godoc
+//
+//	@Summary		Get roles
+//	@Description	Get the roles and the permissions they grant
+//	@Tags			Backoffice - Roles
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Success		200	{array}	db.Role
+//	@Router			/backoffice/getRoles [get]
+func GetRolesAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	roles, err := db.GetRoles(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving roles")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if roles == nil {
+		roles = []db.Role{}
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// AddRoleAPI godoc
+//
+//	@Summary		Add a role
+//	@Description	Add a role granting permissions formatted as module:action, all of them held by the connected user
+//	@Tags			Backoffice - Roles
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			role	body		RoleRequest	true	"Role"
+//	@Success		201		{object}	map[string]interface{}
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
+//	@Failure		409		{object}	map[string]interface{}
+//	@Router			/backoffice/addRole [post]
+func AddRoleAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	request, ok := bindRoleRequest(c)
+	if !ok {
+		return
+	}
+
+	if rbac.RoleExists(request.Name) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "Role already exists",
+			"code":    -5,
+		})
+		return
+	}
+
+	role := db.Role{Name: request.Name, Description: request.Description, Permissions: request.Permissions}
+	if err := db.AddRole(ctx, &role); err != nil {
+		log.Error().Err(err).Str("Role", request.Name).Msg("Error adding role")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	reloadRoles(ctx)
+
+	log.Info().Str("Role", role.Name).Str("By", c.GetString(middleware.ContextUsername)).Msg("Role added")
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Role added successfully",
+		"role":    role,
+	})
+}
+
+// UpdateRoleAPI godoc
+//
+//	@Summary		Update a role
+//	@Description	Replace the description and permissions of a role, all of them held by the connected user. The default roles cannot be modified.
+//	@Tags			Backoffice - Roles
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			role	body		RoleRequest	true	"Role"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Router			/backoffice/updateRole [put]
+func UpdateRoleAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	request, ok := bindRoleRequest(c)
+	if !ok {
+		return
+	}
+
+	role := db.Role{Name: request.Name, Description: request.Description, Permissions: request.Permissions}
+	rowsAffected, err := db.UpdateRole(ctx, &role)
+	if err != nil {
+		log.Error().Err(err).Str("Role", request.Name).Msg("Error updating role")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Role not found or not modifiable",
+			"code":    -4,
+		})
+		return
+	}
+	reloadRoles(ctx)
+
+	log.Info().Str("Role", role.Name).Str("By", c.GetString(middleware.ContextUsername)).Msg("Role updated")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Role updated successfully",
+	})
+}
+
+// DeleteRoleAPI godoc
+//
+//	@Summary		Delete a role
+//	@Description	Delete a role no user holds. The default roles cannot be deleted.
+//	@Tags			Backoffice - Roles
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			name	query		string	true	"Role name"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Failure		409		{object}	map[string]interface{}
+//	@Router			/backoffice/deleteRole [delete]
+func DeleteRoleAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. name parameter is required.",
+			"code":    -5,
+		})
+		return
+	}
+
+	users, err := db.CountUsersWithRole(ctx, name)
+	if err != nil {
+		log.Error().Err(err).Str("Role", name).Msg("Error counting users of role")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if users > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "Role is assigned to users",
+			"code":    -5,
+			"users":   users,
+		})
+		return
+	}
+
+	rowsAffected, err := db.DeleteRole(ctx, name)
+	if err != nil {
+		log.Error().Err(err).Str("Role", name).Msg("Error deleting role")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Role not found or not deletable",
+			"code":    -4,
+		})
+		return
+	}
+	reloadRoles(ctx)
+
+	log.Info().Str("Role", name).Str("By", c.GetString(middleware.ContextUsername)).Msg("Role deleted")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Role deleted successfully",
+	})
+}
+
+// AssignRoleAPI godoc
+//
+//	@Summary		Assign a role to a user
+//	@Description	Change the role of a user and revoke its sessions, the user logging in again with the new role. Neither the new role nor the current one of the user may grant permissions the connected user does not have.
+//	@Tags			Backoffice - Roles
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			role	body		AssignRoleRequest	true	"User and role"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Router			/backoffice/assignRole [post]
+func AssignRoleAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var request AssignRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	if !rbac.RoleExists(request.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Unknown role",
+			"code":    -5,
+		})
+		return
+	}
+
+	// Neither the new role nor the current one of the user may exceed the role
+	// of the connected user
+	if !callerGrants(c, rbac.Permissions(request.Role)) {
+		return
+	}
+	user, err := db.GetUserByUsername(ctx, request.Username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "User not found",
+			"code":    -4,
+		})
+		return
+	}
+	if !callerGrants(c, rbac.Permissions(user.Role)) {
+		return
+	}
+
+	rowsAffected, err := db.SetUserRole(ctx, request.Username, request.Role)
+	if err != nil {
+		log.Error().Err(err).Str("Username", request.Username).Msg("Error assigning role")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "User not found",
+			"code":    -4,
+		})
+		return
+	}
+
+	// The role is carried by the tokens, the user logs in again to get the new one
+	if _, err := db.RevokeUserSessions(ctx, request.Username, "", c.GetString(middleware.ContextUsername)); err != nil {
+		log.Err(err).Str("Username", request.Username).Msg("Error revoking sessions after role change")
+	}
+
+	log.Info().Str("Username", request.Username).Str("Role", request.Role).Str("By", c.GetString(middleware.ContextUsername)).Msg("Role assigned")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Role assigned successfully",
+	})
+}
+
+func bindRoleRequest(c *gin.Context) (*RoleRequest, bool) {
+	var request RoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return nil, false
+	}
+
+	if err := rbac.ValidatePermissions(request.Permissions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return nil, false
+	}
+
+	if !callerGrants(c, request.Permissions) {
+		return nil, false
+	}
+	return &request, true
+}
+
+// callerGrants checks that the connected user holds every one of the
+// permissions, so that nobody hands out more than it is allowed itself,
+// having answered the request otherwise.
+func callerGrants(c *gin.Context, permissions []string) bool {
+	if rbac.Grants(c.GetString(middleware.ContextRole), permissions) {
+		return true
+	}
+
+	log.Warn().Str("Username", c.GetString(middleware.ContextUsername)).Str("Role", c.GetString(middleware.ContextRole)).Strs("Permissions", permissions).Msg("Permissions beyond the role of the user refused")
+	c.JSON(http.StatusForbidden, gin.H{
+		"success": false,
+		"message": "You cannot grant permissions you do not have",
+		"code":    -6,
+	})
+	return false
+}
+
+func reloadRoles(ctx context.Context) {
+	if err := rbac.Load(ctx); err != nil {
+		log.Error().Err(err).Msg("Error reloading role permissions")
+	}
+}
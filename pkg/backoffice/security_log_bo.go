@@ -0,0 +1,102 @@
+package backoffice
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/audit"
+	"fyc/pkg/db"
+	"fyc/pkg/security"
+)
+
+// GetSecurityLogAPI godoc
+//
+//	@Summary		Get the security log
+//	@Description	Get the requests rejected by the security checks, such as the camera events on /cam, most recent first, with the daily counters of the rejections
+//	@Tags			Backoffice - Audit
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			source		query		string	false	"Source of the requests"	default(cam)
+//	@Param			ip			query		string	false	"Client IP"
+//	@Param			reason		query		string	false	"Reason of the rejection"	Enums(unknown_ip, ip_mismatch, unknown_camera, auth_missing, auth_failed, signature_invalid, body_too_large)
+//	@Param			from		query		string	false	"From UTC date, 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			to			query		string	false	"To UTC date, 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			days		query		int		false	"Number of days of counters, 1 to 35"	default(7)
+//	@Param			limit		query		int		false	"Maximum number of entries"	default(50)
+//	@Param			offset		query		int		false	"Number of entries to skip"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Router			/backoffice/getSecurityLog [get]
+func GetSecurityLogAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	dates, err := audit.ParseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > 35 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. days must be between 1 and 35.",
+			"code":    -5,
+		})
+		return
+	}
+
+	filter := db.SecurityLogFilter{
+		Source:   c.DefaultQuery("source", security.SourceCamera),
+		ClientIP: c.Query("ip"),
+		Reason:   c.Query("reason"),
+		From:     dates.From,
+		To:       dates.To,
+	}
+	filter.Limit, filter.Offset, err = parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	entries, total, err := db.GetSecurityLogs(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving security log")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	// The log is still served without the counters
+	counters, err := security.Counters(ctx, filter.Source, days)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error retrieving security counters")
+		counters = []security.DayCounters{}
+	}
+
+	if entries == nil {
+		entries = []db.SecurityLog{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"total":    total,
+		"limit":    filter.Limit,
+		"offset":   filter.Offset,
+		"data":     entries,
+		"counters": counters,
+	})
+}
@@ -0,0 +1,393 @@
+package backoffice
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/middleware"
+	"fyc/pkg/db"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// sessionTokens are the tokens issued at login and on refresh
+type sessionTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+	SessionID    string
+}
+
+// startSession opens a session for the user and issues its tokens. twoFactor
+// tells whether the user proved a second factor.
+func startSession(ctx context.Context, c *gin.Context, user *db.User, twoFactor bool) (*sessionTokens, error) {
+	sessionID, err := middleware.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := middleware.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	sessionExpiresAt := now.Add(time.Duration(config.Configvar.Session.MaxLifetime) * time.Second)
+	expiresAt := now.Add(time.Duration(config.Configvar.Session.RefreshTTL) * time.Second)
+	if expiresAt.After(sessionExpiresAt) {
+		expiresAt = sessionExpiresAt
+	}
+
+	err = db.CreateBackofficeSession(ctx, &db.BackofficeSession{
+		SessionID:        sessionID,
+		Username:         user.UserName,
+		RefreshHash:      middleware.HashToken(refreshToken),
+		ClientIP:         c.ClientIP(),
+		UserAgent:        c.Request.UserAgent(),
+		CreatedAt:        now.Format(time.DateTime),
+		LastUsedAt:       now.Format(time.DateTime),
+		ExpiresAt:        expiresAt.Format(time.DateTime),
+		SessionExpiresAt: sessionExpiresAt.Format(time.DateTime),
+		TwoFactor:        twoFactor,
+
+		MustChangePassword: user.MustChangePassword,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, expiresIn, err := middleware.GenerateToken(user.UserName, user.Role, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionTokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: expiresIn, SessionID: sessionID}, nil
+}
+
+// bearerToken prefixes the access token when the backoffice expects it.
+func bearerToken(token string) string {
+	if config.Configvar.App.TokenPrefBackoffice == "true" {
+		return "Bearer " + token
+	}
+	return token
+}
+
+// RefreshToken godoc
+//
+//	@Summary		Refresh the access token
+//	@Description	Issue a new access token and a new refresh token for the session of the refresh token, which can be used only once. Reusing a rotated refresh token revokes the session. Sessions are not extended past their absolute end, set at login.
+//	@Tags			Backoffice - Login
+//	@Accept			json
+//	@Produce		json
+//	@Param			refresh	body		RefreshRequest	true	"Refresh token"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		401		{object}	map[string]interface{}
+//	@Router			/backoffice/refresh [post]
+func RefreshTokenAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var request RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	refreshHash := middleware.HashToken(request.RefreshToken)
+	session, err := db.GetBackofficeSessionByRefresh(ctx, refreshHash)
+	if err != nil {
+		log.Err(err).Msg("Error retrieving session to refresh")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if session != nil && session.RefreshHash != refreshHash {
+		// The token was already rotated, it may have been stolen
+		log.Warn().Str("Username", session.Username).Str("Session", session.SessionID).Msg("Reuse of a rotated refresh token, session revoked")
+		if _, err := db.RevokeBackofficeSession(ctx, session.Username, session.SessionID, "refresh token reuse"); err != nil {
+			log.Err(err).Msg("Error revoking session")
+		}
+		session = nil
+	}
+	if session == nil || !session.IsActive() {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"code":    -3,
+			"message": "Unauthorized, session revoked or expired!",
+		})
+		return
+	}
+
+	// The role and state of the user may have changed since the login
+	user, err := db.GetUserByUsername(ctx, session.Username)
+	if err != nil || !user.IsEnabled {
+		log.Warn().Str("Username", session.Username).Msg("Refresh refused, user disabled or deleted")
+		if _, err := db.RevokeBackofficeSession(ctx, session.Username, session.SessionID, "user disabled"); err != nil {
+			log.Err(err).Msg("Error revoking session")
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"code":    -2,
+			"message": "User is disabled",
+		})
+		return
+	}
+
+	refreshToken, err := middleware.GenerateOpaqueToken()
+	if err != nil {
+		log.Err(err).Str("Username", user.UserName).Msg("Error generating refresh token")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(time.Duration(config.Configvar.Session.RefreshTTL) * time.Second).Format(time.DateTime)
+	rotated, err := db.RotateSessionRefresh(ctx, session.SessionID, refreshHash, middleware.HashToken(refreshToken), expiresAt)
+	if err != nil {
+		log.Err(err).Str("Username", user.UserName).Msg("Error rotating refresh token")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if rotated == 0 {
+		// Rotated meanwhile by a concurrent request with the same token
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"code":    -3,
+			"message": "Unauthorized, session revoked or expired!",
+		})
+		return
+	}
+
+	accessToken, expiresIn, err := middleware.GenerateToken(user.UserName, user.Role, session.SessionID)
+	if err != nil {
+		log.Err(err).Str("Username", user.UserName).Msg("Failed to generate token")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Could not generate token",
+			"code":    -500,
+		})
+		return
+	}
+
+	log.Debug().Str("Username", user.UserName).Msg("Session refreshed")
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"token":         bearerToken(accessToken),
+		"expires_in":    expiresIn,
+		"refresh_token": refreshToken,
+		"session_id":    session.SessionID,
+		"role":          user.Role,
+	})
+}
+
+// Logout godoc
+//
+//	@Summary		Logout
+//	@Description	Revoke the session of the access token, its access and refresh tokens being rejected afterwards
+//	@Tags			Backoffice - Login
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Success		200	{object}	map[string]interface{}
+//	@Router			/backoffice/logout [post]
+func LogoutAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	username := c.GetString(middleware.ContextUsername)
+
+	if _, err := db.RevokeBackofficeSession(ctx, username, c.GetString(middleware.ContextSessionID), username); err != nil {
+		log.Err(err).Str("Username", username).Msg("Error revoking session at logout")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	log.Info().Str("Username", username).Msg("Logged out")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Logged out successfully",
+	})
+}
+
+// GetMySessions godoc
+//
+//	@Summary		List my sessions
+//	@Description	List the active sessions of the connected user, flagging the current one
+//	@Tags			Backoffice - Sessions
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Success		200	{object}	map[string]interface{}
+//	@Router			/backoffice/mySessions [get]
+func GetMySessionsAPI(c *gin.Context) {
+	listSessions(c, c.GetString(middleware.ContextUsername))
+}
+
+// RevokeMySession godoc
+//
+//	@Summary		Revoke one of my sessions
+//	@Description	Revoke a session of the connected user, for instance one opened on a lost device
+//	@Tags			Backoffice - Sessions
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			session_id	query		string	true	"Session ID"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Router			/backoffice/mySessions [delete]
+func RevokeMySessionAPI(c *gin.Context) {
+	username := c.GetString(middleware.ContextUsername)
+	revokeSessions(c, username, c.Query("session_id"), true)
+}
+
+// GetUserSessions godoc
+//
+//	@Summary		List the sessions of a user
+//	@Description	List the active sessions of any backoffice user
+//	@Tags			Backoffice - Sessions
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			username	query		string	true	"Username"
+//	@Success		200			{object}	map[string]interface{}
+//	@Router			/backoffice/userSessions [get]
+func GetUserSessionsAPI(c *gin.Context) {
+	username := c.Query("username")
+	if username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. username parameter is required.",
+			"code":    -5,
+		})
+		return
+	}
+	listSessions(c, username)
+}
+
+// RevokeUserSessions godoc
+//
+//	@Summary		Revoke the sessions of a user
+//	@Description	Revoke a session of any backoffice user, or all of them when no session is given
+//	@Tags			Backoffice - Sessions
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			username	query		string	true	"Username"
+//	@Param			session_id	query		string	false	"Session ID, all sessions when empty"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Router			/backoffice/userSessions [delete]
+func RevokeUserSessionsAPI(c *gin.Context) {
+	username := c.Query("username")
+	if username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. username parameter is required.",
+			"code":    -5,
+		})
+		return
+	}
+	revokeSessions(c, username, c.Query("session_id"), false)
+}
+
+func listSessions(c *gin.Context, username string) {
+	ctx := c.Request.Context()
+
+	sessions, err := db.GetUserSessions(ctx, username)
+	if err != nil {
+		log.Err(err).Str("Username", username).Msg("Error retrieving sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	current := c.GetString(middleware.ContextSessionID)
+	data := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, gin.H{
+			"session_id":   session.SessionID,
+			"client_ip":    session.ClientIP,
+			"user_agent":   session.UserAgent,
+			"created_at":   session.CreatedAt,
+			"last_used_at": session.LastUsedAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.SessionID == current,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"username": username,
+		"data":     data,
+	})
+}
+
+// revokeSessions revokes a session of the user, or all of them when sessionID
+// is empty and not required.
+func revokeSessions(c *gin.Context, username string, sessionID string, requireID bool) {
+	ctx := c.Request.Context()
+	by := c.GetString(middleware.ContextUsername)
+
+	if sessionID == "" && requireID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. session_id parameter is required.",
+			"code":    -5,
+		})
+		return
+	}
+
+	var (
+		rowsAffected int64
+		err          error
+	)
+	if sessionID == "" {
+		rowsAffected, err = db.RevokeUserSessions(ctx, username, "", by)
+	} else {
+		rowsAffected, err = db.RevokeBackofficeSession(ctx, username, sessionID, by)
+	}
+	if err != nil {
+		log.Err(err).Str("Username", username).Msg("Error revoking sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if sessionID != "" && rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Session not found or already revoked",
+			"code":    -4,
+		})
+		return
+	}
+
+	log.Info().Str("Username", username).Int64("Sessions", rowsAffected).Str("By", by).Msg("Sessions revoked")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Sessions revoked successfully",
+		"revoked": rowsAffected,
+	})
+}
@@ -1,7 +1,6 @@
 package backoffice
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
@@ -14,9 +13,10 @@ import (
 )
 
 type ResponseData struct {
-	General GeneralInfo `json:"general"`
-	Cron    CronInfo    `json:"cron"`
-	Kiosk   KioskInfo   `json:"Kiosk"`
+	General  GeneralInfo  `json:"general"`
+	Cron     CronInfo     `json:"cron"`
+	Kiosk    KioskInfo    `json:"Kiosk"`
+	Security SecurityInfo `json:"security"`
 }
 
 type GeneralInfo struct {
@@ -31,6 +31,8 @@ type CronInfo struct {
 	CountingCleanCron int  `json:"counting_clean_cron"`
 	IsFycEnabled      bool `json:"is_fyc_enabled"`
 	IsCountingEnabled bool `json:"is_counting_enabled"`
+	SignRefreshCron   int  `json:"sign_refresh_cron"`
+	IsSignRefresh     bool `json:"is_sign_refresh_enabled"`
 }
 
 type KioskInfo struct {
@@ -39,6 +41,15 @@ type KioskInfo struct {
 	TC                 string `json:"TC"`
 }
 
+// SecurityInfo is the login brute-force protection, durations in seconds
+type SecurityInfo struct {
+	LoginMaxAttempts   int  `json:"login_max_attempts"`
+	LoginIPMaxAttempts int  `json:"login_ip_max_attempts"`
+	LoginWindow        int  `json:"login_window"`
+	LoginLockout       int  `json:"login_lockout"`
+	LoginDelay         *int `json:"login_delay"`
+}
+
 // GetSettings godoc
 //
 //	@Summary		Get settings
@@ -49,7 +60,7 @@ type KioskInfo struct {
 //	@Success		200	{object}	db.Settings
 //	@Router			/backoffice/getSettings [get]
 func GetSettingsDataAPI(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	log.Info().Msg("Fetching all settings")
 	settings, err := db.GetAllSettings(ctx)
@@ -68,16 +79,25 @@ func GetSettingsDataAPI(c *gin.Context) {
 				"pka_image_size": settings.PkaImageSize,
 			},
 			"cron": map[string]interface{}{
-				"fyc_clean_cron":      settings.FycCleanCron,
-				"counting_clean_cron": settings.CountingCleanCron,
-				"is_counting_enabled": settings.IsCountingEnabled,
-				"is_fyc_enabled":      settings.IsFycEnabled,
+				"fyc_clean_cron":          settings.FycCleanCron,
+				"counting_clean_cron":     settings.CountingCleanCron,
+				"is_counting_enabled":     settings.IsCountingEnabled,
+				"is_fyc_enabled":          settings.IsFycEnabled,
+				"sign_refresh_cron":       settings.SignRefreshCron,
+				"is_sign_refresh_enabled": settings.IsSignRefresh,
 			},
 			"Kiosk": map[string]interface{}{
 				"timeout_screenKiosk": settings.TimeOutScreenKisok,
 				"app_logo":            settings.AppLogo,
 				"tc":                  settings.TC,
 			},
+			"security": map[string]interface{}{
+				"login_max_attempts":    settings.LoginMaxAttempts,
+				"login_ip_max_attempts": settings.LoginIPMaxAttempts,
+				"login_window":          settings.LoginWindow,
+				"login_lockout":         settings.LoginLockout,
+				"login_delay":           settings.LoginDelay,
+			},
 		},
 	}
 
@@ -158,12 +178,21 @@ func UpdateSettingsDataAPI(c *gin.Context) {
 		IsFycEnabled:      &set2update.Cron.IsFycEnabled,
 		IsCountingEnabled: &set2update.Cron.IsCountingEnabled,
 
+		SignRefreshCron: set2update.Cron.SignRefreshCron,
+		IsSignRefresh:   &set2update.Cron.IsSignRefresh,
+
 		TimeOutScreenKisok: set2update.Kiosk.TimeOutScreenKiosk,
 		AppLogo:            set2update.Kiosk.AppLogo,
 		TC:                 set2update.Kiosk.TC,
+
+		LoginMaxAttempts:   set2update.Security.LoginMaxAttempts,
+		LoginIPMaxAttempts: set2update.Security.LoginIPMaxAttempts,
+		LoginWindow:        set2update.Security.LoginWindow,
+		LoginLockout:       set2update.Security.LoginLockout,
+		LoginDelay:         set2update.Security.LoginDelay,
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	err = db.UpdateSettings(ctx, dataSetting, cp_id)
 	if err != nil {
 		if err.Error() == "no rows updated" {
@@ -186,6 +215,7 @@ func UpdateSettingsDataAPI(c *gin.Context) {
 
 	go cron.CronFyc()
 	go cron.CronCounting()
+	go cron.CronSignRefresh()
 	log.Info().Int("carpark_id", cp_id).Msg("Settings updated successfully")
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
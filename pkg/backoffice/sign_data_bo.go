@@ -1,7 +1,6 @@
 package backoffice
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,7 +9,9 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"fyc/functions"
+	"fyc/pkg/counting"
 	"fyc/pkg/db"
+	"fyc/pkg/valkey"
 )
 
 // GetSignAPI godoc
@@ -25,7 +26,7 @@ import (
 //	@Router			/backoffice/getSign [get]
 func GetSignDataAPI(c *gin.Context) {
 	log.Debug().Msg("Starting GetSignAPI request")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	idStr := c.Query("sign_id")
 	var response []map[string]interface{}
 	//var responseSingle map[string]interface{}
@@ -155,7 +156,7 @@ func GetSignDataAPI(c *gin.Context) {
 func CreateSignDataAPI(c *gin.Context) {
 
 	log.Info().Msg(" - - - - - # Creating new sign # - - - - - ")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	var newSign db.Sign
 
 	if err := c.ShouldBindJSON(&newSign); err != nil {
@@ -220,7 +221,7 @@ func UpdateSignDataAPI(c *gin.Context) {
 
 	sign_id := c.Query("sign_id")
 	var updates db.SignNoBind
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	log.Info().Str("sign_id", sign_id).Msg("Updating sign")
 
 	if sign_id == "" {
@@ -302,7 +303,7 @@ func UpdateSignDataAPI(c *gin.Context) {
 //	@Router			/backoffice/deleteSign [delete]
 func DeleteSignDataAPI(c *gin.Context) {
 	idStr := c.Query("sign_id")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if idStr == "" {
 		log.Error().Msg("No sign ID provided for deletion")
@@ -364,3 +365,121 @@ func DeleteSignDataAPI(c *gin.Context) {
 		"code":         8,
 	})
 }
+
+// GetSignStatusDataAPI godoc
+//
+//	@Summary		Get signs delivery status
+//	@Description	Get the delivery status (last value sent, last ack, failures) of all signs or a specific sign by ID
+//	@Tags			Backoffice - Signs
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			sign_id	query		int				false	"sign ID"
+//	@Success		200		{object}	[]db.SignStatus	"Signs delivery status"
+//	@Router			/backoffice/getSignStatus [get]
+func GetSignStatusDataAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	idStr := c.Query("sign_id")
+
+	if idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			log.Error().Err(err).Str("id", idStr).Msg("Invalid sign ID format")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid ID format",
+				"message": "ID must be a valid integer",
+				"code":    12,
+			})
+			return
+		}
+
+		status, err := db.GetSignStatusByID(ctx, id)
+		if err != nil {
+			log.Warn().Err(err).Int("sign_id", id).Msg("No delivery status found for sign")
+			c.JSON(http.StatusOK, []db.SignStatus{})
+			return
+		}
+
+		c.JSON(http.StatusOK, []db.SignStatus{*status})
+		return
+	}
+
+	statuses, err := db.GetAllSignStatus(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving signs delivery status")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to retrieve signs status",
+			"code":    -500,
+		})
+		return
+	}
+
+	if len(statuses) == 0 {
+		c.JSON(http.StatusOK, []db.SignStatus{})
+		return
+	}
+
+	log.Info().Int("sign_count", len(statuses)).Msg("Returning signs delivery status")
+	c.JSON(http.StatusOK, statuses)
+}
+
+// RefreshSignDataAPI godoc
+//
+//	@Summary		Refresh signs
+//	@Description	Re-send the current free capacity to a specific sign, or to every enabled sign when no ID is given
+//	@Tags			Backoffice - Signs
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			sign_id	query	int	false	"sign ID"
+//	@Router			/backoffice/refreshSign [post]
+func RefreshSignDataAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	idStr := c.Query("sign_id")
+
+	if idStr == "" {
+		failed := counting.RefreshAllSigns(ctx)
+		c.JSON(http.StatusOK, gin.H{
+			"success": failed == 0,
+			"message": "Signs refresh finished",
+			"failed":  failed,
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		log.Err(err).Str("id", idStr).Msg("Invalid sign ID format for refresh")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "ID must be a valid integer",
+			"code":    12,
+		})
+		return
+	}
+
+	sign, err := db.GetSignById(ctx, id)
+	if err != nil || sign.IsDeleted {
+		log.Warn().Int("sign_id", id).Msg("Sign ID not exist !")
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("Sign ID %v not exist !", id),
+			"code":    -11,
+		})
+		return
+	}
+
+	if err := counting.RefreshSign(ctx, valkey.Valkey_GlobalVar, sign); err != nil {
+		log.Err(err).Int("sign_id", id).Msg("Failed to refresh sign")
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    10,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Sign refreshed successfully",
+	})
+}
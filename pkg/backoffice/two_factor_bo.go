@@ -0,0 +1,689 @@
+package backoffice
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/middleware"
+	"fyc/pkg/db"
+	"fyc/pkg/ratelimit"
+	"fyc/pkg/rbac"
+	"fyc/pkg/totp"
+	"fyc/pkg/valkey"
+)
+
+type TwoFactorLoginRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" example:"123456"`
+	RecoveryCode string `json:"recovery_code" example:"abcde-fghij"`
+}
+
+type TwoFactorEnrollRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+type RoleTwoFactorRequest struct {
+	Name     string `json:"name" binding:"required" example:"Admin"`
+	Required bool   `json:"required"`
+}
+
+func challengeKey(token string) string {
+	return "fyc:mfa:" + middleware.HashToken(token)
+}
+
+// startTwoFactorChallenge answers a login whose password was verified with a
+// single-use token, to be completed with a second factor.
+func startTwoFactorChallenge(ctx context.Context, c *gin.Context, user *db.User) {
+	token, err := middleware.GenerateOpaqueToken()
+	if err == nil {
+		ttl := time.Duration(config.Configvar.Session.TwoFactorChallenge) * time.Second
+		err = valkey.Valkey_GlobalVar.SetWithExpiry(ctx, challengeKey(token), user.UserName, ttl)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("Username", user.UserName).Msg("Error starting two-factor challenge")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	log.Info().Str("Username", user.UserName).Msg("Password verified, two-factor code required")
+	c.JSON(http.StatusOK, gin.H{
+		"success":             true,
+		"two_factor_required": true,
+		"mfa_token":           token,
+		"expires_in":          config.Configvar.Session.TwoFactorChallenge,
+		"message":             "Two-factor code required",
+	})
+}
+
+// verifySecondFactor checks the TOTP code, or else the recovery code which is
+// consumed.
+func verifySecondFactor(ctx context.Context, user *db.User, code string, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		// A code is accepted once, even within its validity
+		used, err := db.UseTOTPStep(ctx, user.UserName, step)
+		return used > 0, err
+	}
+
+	if recoveryCode == "" {
+		return false, nil
+	}
+	hash := middleware.HashToken(totp.NormalizeRecoveryCode(recoveryCode))
+	remaining := make([]string, 0, len(user.RecoveryCodes))
+	for _, stored := range user.RecoveryCodes {
+		if stored != hash {
+			remaining = append(remaining, stored)
+		}
+	}
+	if len(remaining) == len(user.RecoveryCodes) {
+		return false, nil
+	}
+
+	updated, err := db.SetUserRecoveryCodes(ctx, user.UserName, user.RecoveryCodes, remaining)
+	if err == nil && updated > 0 {
+		log.Info().Str("Username", user.UserName).Int("Remaining", len(remaining)).Msg("Recovery code used")
+	}
+	return updated > 0, err
+}
+
+// newRecoveryCodes generates recovery codes and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := totp.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = middleware.HashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// LoginTwoFactor godoc
+//
+//	@Summary		Complete a login with two-factor authentication
+//	@Description	Verify the authenticator code, or a recovery code, of the user whose password was accepted by /backoffice/login and open the session. The mfa_token is single-use, a wrong code requiring to log in again.
+//	@Tags			Backoffice - Login
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		TwoFactorLoginRequest	true	"Login token and code"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		401		{object}	map[string]interface{}
+//	@Failure		429		{object}	map[string]interface{}
+//	@Router			/backoffice/loginTwoFactor [post]
+func LoginTwoFactorAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var request TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil || (request.Code == "") == (request.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. mfa_token and either code or recovery_code are required.",
+			"code":    -5,
+		})
+		return
+	}
+
+	username, ok, err := valkey.Valkey_GlobalVar.GetAndDelete(ctx, challengeKey(request.MFAToken))
+	if err != nil {
+		log.Error().Err(err).Msg("Error reading two-factor challenge")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"code":    -3,
+			"message": "Login expired, please log in again",
+		})
+		return
+	}
+
+	attempt := loginAttempt(ctx, c, username)
+	if !attempt.Allowed {
+		loginLockedOut(ctx, c, username, attempt)
+		return
+	}
+
+	user, err := db.GetUserByUsername(ctx, username)
+	if err != nil || !user.IsEnabled || !user.TwoFactorEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"code":    -3,
+			"message": "Login expired, please log in again",
+		})
+		return
+	}
+
+	verified, err := verifySecondFactor(ctx, user, request.Code, request.RecoveryCode)
+	if err != nil {
+		log.Error().Err(err).Str("Username", username).Msg("Error verifying two-factor code")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if !verified {
+		loginFailed(ctx, c, username, db.AuthInvalidTwoFactor, attempt)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"code":    -1,
+			"message": "Invalid two-factor code, please log in again",
+		})
+		return
+	}
+
+	completeLogin(ctx, c, user, true)
+}
+
+// GetTwoFactor godoc
+//
+//	@Summary		Get my two-factor authentication
+//	@Description	Get whether two-factor authentication is enabled for the connected user, required by its role, and the number of recovery codes left
+//	@Tags			Backoffice - Two-factor
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Success		200	{object}	map[string]interface{}
+//	@Router			/backoffice/twoFactor [get]
+func GetTwoFactorAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	username := c.GetString(middleware.ContextUsername)
+
+	user, err := db.GetUserByUsername(ctx, username)
+	if err != nil {
+		log.Err(err).Str("Username", username).Msg("Error retrieving user")
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "User not found",
+			"code":    -4,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":             true,
+		"enabled":             user.TwoFactorEnabled,
+		"required":            rbac.RequiresTwoFactor(user.Role),
+		"session_verified":    c.GetBool(middleware.ContextTwoFactor),
+		"recovery_codes_left": len(user.RecoveryCodes),
+	})
+}
+
+// EnrollTwoFactor godoc
+//
+//	@Summary		Start the two-factor enrolment
+//	@Description	Generate a new authenticator secret for the connected user, returned with its otpauth URI to be shown as a QR code. Two-factor authentication is enabled once a code is confirmed on /backoffice/confirmTwoFactor.
+//	@Tags			Backoffice - Two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			request	body		TwoFactorEnrollRequest	true	"Current password"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		401		{object}	map[string]interface{}
+//	@Failure		409		{object}	map[string]interface{}
+//	@Router			/backoffice/enrollTwoFactor [post]
+func EnrollTwoFactorAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	username := c.GetString(middleware.ContextUsername)
+
+	var request TwoFactorEnrollRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	user, err := db.GetUserByUsername(ctx, username)
+	if err != nil || !db.VerifyUserPassword(ctx, user, request.Password) {
+		log.Warn().Str("Username", username).Msg("Two-factor enrolment with wrong password")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Password is incorrect",
+			"code":    -1,
+		})
+		return
+	}
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "Two-factor authentication is already enabled, disable it first",
+			"code":    -5,
+		})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err == nil {
+		_, err = db.SetUserPendingTOTP(ctx, username, secret)
+	}
+	if err != nil {
+		log.Err(err).Str("Username", username).Msg("Error starting two-factor enrolment")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"secret":      secret,
+		"otpauth_uri": totp.URI(config.Configvar.Session.TwoFactorIssuer, username, secret),
+		"message":     "Scan the QR code with your authenticator app and confirm a code",
+	})
+}
+
+// ConfirmTwoFactor godoc
+//
+//	@Summary		Confirm the two-factor enrolment
+//	@Description	Enable two-factor authentication with a code of the secret being enrolled. The recovery codes are returned only in this response, and the other sessions of the user are revoked.
+//	@Tags			Backoffice - Two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			request	body		TwoFactorCodeRequest	true	"Authenticator code"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		401		{object}	map[string]interface{}
+//	@Failure		429		{object}	map[string]interface{}
+//	@Router			/backoffice/confirmTwoFactor [post]
+func ConfirmTwoFactorAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	username := c.GetString(middleware.ContextUsername)
+
+	var request TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	user, err := db.GetUserByUsername(ctx, username)
+	if err != nil || user.TOTPPendingSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "No two-factor enrolment in progress",
+			"code":    -5,
+		})
+		return
+	}
+
+	if !twoFactorAttempt(ctx, c, username) {
+		return
+	}
+	step, ok := totp.Validate(user.TOTPPendingSecret, request.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Invalid two-factor code",
+			"code":    -1,
+		})
+		return
+	}
+
+	releaseTwoFactorAttempt(ctx, username)
+
+	codes, hashes, err := newRecoveryCodes()
+	var enabled int64
+	if err == nil {
+		enabled, err = db.EnableUserTwoFactor(ctx, username, user.TOTPPendingSecret, step, hashes)
+	}
+	if err == nil && enabled > 0 {
+		err = db.SetSessionTwoFactor(ctx, c.GetString(middleware.ContextSessionID))
+	}
+	if err != nil || enabled == 0 {
+		log.Err(err).Str("Username", username).Msg("Error enabling two-factor authentication")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if _, err := db.RevokeUserSessions(ctx, username, c.GetString(middleware.ContextSessionID), username); err != nil {
+		log.Err(err).Str("Username", username).Msg("Error revoking sessions after two-factor enrolment")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"recovery_codes": codes,
+		"message":        "Two-factor authentication enabled, keep the recovery codes in a safe place",
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+//
+//	@Summary		Regenerate my recovery codes
+//	@Description	Replace the recovery codes of the connected user, returned only in this response
+//	@Tags			Backoffice - Two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			request	body		TwoFactorCodeRequest	true	"Authenticator code"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		401		{object}	map[string]interface{}
+//	@Failure		429		{object}	map[string]interface{}
+//	@Router			/backoffice/recoveryCodes [post]
+func RegenerateRecoveryCodesAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	username := c.GetString(middleware.ContextUsername)
+
+	var request TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	user, ok := verifiedTwoFactorUser(ctx, c, username, request.Code)
+	if !ok {
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		_, err = db.SetUserRecoveryCodes(ctx, user.UserName, nil, hashes)
+	}
+	if err != nil {
+		log.Err(err).Str("Username", username).Msg("Error regenerating recovery codes")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"recovery_codes": codes,
+		"message":        "Recovery codes regenerated, the previous ones are no longer valid",
+	})
+}
+
+// DisableTwoFactor godoc
+//
+//	@Summary		Disable my two-factor authentication
+//	@Description	Disable two-factor authentication for the connected user, unless required by its role
+//	@Tags			Backoffice - Two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			request	body		TwoFactorDisableRequest	true	"Current password and authenticator code"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		401		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
+//	@Failure		429		{object}	map[string]interface{}
+//	@Router			/backoffice/disableTwoFactor [post]
+func DisableTwoFactorAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	username := c.GetString(middleware.ContextUsername)
+
+	var request TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	if rbac.RequiresTwoFactor(c.GetString(middleware.ContextRole)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "Two-factor authentication is required for your role",
+			"code":    -6,
+		})
+		return
+	}
+
+	user, ok := verifiedTwoFactorUser(ctx, c, username, request.Code)
+	if !ok {
+		return
+	}
+	if !db.VerifyUserPassword(ctx, user, request.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Password is incorrect",
+			"code":    -1,
+		})
+		return
+	}
+
+	if _, err := db.DisableUserTwoFactor(ctx, username); err != nil {
+		log.Err(err).Str("Username", username).Msg("Error disabling two-factor authentication")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// verifiedTwoFactorUser returns the user once its authenticator code is
+// verified, having answered the request otherwise.
+func verifiedTwoFactorUser(ctx context.Context, c *gin.Context, username string, code string) (*db.User, bool) {
+	user, err := db.GetUserByUsername(ctx, username)
+	if err != nil || !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Two-factor authentication is not enabled",
+			"code":    -5,
+		})
+		return nil, false
+	}
+
+	if !twoFactorAttempt(ctx, c, username) {
+		return nil, false
+	}
+	verified, err := verifySecondFactor(ctx, user, code, "")
+	if err != nil {
+		log.Err(err).Str("Username", username).Msg("Error verifying two-factor code")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return nil, false
+	}
+	if !verified {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Invalid two-factor code",
+			"code":    -1,
+		})
+		return nil, false
+	}
+	releaseTwoFactorAttempt(ctx, username)
+	return user, true
+}
+
+// twoFactorAttempt counts a code check of the connected user against the login
+// lockout of its username, so that a stolen session cannot try every code. It
+// answers the request when the username is locked out.
+func twoFactorAttempt(ctx context.Context, c *gin.Context, username string) bool {
+	policy := loadLoginPolicy(ctx)
+
+	decision := ratelimit.Throttle(ctx, loginUserKey(username), policy.MaxAttempts, policy.Window, policy.Lockout)
+	if !decision.Allowed {
+		log.Warn().Str("Username", username).Str("IP", c.ClientIP()).Msg("Two-factor code check locked out")
+		abortLockedOut(c, decision)
+		return false
+	}
+	return true
+}
+
+// releaseTwoFactorAttempt gives back the attempt of a verified code.
+func releaseTwoFactorAttempt(ctx context.Context, username string) {
+	if err := ratelimit.Release(ctx, loginUserKey(username)); err != nil {
+		log.Warn().Err(err).Str("Username", username).Msg("Error releasing two-factor attempt")
+	}
+}
+
+// ResetUserTwoFactor godoc
+//
+//	@Summary		Reset the two-factor authentication of a user
+//	@Description	Disable two-factor authentication for a user who lost its authenticator and recovery codes, and revoke its sessions. A role requiring it makes the user enroll again.
+//	@Tags			Backoffice - Users
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			username	query		string	true	"Username"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		403			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Router			/backoffice/userTwoFactor [delete]
+func ResetUserTwoFactorAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+	admin := c.GetString(middleware.ContextUsername)
+
+	username := c.Query("username")
+	if username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. username is required.",
+			"code":    -5,
+		})
+		return
+	}
+
+	// Removing the second factor of a user weakens its account, which is refused
+	// for users with more permissions than the connected one
+	user, err := db.GetUserByUsername(ctx, username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "User not found",
+			"code":    -4,
+		})
+		return
+	}
+	if !callerGrants(c, rbac.Permissions(user.Role)) {
+		return
+	}
+
+	rowsAffected, err := db.DisableUserTwoFactor(ctx, username)
+	if err != nil {
+		log.Err(err).Str("Username", username).Msg("Error resetting two-factor authentication")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "User not found",
+			"code":    -4,
+		})
+		return
+	}
+
+	if _, err := db.RevokeUserSessions(ctx, username, "", admin); err != nil {
+		log.Err(err).Str("Username", username).Msg("Error revoking sessions after two-factor reset")
+	}
+
+	log.Info().Str("Username", username).Str("By", admin).Msg("Two-factor authentication reset")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication reset successfully",
+	})
+}
+
+// SetRoleTwoFactor godoc
+//
+//	@Summary		Require two-factor authentication for a role
+//	@Description	Set whether the users of the role, system roles included, must use two-factor authentication. Users of the role without it can only enroll until they do.
+//	@Tags			Backoffice - Roles
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			request	body		RoleTwoFactorRequest	true	"Role and policy"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Router			/backoffice/roleTwoFactor [put]
+func SetRoleTwoFactorAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var request RoleTwoFactorRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	rowsAffected, err := db.SetRoleTwoFactor(ctx, request.Name, request.Required)
+	if err != nil {
+		log.Error().Err(err).Str("Role", request.Name).Msg("Error updating two-factor policy")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Role not found",
+			"code":    -4,
+		})
+		return
+	}
+	reloadRoles(ctx)
+
+	log.Info().Str("Role", request.Name).Bool("Required", request.Required).Msg("Two-factor policy updated")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor policy updated successfully",
+	})
+}
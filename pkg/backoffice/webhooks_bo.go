@@ -0,0 +1,209 @@
+package backoffice
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/functions"
+	"fyc/pkg/db"
+	"fyc/pkg/webhooks"
+)
+
+// GetWebhooksAPI godoc
+//
+//	@Summary		Get webhook subscriptions
+//	@Description	Get the webhook subscriptions of a client, or of all clients
+//	@Tags			Backoffice - Webhooks
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			client_id	query	string	false	"Client ID"
+//	@Success		200			{array}	db.WebhookSubscription
+//	@Router			/backoffice/getWebhooks [get]
+func GetWebhooksAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscriptions, err := db.GetWebhookSubscriptions(ctx, c.Query("client_id"))
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving webhook subscriptions")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if subscriptions == nil {
+		subscriptions = []db.WebhookSubscription{}
+	}
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// DeleteWebhookAPI godoc
+//
+//	@Summary		Delete a webhook subscription
+//	@Description	Delete a webhook subscription of any client, its pending deliveries end in the dead letters
+//	@Tags			Backoffice - Webhooks
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			id	query		int	true	"Subscription ID"
+//	@Success		200	{object}	map[string]interface{}
+//	@Router			/backoffice/deleteWebhook [delete]
+func DeleteWebhookAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. id parameter must be a valid integer.",
+			"code":    -5,
+		})
+		return
+	}
+
+	rowsAffected, err := db.DeleteWebhookSubscription(ctx, "", id)
+	if err != nil {
+		log.Error().Err(err).Int("id", id).Msg("Error deleting webhook subscription")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("Webhook subscription %d doesn't exist !", id),
+			"code":    -4,
+		})
+		return
+	}
+
+	log.Info().Int("id", id).Msg("Webhook subscription deleted from backoffice")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook subscription deleted successfully",
+	})
+}
+
+// GetWebhookDeliveriesAPI godoc
+//
+//	@Summary		Get webhook deliveries
+//	@Description	Get the webhook delivery log, newest first. Filter on status dead for the dead-letter store.
+//	@Tags			Backoffice - Webhooks
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			client_id		query	string	false	"Client ID"
+//	@Param			subscription_id	query	int		false	"Subscription ID"
+//	@Param			status			query	string	false	"Delivery status"	Enums(pending, sending, delivered, dead)
+//	@Param			limit			query	int		false	"Maximum number of deliveries, up to 1000"	default(100)
+//	@Success		200				{array}	db.WebhookDelivery
+//	@Router			/backoffice/getWebhookDeliveries [get]
+func GetWebhookDeliveriesAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	filter := db.WebhookDeliveryFilter{
+		ClientID: c.Query("client_id"),
+		Status:   c.Query("status"),
+	}
+
+	if filter.Status != "" && !functions.ContainsStr([]string{db.DeliveryPending, db.DeliverySending, db.DeliveryDelivered, db.DeliveryDead}, filter.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid status, expected pending, sending, delivered or dead",
+			"code":    -5,
+		})
+		return
+	}
+
+	var err error
+	if subscription := c.Query("subscription_id"); subscription != "" {
+		if filter.SubscriptionID, err = strconv.Atoi(subscription); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid subscription_id, expected a number",
+				"code":    -5,
+			})
+			return
+		}
+	}
+
+	filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || filter.Limit < 1 || filter.Limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid limit, expected a number between 1 and 1000",
+			"code":    -5,
+		})
+		return
+	}
+
+	deliveries, err := db.GetWebhookDeliveries(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error retrieving webhook deliveries")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []db.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayWebhookDeliveryAPI godoc
+//
+//	@Summary		Replay a webhook delivery
+//	@Description	Queue the payload of a logged delivery, typically a dead letter, to be sent again to its subscription
+//	@Tags			Backoffice - Webhooks
+//	@Produce		json
+//	@Security		BearerAuthBackOffice
+//	@Param			id	query		int	true	"Delivery ID"
+//	@Success		201	{object}	db.WebhookDelivery
+//	@Router			/backoffice/replayWebhookDelivery [post]
+func ReplayWebhookDeliveryAPI(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request. id parameter must be a valid integer.",
+			"code":    -5,
+		})
+		return
+	}
+
+	replay, err := webhooks.Replay(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Int("id", id).Msg("Error replaying webhook delivery")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if replay == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("Webhook delivery %d doesn't exist !", id),
+			"code":    -4,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, replay)
+}
@@ -1,7 +1,6 @@
 package backoffice
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,6 +24,9 @@ type AddZone struct {
 	LastUpdated  string                 `json:"-"`
 	Images       json.RawMessage        `json:"images" binding:"required" swaggertype:"object"`
 	Extra        map[string]interface{} `json:"extra" swaggertype:"object"`
+	PkaBayID     *int                   `json:"pka_bay_id"`
+	PkaX         *float64               `json:"pka_x"`
+	PkaY         *float64               `json:"pka_y"`
 }
 
 type Image struct {
@@ -48,6 +50,9 @@ type UpdateZone struct {
 	Extra        map[string]interface{} `json:"extra" swaggertype:"object"`
 	IsDeleted    *bool                  `json:"-"`
 	IsEnabled    *bool                  `json:"is_enabled"`
+	PkaBayID     *int                   `json:"pka_bay_id"`
+	PkaX         *float64               `json:"pka_x"`
+	PkaY         *float64               `json:"pka_y"`
 }
 
 // GetZonesAPI godoc
@@ -62,7 +67,7 @@ type UpdateZone struct {
 //	@Router			/backoffice/get_zones [get]
 //	@Success		200	{array}	models.ZoneDataModel2	"List of zones or a single zone"
 func GetZonesAPI(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	extraReq := strings.ToLower(c.DefaultQuery("extra", "false"))
 	idStr := c.Query("zone_id")
 
@@ -211,7 +216,7 @@ func GetZonesAPI(c *gin.Context) {
 //	@Success		200	{array}	models.ZoneNamesModel	"List of zones or a single zone"
 //	@Router			/backoffice/get_zones_names [get]
 func GetZonesNames(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	log.Debug().Msg("Fetching all zone names")
 
@@ -269,7 +274,7 @@ func GetZonesNames(c *gin.Context) {
 //	@Router			/backoffice/add_zone [post]
 func CreateZone(c *gin.Context) {
 	var addZone AddZone
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	if err := c.ShouldBindJSON(&addZone); err != nil {
 		log.Error().Err(err).Msg("Invalid request payload for zone creation")
@@ -320,6 +325,9 @@ func CreateZone(c *gin.Context) {
 		MaxCapacity:  addZone.MaxCapacity,
 		FreeCapacity: addZone.FreeCapacity,
 		Extra:        addZone.Extra,
+		PkaBayID:     addZone.PkaBayID,
+		PkaX:         addZone.PkaX,
+		PkaY:         addZone.PkaY,
 	}
 
 	var zoneImages map[string]map[string]string
@@ -395,7 +403,7 @@ func CreateZone(c *gin.Context) {
 //	@Router			/backoffice/update_zone [put]
 func UpdateZoneDataAPI(c *gin.Context) {
 	var Zone2update UpdateZone
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	zone_id := c.Query("zone_id")
 	log.Info().Str("id", zone_id).Msg("Updating Zone ")
@@ -470,6 +478,9 @@ func UpdateZoneDataAPI(c *gin.Context) {
 		Extra:        Zone2update.Extra,
 		IsEnabled:    Zone2update.IsEnabled,
 		IsDeleted:    Zone2update.IsDeleted,
+		PkaBayID:     Zone2update.PkaBayID,
+		PkaX:         Zone2update.PkaX,
+		PkaY:         Zone2update.PkaY,
 	}
 
 	//log.Debug().Interface("Data -*-*-*-* ", updateZone).Send()
@@ -589,7 +600,7 @@ func DeleteZoneDataAPI(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	rowsAffected, err := db.DeleteZone(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("Error deleting Zone")
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/counting"
+	"fyc/pkg/db"
+	"fyc/pkg/events"
+	"fyc/pkg/valkey"
+)
+
+const (
+	CmdRefreshSigns = "refresh_signs"
+	CmdRefreshSign  = "refresh_sign"
+	CmdAdjustCount  = "adjust_count"
+	CmdSetCount     = "set_count"
+	CmdReloadCache  = "reload_cache"
+)
+
+// Command is a request sent by an external system on the Valkey command channel, e.g.
+//
+//	{"command": "refresh_sign", "sign_id": 3}
+//	{"command": "adjust_count", "zone_id": 2, "delta": -1}
+//	{"command": "set_count", "zone_id": 2, "free_capacity": 40}
+type Command struct {
+	Command      string `json:"command"`
+	SignID       int    `json:"sign_id,omitempty"`
+	ZoneID       int    `json:"zone_id,omitempty"`
+	Delta        int    `json:"delta,omitempty"`
+	FreeCapacity *int   `json:"free_capacity,omitempty"`
+}
+
+// StartCommandListener subscribes to the command channel and executes the
+// received commands until ctx is cancelled.
+func StartCommandListener(ctx context.Context) {
+	channel := config.Configvar.Valkey.CommandChannel
+	log.Info().Str("channel", channel).Msg("------------------------------ # VALKEY COMMAND LISTENER STARTED # ------------------------------")
+
+	valkey.Valkey_GlobalVar.Subscribe(ctx, channel, func(message string) {
+		var cmd Command
+		if err := json.Unmarshal([]byte(message), &cmd); err != nil {
+			log.Warn().Err(err).Str("message", message).Msg("Invalid command received on Valkey channel")
+			return
+		}
+
+		if err := Execute(ctx, cmd); err != nil {
+			log.Err(err).Str("command", cmd.Command).Msg("Error executing Valkey command")
+			return
+		}
+		log.Info().Str("command", cmd.Command).Int("sign_id", cmd.SignID).Int("zone_id", cmd.ZoneID).Msg("Valkey command executed")
+	})
+}
+
+// Execute runs a single command.
+func Execute(ctx context.Context, cmd Command) error {
+	switch cmd.Command {
+	case CmdRefreshSigns:
+		if failed := counting.RefreshAllSigns(ctx); failed > 0 {
+			return fmt.Errorf("%d signs could not be refreshed", failed)
+		}
+		return nil
+
+	case CmdRefreshSign:
+		sign, err := db.GetSignById(ctx, cmd.SignID)
+		if err != nil {
+			return err
+		}
+		return counting.RefreshSign(ctx, valkey.Valkey_GlobalVar, sign)
+
+	case CmdAdjustCount:
+		// Adjusted in a single statement, so that the counts of the cameras
+		// updated meanwhile are not lost
+		rows, err := db.AdjustZoneFreeCapacity(ctx, cmd.ZoneID, cmd.Delta)
+		return zoneCountUpdated(ctx, cmd.ZoneID, rows, err)
+
+	case CmdSetCount:
+		if cmd.FreeCapacity == nil {
+			return fmt.Errorf("free_capacity is required for %s", CmdSetCount)
+		}
+		rows, err := db.SetZoneFreeCapacity(ctx, cmd.ZoneID, *cmd.FreeCapacity)
+		return zoneCountUpdated(ctx, cmd.ZoneID, rows, err)
+
+	case CmdReloadCache:
+		db.ReloadCache()
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command: %q", cmd.Command)
+	}
+}
+
+// zoneCountUpdated publishes the capacity of a zone whose count was set or
+// adjusted by a command and refreshes its sign.
+func zoneCountUpdated(ctx context.Context, zoneID int, rows int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("zone %d not found", zoneID)
+	}
+
+	if zone, err := db.GetZoneByID(ctx, zoneID); err == nil && zone.FreeCapacity != nil && zone.MaxCapacity != nil {
+		events.PublishCapacityChange(ctx, events.CapacityChange{
+			ZoneID:       zone.ZoneID,
+			FreeCapacity: *zone.FreeCapacity,
+			MaxCapacity:  *zone.MaxCapacity,
+			Operation:    "set",
+		})
+	}
+
+	if err := counting.RefreshZoneSign(ctx, zoneID); err != nil {
+		log.Warn().Err(err).Int("zone_id", zoneID).Msg("Zone count updated but sign not refreshed")
+	}
+	return nil
+}
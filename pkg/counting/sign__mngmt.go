@@ -4,17 +4,11 @@ import (
 	"context"
 	"fmt"
 	"fyc/pkg/db"
-	"fyc/pkg/valkey"
 
 	"github.com/rs/zerolog/log"
 )
 
-func Sign_Data_Values(zone_id int, meth string, places_free string) string {
-	ctx := context.Background()
-	var valk valkey.ValkeyStrct
-
-	valk.Valkey_Connect()
-
+func Sign_Data_Values(ctx context.Context, zone_id int, meth string, places_free string) string {
 	if meth == "inc" {
 		log.Debug().Msg(" - - - SIGN Increase Value - - - ")
 
@@ -37,11 +31,9 @@ func Sign_Data_Values(zone_id int, meth string, places_free string) string {
 
 		if *capacity.FreeCapacity < *capacity.MaxCapacity && *capacity.FreeCapacity > 0 {
 			log.Info().Int("zone_id", zone_id).Int("FreeCapacity", *capacity.FreeCapacity).Int("MAXCapacity", *capacity.MaxCapacity).Msg("Sign data values are valid")
-			var SignHost = fmt.Sprintf("%s:%d", sign.SignIP, sign.SignPort)
 
 			//valk.Valkey_Incr_Data(ctx, SignHost)
-			valk.Valkey_Setter_Data(ctx, SignHost, places_free)
-			valk.PublishMessage(ctx, SignHost)
+			QueueSignValue(sign, places_free)
 		}
 
 		return sign.SignIP
@@ -70,8 +62,7 @@ func Sign_Data_Values(zone_id int, meth string, places_free string) string {
 		log.Info().Int("zone_id", zone_id).Str("Sign HOST", SignHost).Int("places_count", *capacity.FreeCapacity).Msg("Sign data values are valid")
 
 		//valk.Valkey_Decr_Data(ctx, SignHost)
-		valk.Valkey_Setter_Data(ctx, SignHost, places_free)
-		valk.PublishMessage(ctx, SignHost)
+		QueueSignValue(sign, places_free)
 	}
 
 	return sign.SignIP
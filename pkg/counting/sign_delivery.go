@@ -0,0 +1,135 @@
+package counting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/db"
+	"fyc/pkg/events"
+	"fyc/pkg/valkey"
+)
+
+// DeliverSignValue stores the value for the sign in Valkey and notifies the sign
+// drivers, retrying with exponential backoff until the notification reaches a
+// driver. The outcome is recorded in the sign delivery status. The value
+// counts as delivered once Valkey reports at least one subscribed driver: the
+// drivers do not confirm that the sign shows it. The camera ingest queues its
+// values with QueueSignValue instead, so as not to wait for the retries.
+func DeliverSignValue(ctx context.Context, valk *valkey.ValkeyStrct, sign *db.SignResp, value string) error {
+	var SignHost = fmt.Sprintf("%s:%d", sign.SignIP, sign.SignPort)
+
+	attempts := config.Configvar.Valkey.SignRetries + 1
+	delay := time.Duration(config.Configvar.Valkey.SignRetryDelay) * time.Millisecond
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = publishSignValue(ctx, valk, SignHost, value)
+		if err == nil {
+			log.Info().Int("sign_id", sign.SignID).Str("Sign HOST", SignHost).Str("value", value).Int("attempt", attempt).Msg("Sign value delivered")
+			break
+		}
+
+		log.Warn().Err(err).Int("sign_id", sign.SignID).Str("Sign HOST", SignHost).Int("attempt", attempt).Msg("Sign value delivery failed")
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(delay):
+			delay *= 2
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if saveErr := db.SaveSignDelivery(ctx, sign.SignID, sign.ZoneID, value, err); saveErr != nil {
+		log.Err(saveErr).Int("sign_id", sign.SignID).Msg("Error recording sign delivery")
+	}
+
+	update := events.SignUpdate{SignID: sign.SignID, ZoneID: sign.ZoneID, Value: value, Delivered: err == nil}
+	if err != nil {
+		update.Error = err.Error()
+	}
+	events.PublishSignUpdate(ctx, update)
+
+	return err
+}
+
+func publishSignValue(ctx context.Context, valk *valkey.ValkeyStrct, SignHost string, value string) error {
+	if !valk.Valkey_Setter_Data(ctx, SignHost, value) {
+		return fmt.Errorf("error setting value %s for sign %s", value, SignHost)
+	}
+
+	receivers, err := valk.PublishMessage(ctx, SignHost)
+	if err != nil {
+		return err
+	}
+
+	if receivers == 0 {
+		return fmt.Errorf("no sign driver subscribed to channel %s", config.Configvar.Valkey.Channel)
+	}
+
+	return nil
+}
+
+// RefreshAllSigns re-sends the current free capacity of its zone to every
+// enabled sign and returns the number of signs that could not be refreshed.
+func RefreshAllSigns(ctx context.Context) int {
+	signs, err := db.GetSignByStatus(ctx, "enabled")
+	if err != nil {
+		log.Err(err).Msg("Error retrieving enabled signs for refresh")
+		return 0
+	}
+
+	if len(signs) == 0 {
+		log.Debug().Msg("No enabled signs to refresh")
+		return 0
+	}
+
+	failed := 0
+	for i := range signs {
+		if err := RefreshSign(ctx, valkey.Valkey_GlobalVar, &signs[i]); err != nil {
+			failed++
+		}
+	}
+
+	log.Info().Int("signs", len(signs)).Int("failed", failed).Msg("Signs refresh finished")
+	return failed
+}
+
+// RefreshSign re-sends the current free capacity of its zone to a single sign.
+func RefreshSign(ctx context.Context, valk *valkey.ValkeyStrct, sign *db.SignResp) error {
+	zone, err := db.GetZoneByID(ctx, sign.ZoneID)
+	if err != nil {
+		log.Err(err).Int("sign_id", sign.SignID).Int("zone_id", sign.ZoneID).Msg("Error fetching zone for sign refresh")
+		return err
+	}
+	if zone.FreeCapacity == nil {
+		log.Warn().Int("sign_id", sign.SignID).Int("zone_id", sign.ZoneID).Msg("Zone without free capacity, sign not refreshed")
+		return fmt.Errorf("zone %d has no free capacity", sign.ZoneID)
+	}
+
+	return DeliverSignValue(ctx, valk, sign, fmt.Sprintf("%d", *zone.FreeCapacity))
+}
+
+// RefreshZoneSign re-sends the current free capacity to the sign of a zone.
+func RefreshZoneSign(ctx context.Context, zoneID int) error {
+	sign, err := db.GetSignByZoneId(ctx, zoneID)
+	if err != nil {
+		return err
+	}
+
+	if sign.IsDeleted || !sign.IsEnabled {
+		return fmt.Errorf("sign of zone %d is disabled", zoneID)
+	}
+
+	return RefreshSign(ctx, valkey.Valkey_GlobalVar, sign)
+}
@@ -0,0 +1,90 @@
+package counting
+
+import (
+	"context"
+	"sync"
+
+	"fyc/pkg/db"
+	"fyc/pkg/valkey"
+)
+
+// Sign values waiting to be delivered in the background, the latest one per
+// sign, so that the camera ingest is not held by the retries of a delivery.
+var signQueue = struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	pending map[int]queuedSignValue
+	running map[int]bool
+	workers sync.WaitGroup
+}{
+	ctx:     context.Background(),
+	pending: map[int]queuedSignValue{},
+	running: map[int]bool{},
+}
+
+type queuedSignValue struct {
+	sign  db.SignResp
+	value string
+}
+
+// StartSignDelivery sets the context of the background sign deliveries, whose
+// retries stop once it is cancelled.
+func StartSignDelivery(ctx context.Context) {
+	signQueue.mu.Lock()
+	defer signQueue.mu.Unlock()
+	signQueue.ctx = ctx
+}
+
+// QueueSignValue queues the value for delivery to the sign. A value of the
+// sign still waiting is replaced, the sign only having to show the latest.
+// Values of a sign are delivered one at a time, in order.
+func QueueSignValue(sign *db.SignResp, value string) {
+	signQueue.mu.Lock()
+	defer signQueue.mu.Unlock()
+
+	signQueue.pending[sign.SignID] = queuedSignValue{sign: *sign, value: value}
+	if signQueue.running[sign.SignID] {
+		return
+	}
+
+	signQueue.running[sign.SignID] = true
+	signQueue.workers.Add(1)
+	go deliverQueuedSignValues(signQueue.ctx, sign.SignID)
+}
+
+// deliverQueuedSignValues delivers the values queued for the sign until none
+// is left.
+func deliverQueuedSignValues(ctx context.Context, signID int) {
+	defer signQueue.workers.Done()
+
+	for {
+		signQueue.mu.Lock()
+		queued, ok := signQueue.pending[signID]
+		if !ok || ctx.Err() != nil {
+			delete(signQueue.running, signID)
+			signQueue.mu.Unlock()
+			return
+		}
+		delete(signQueue.pending, signID)
+		signQueue.mu.Unlock()
+
+		DeliverSignValue(ctx, valkey.Valkey_GlobalVar, &queued.sign, queued.value)
+	}
+}
+
+// WaitSignDelivery waits for the sign deliveries in progress, or until ctx is
+// done.
+func WaitSignDelivery(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		signQueue.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -3,6 +3,7 @@ package counting
 import (
 	"context"
 	"fyc/pkg/db"
+	"fyc/pkg/events"
 
 	"github.com/rs/zerolog/log"
 )
@@ -28,6 +29,7 @@ func Increase_Zone_Capacity(ctx context.Context, CurrZone int, lpn string) int {
 		log.Error().Str("Error: ", err.Error()).Int("Zone ID", CurrZone).Msg("Error retrieving zone")
 	}
 
+	publishCapacityChange(ctx, zoneData, "inc")
 	return *zoneData.FreeCapacity
 
 }
@@ -55,5 +57,19 @@ func Decrease_Zone_Capacity(ctx context.Context, CurrZone int, lpn string) int {
 		log.Error().Str("Error: ", err.Error()).Int("Zone ID", CurrZone).Msg("Error retrieving zone")
 	}
 
+	publishCapacityChange(ctx, zoneData, "dec")
 	return *zoneData.FreeCapacity
 }
+
+func publishCapacityChange(ctx context.Context, zone *db.Zone, operation string) {
+	if zone == nil || zone.FreeCapacity == nil || zone.MaxCapacity == nil {
+		return
+	}
+
+	events.PublishCapacityChange(ctx, events.CapacityChange{
+		ZoneID:       zone.ZoneID,
+		FreeCapacity: *zone.FreeCapacity,
+		MaxCapacity:  *zone.MaxCapacity,
+		Operation:    operation,
+	})
+}
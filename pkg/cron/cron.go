@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"context"
+	"sync"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	schedulersMu sync.Mutex
+	schedulers   = make(map[string]*cron.Cron)
+	// Context of the service, read by the settings and the jobs, including
+	// when rescheduled on a change of the settings
+	jobsCtx = context.Background()
+)
+
+// Start schedules the jobs, which run with ctx.
+func Start(ctx context.Context) {
+	schedulersMu.Lock()
+	jobsCtx = ctx
+	schedulersMu.Unlock()
+
+	CronFyc()
+	CronCounting()
+	CronSignRefresh()
+}
+
+func jobContext() context.Context {
+	schedulersMu.Lock()
+	defer schedulersMu.Unlock()
+	return jobsCtx
+}
+
+// schedule starts the scheduler of the job, stopping the one previously
+// scheduled under the same name, a nil scheduler only stopping it.
+func schedule(name string, c *cron.Cron) {
+	schedulersMu.Lock()
+	defer schedulersMu.Unlock()
+
+	if previous, exists := schedulers[name]; exists {
+		previous.Stop()
+		delete(schedulers, name)
+	}
+	if c != nil {
+		c.Start()
+		schedulers[name] = c
+	}
+}
+
+// Stop stops every scheduler and waits for the running jobs, or until ctx is
+// done.
+func Stop(ctx context.Context) {
+	schedulersMu.Lock()
+	running := make([]context.Context, 0, len(schedulers))
+	for name, c := range schedulers {
+		running = append(running, c.Stop())
+		delete(schedulers, name)
+	}
+	schedulersMu.Unlock()
+
+	for _, jobs := range running {
+		select {
+		case <-jobs.Done():
+		case <-ctx.Done():
+			log.Warn().Msg("Cron jobs still running at shutdown")
+			return
+		}
+	}
+	log.Info().Msg("------------------------------ # CRON STOPPED # ------------------------------")
+}
@@ -11,7 +11,7 @@ import (
 )
 
 func CronCounting() {
-	ctx := context.Background()
+	ctx := jobContext()
 	defaultCronHour := 00
 	cronHour := defaultCronHour
 	CronEnabled := false
@@ -36,25 +36,22 @@ func CronCounting() {
 		cronExpression := fmt.Sprintf("0 %d * * *", cronHour)
 		c := cron.New()
 
-		_, err = c.AddFunc(cronExpression, CronJobCounting)
+		_, err = c.AddFunc(cronExpression, func() { CronJobCounting(ctx) })
 		if err != nil {
 			log.Err(err).Msg("Error adding cron counting job")
 			return
 		}
 
-		c.Start()
+		schedule("counting", c)
 		log.Info().Msgf("------------------------------ # Cron Job Counting Scheduled to Run Daily at %v:00 # ------------------------------", cronHour)
-
-		select {}
 	} else {
+		schedule("counting", nil)
 		log.Warn().Msg("------------------------------ # CRON COUNTING DISABLED # ------------------------------ ")
 	}
 
 }
 
-func CronJobCounting() {
-	//ctx := context.Background()
-
+func CronJobCounting(ctx context.Context) {
 	log.Debug().Msg("------------------------------ # Cron Counting Job STARTED # ------------------------------ ")
 	/* if err := db.Db_GlobalVar.ResetModel(ctx, &db.PresentCar{}); err != nil {
 		log.Error().Str("Error", err.Error()).Msg("Failed to reset PresentCar table")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"fyc/functions"
 	"fyc/pkg/db"
 
 	"github.com/robfig/cron/v3"
@@ -11,7 +12,7 @@ import (
 )
 
 func CronFyc() {
-	ctx := context.Background()
+	ctx := jobContext()
 	defaultCronHour := 00
 	cronHour := defaultCronHour
 	CronEnabled := false
@@ -38,30 +39,42 @@ func CronFyc() {
 		cronExpression := fmt.Sprintf("0 %d * * *", cronHour)
 		c := cron.New()
 
-		_, err = c.AddFunc(cronExpression, CronJobFyc)
+		_, err = c.AddFunc(cronExpression, func() { CronJobFyc(ctx) })
 		if err != nil {
 			log.Err(err).Msg("Error adding cron fyc job")
 			return
 		}
 
-		c.Start()
+		schedule("fyc", c)
 		log.Info().Msgf("------------------------------ # Cron Job FYC Scheduled to Run Daily at %v:00 # ------------------------------", cronHour)
-
-		select {}
 	} else {
+		schedule("fyc", nil)
 		log.Warn().Msg("------------------------------ # CRON FYC DISABLED # ------------------------------ ")
 	}
 
 }
 
-func CronJobFyc() {
-	ctx := context.Background()
-
+func CronJobFyc(ctx context.Context) {
 	log.Debug().Msg("------------------------------ # Cron Job STARTED # ------------------------------ ")
 	if err := db.Db_GlobalVar.ResetModel(ctx, &db.PresentCar{}); err != nil {
 		log.Error().Str("Error", err.Error()).Msg("Failed to reset PresentCar table")
 		return
 	}
+	if err := db.IndexPresentCars(ctx); err != nil {
+		log.Err(err).Msg("Failed to index PresentCar table")
+	}
+
+	if deleted, err := db.DeleteExpiredOAuthTokens(ctx, functions.GetFormatedLocalTime()); err != nil {
+		log.Err(err).Msg("Failed to delete expired OAuth tokens")
+	} else {
+		log.Debug().Int64("deleted", deleted).Msg("Expired OAuth tokens deleted")
+	}
+
+	if deleted, err := db.DeleteExpiredBackofficeSessions(ctx, functions.GetFormatedLocalTime()); err != nil {
+		log.Err(err).Msg("Failed to delete expired backoffice sessions")
+	} else {
+		log.Debug().Int64("deleted", deleted).Msg("Expired backoffice sessions deleted")
+	}
 
 	log.Info().Msg("Cron FYC Successfully worked")
 	log.Debug().Msg("------------------------------ # Cron FYC Job FINISHED # ------------------------------ ")
@@ -0,0 +1,58 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/counting"
+	"fyc/pkg/db"
+)
+
+// CronSignRefresh (re)schedules the periodic full refresh of all enabled signs
+// using the interval in minutes from the settings.
+func CronSignRefresh() {
+	ctx := jobContext()
+	defaultInterval := 5
+	interval := defaultInterval
+	CronEnabled := false
+
+	settings, err := db.GetAllSettings(ctx)
+	if err != nil {
+		log.Warn().Msgf("Error retrieving Settings from the database -- CRON SIGN REFRESH DISABLED -- Error %s", err)
+	} else {
+		interval = settings.SignRefreshCron
+		CronEnabled = settings.IsSignRefresh
+	}
+
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	if !CronEnabled {
+		schedule("sign_refresh", nil)
+		log.Warn().Msg("------------------------------ # CRON SIGN REFRESH DISABLED # ------------------------------ ")
+		return
+	}
+
+	c := cron.New()
+	_, err = c.AddFunc(fmt.Sprintf("@every %dm", interval), func() { CronJobSignRefresh(ctx) })
+	if err != nil {
+		log.Err(err).Msg("Error adding cron sign refresh job")
+		return
+	}
+
+	schedule("sign_refresh", c)
+	log.Info().Msgf("------------------------------ # Cron Job Sign Refresh Scheduled to Run Every %d minutes # ------------------------------", interval)
+}
+
+func CronJobSignRefresh(ctx context.Context) {
+	log.Debug().Msg("------------------------------ # Cron Sign Refresh Job STARTED # ------------------------------ ")
+	failed := counting.RefreshAllSigns(ctx)
+	if failed > 0 {
+		log.Warn().Int("failed", failed).Msg("Some signs could not be refreshed")
+	}
+	log.Debug().Msg("------------------------------ # Cron Sign Refresh Job FINISHED # ------------------------------ ")
+}
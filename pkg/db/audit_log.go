@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+
+	"fyc/functions"
+)
+
+// AuditLog records a change made to the configuration, with the state of the
+// entity before and after it. Rows are never updated nor deleted.
+type AuditLog struct {
+	bun.BaseModel `json:"-" bun:"table:audit_log"`
+	ID            int64                  `bun:"id,pk,autoincrement" json:"id"`
+	ActionDate    string                 `bun:"action_date,type:timestamp" json:"action_date"`
+	Username      string                 `bun:"username" json:"username"`
+	Role          string                 `bun:"role" json:"role"`
+	ClientIP      string                 `bun:"client_ip" json:"client_ip"`
+	Module        string                 `bun:"module" json:"module"`
+	Action        string                 `bun:"action" json:"action"`
+	EntityID      string                 `bun:"entity_id" json:"entity_id"`
+	Method        string                 `bun:"method" json:"method"`
+	Path          string                 `bun:"path" json:"path"`
+	Status        int                    `bun:"status" json:"status"`
+	OldValue      map[string]interface{} `bun:"old_value,type:jsonb" json:"old_value" swaggertype:"object"`
+	NewValue      map[string]interface{} `bun:"new_value,type:jsonb" json:"new_value" swaggertype:"object"`
+}
+
+type AuditFilter struct {
+	Username string
+	Module   string
+	Action   string
+	EntityID string
+	// From and To bound the action date, formatted as 2006-01-02 15:04:05 UTC
+	From   string
+	To     string
+	Limit  int
+	Offset int
+}
+
+func CreateAuditLog(ctx context.Context, entry *AuditLog) error {
+	if entry.ActionDate == "" {
+		entry.ActionDate = functions.GetFormatedLocalTime()
+	}
+
+	_, err := Db_GlobalVar.NewInsert().Model(entry).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error adding audit log: %w", err)
+	}
+	return nil
+}
+
+// GetAuditLogs returns the entries matching the filter, most recent first, and
+// the number of matching entries ignoring the limit and offset.
+func GetAuditLogs(ctx context.Context, filter AuditFilter) ([]AuditLog, int, error) {
+	var entries []AuditLog
+	query := Db_GlobalVar.NewSelect().Model(&entries)
+
+	if filter.Username != "" {
+		query.Where("username = ?", filter.Username)
+	}
+	if filter.Module != "" {
+		query.Where("module = ?", filter.Module)
+	}
+	if filter.Action != "" {
+		query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityID != "" {
+		query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.From != "" {
+		query.Where("action_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query.Where("action_date <= ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query.Offset(filter.Offset)
+	}
+
+	total, err := query.Order("id DESC").ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting audit logs: %w", err)
+	}
+
+	for i := range entries {
+		entries[i].ActionDate, _ = functions.ParseTimeData(entries[i].ActionDate)
+	}
+	return entries, total, nil
+}
+
+// ProtectAuditLog installs a trigger rejecting any update or delete of the
+// audit log, so that entries cannot be altered even through SQL.
+func ProtectAuditLog(ctx context.Context) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_log is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log`,
+		`CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only()`,
+	}
+
+	for _, statement := range statements {
+		if _, err := Db_GlobalVar.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("error protecting audit log: %w", err)
+		}
+	}
+	return nil
+}
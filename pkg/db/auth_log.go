@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+
+	"fyc/functions"
+)
+
+// Outcomes of a login attempt
+const (
+	AuthSuccess            = "success"
+	AuthInvalidCredentials = "invalid_credentials"
+	AuthInvalidTwoFactor   = "invalid_two_factor"
+	AuthUserDisabled       = "user_disabled"
+	AuthLockedOut          = "locked_out"
+)
+
+// AuthLog records a login attempt on the backoffice, successful or not.
+type AuthLog struct {
+	bun.BaseModel `json:"-" bun:"table:auth_log"`
+	ID            int64  `bun:"id,pk,autoincrement" json:"id"`
+	AttemptDate   string `bun:"attempt_date,type:timestamp" json:"attempt_date"`
+	Username      string `bun:"username" json:"username"`
+	ClientIP      string `bun:"client_ip" json:"client_ip"`
+	UserAgent     string `bun:"user_agent" json:"user_agent"`
+	Success       bool   `bun:"success,type:bool" json:"success"`
+	Reason        string `bun:"reason" json:"reason"`
+}
+
+type AuthLogFilter struct {
+	Username string
+	ClientIP string
+	Success  *bool
+	// From and To bound the attempt date, formatted as 2006-01-02 15:04:05 UTC
+	From   string
+	To     string
+	Limit  int
+	Offset int
+}
+
+func CreateAuthLog(ctx context.Context, entry *AuthLog) error {
+	if entry.AttemptDate == "" {
+		entry.AttemptDate = functions.GetFormatedLocalTime()
+	}
+
+	_, err := Db_GlobalVar.NewInsert().Model(entry).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error adding auth log: %w", err)
+	}
+	return nil
+}
+
+// GetAuthLogs returns the login attempts matching the filter, most recent
+// first, and the number of matching attempts ignoring the limit and offset.
+func GetAuthLogs(ctx context.Context, filter AuthLogFilter) ([]AuthLog, int, error) {
+	var entries []AuthLog
+	query := Db_GlobalVar.NewSelect().Model(&entries)
+
+	if filter.Username != "" {
+		query.Where("username = ?", filter.Username)
+	}
+	if filter.ClientIP != "" {
+		query.Where("client_ip = ?", filter.ClientIP)
+	}
+	if filter.Success != nil {
+		query.Where("success = ?", *filter.Success)
+	}
+	if filter.From != "" {
+		query.Where("attempt_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query.Where("attempt_date <= ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query.Offset(filter.Offset)
+	}
+
+	total, err := query.Order("id DESC").ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting auth logs: %w", err)
+	}
+
+	for i := range entries {
+		entries[i].AttemptDate, _ = functions.ParseTimeData(entries[i].AttemptDate)
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"fyc/functions"
+)
+
+// BackofficeSession is a login of a backoffice user. Access tokens carry the
+// session ID and are accepted while the session is active. Only the SHA-256
+// hashes of the refresh tokens are stored, the previous one being kept to
+// detect the reuse of a rotated token.
+type BackofficeSession struct {
+	bun.BaseModel       `json:"-" bun:"table:backoffice_session"`
+	ID                  int    `bun:"id,pk,autoincrement" json:"-"`
+	SessionID           string `bun:"session_id,unique,notnull" json:"session_id"`
+	Username            string `bun:"username,notnull" json:"username"`
+	RefreshHash         string `bun:"refresh_hash,unique,notnull" json:"-"`
+	PreviousRefreshHash string `bun:"previous_refresh_hash,nullzero" json:"-"`
+	ClientIP            string `bun:"client_ip" json:"client_ip"`
+	UserAgent           string `bun:"user_agent" json:"user_agent"`
+	CreatedAt           string `bun:"created_at,type:timestamp" json:"created_at"`
+	LastUsedAt          string `bun:"last_used_at,type:timestamp" json:"last_used_at"`
+	ExpiresAt           string `bun:"expires_at,type:timestamp" json:"expires_at"`
+	Revoked             bool   `bun:"revoked,type:bool" json:"revoked"`
+	RevokedAt           string `bun:"revoked_at,type:timestamp,nullzero" json:"revoked_at,omitempty"`
+	RevokedBy           string `bun:"revoked_by,nullzero" json:"revoked_by,omitempty"`
+	// Absolute end of the session, set at login, past which refreshing the
+	// tokens does not extend it
+	SessionExpiresAt string `bun:"session_expires_at,type:timestamp,nullzero" json:"session_expires_at"`
+	// The user proved a second factor during the session
+	TwoFactor bool `bun:"two_factor,type:bool" json:"two_factor"`
+	// The session was opened with a password to be changed, only allowing to
+	// change it until then
+	MustChangePassword bool `bun:"must_change_password,type:bool" json:"must_change_password"`
+}
+
+func CreateBackofficeSession(ctx context.Context, session *BackofficeSession) error {
+	session.Revoked = false
+
+	_, err := Db_GlobalVar.NewInsert().Model(session).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error storing session of user %s: %w", session.Username, err)
+	}
+	return nil
+}
+
+// GetBackofficeSession returns the session whatever its state, or nil when it
+// does not exist.
+func GetBackofficeSession(ctx context.Context, sessionID string) (*BackofficeSession, error) {
+	return getBackofficeSession(ctx, "session_id = ?", sessionID)
+}
+
+// GetBackofficeSessionByRefresh returns the session of the current or previous
+// refresh token, or nil when the hash belongs to no session.
+func GetBackofficeSessionByRefresh(ctx context.Context, refreshHash string) (*BackofficeSession, error) {
+	return getBackofficeSession(ctx, "refresh_hash = ? OR previous_refresh_hash = ?", refreshHash, refreshHash)
+}
+
+func getBackofficeSession(ctx context.Context, where string, args ...interface{}) (*BackofficeSession, error) {
+	var session BackofficeSession
+
+	err := Db_GlobalVar.NewSelect().Model(&session).
+		Where(where, args...).
+		Scan(ctx)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error retrieving session: %w", err)
+	}
+
+	parseSessionDates(&session)
+	return &session, nil
+}
+
+// GetUserSessions returns the active sessions of the user, most recent first.
+func GetUserSessions(ctx context.Context, username string) ([]BackofficeSession, error) {
+	var sessions []BackofficeSession
+
+	err := Db_GlobalVar.NewSelect().Model(&sessions).
+		Where("username = ?", username).
+		Where("revoked = ?", false).
+		Where("expires_at > ?", functions.GetFormatedLocalTime()).
+		Order("last_used_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving sessions of user %s: %w", username, err)
+	}
+
+	for i := range sessions {
+		parseSessionDates(&sessions[i])
+	}
+	return sessions, nil
+}
+
+// IsActive reports whether the session is neither revoked nor expired.
+func (s *BackofficeSession) IsActive() bool {
+	return !s.Revoked && s.ExpiresAt > functions.GetFormatedLocalTime()
+}
+
+// SetSessionTwoFactor records that the user of the session proved a second
+// factor, once enrolled in two-factor authentication.
+func SetSessionTwoFactor(ctx context.Context, sessionID string) error {
+	_, err := Db_GlobalVar.NewUpdate().
+		Model((*BackofficeSession)(nil)).
+		Set("two_factor = ?", true).
+		Where("session_id = ?", sessionID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error updating session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// ClearSessionMustChangePassword lifts the restriction of the session once the
+// user changed its password.
+func ClearSessionMustChangePassword(ctx context.Context, sessionID string) error {
+	_, err := Db_GlobalVar.NewUpdate().
+		Model((*BackofficeSession)(nil)).
+		Set("must_change_password = ?", false).
+		Where("session_id = ?", sessionID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error updating session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// RotateSessionRefresh replaces the refresh token of the session, provided it
+// is still currentHash, so that a token is only rotated once. The session is
+// extended to expiresAt, at most to its absolute end.
+func RotateSessionRefresh(ctx context.Context, sessionID string, currentHash string, newHash string, expiresAt string) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*BackofficeSession)(nil)).
+		Set("previous_refresh_hash = refresh_hash").
+		Set("refresh_hash = ?", newHash).
+		Set("last_used_at = ?", functions.GetFormatedLocalTime()).
+		Set("expires_at = LEAST(?::timestamp, session_expires_at)", expiresAt).
+		Where("session_id = ?", sessionID).
+		Where("refresh_hash = ?", currentHash).
+		Where("revoked = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error rotating refresh token of session %s: %w", sessionID, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+// RevokeBackofficeSession revokes the session of the user. revokedBy is the
+// user at the origin of the revocation.
+func RevokeBackofficeSession(ctx context.Context, username string, sessionID string, revokedBy string) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*BackofficeSession)(nil)).
+		Set("revoked = ?", true).
+		Set("revoked_at = ?", functions.GetFormatedLocalTime()).
+		Set("revoked_by = ?", revokedBy).
+		Where("session_id = ?", sessionID).
+		Where("username = ?", username).
+		Where("revoked = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error revoking session %s: %w", sessionID, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+// RevokeUserSessions revokes every session of the user but exceptSessionID,
+// which may be empty.
+func RevokeUserSessions(ctx context.Context, username string, exceptSessionID string, revokedBy string) (int64, error) {
+	return revokeUserSessions(ctx, Db_GlobalVar, username, exceptSessionID, revokedBy)
+}
+
+func revokeUserSessions(ctx context.Context, idb bun.IDB, username string, exceptSessionID string, revokedBy string) (int64, error) {
+	query := idb.NewUpdate().
+		Model((*BackofficeSession)(nil)).
+		Set("revoked = ?", true).
+		Set("revoked_at = ?", functions.GetFormatedLocalTime()).
+		Set("revoked_by = ?", revokedBy).
+		Where("username = ?", username).
+		Where("revoked = ?", false)
+	if exceptSessionID != "" {
+		query.Where("session_id <> ?", exceptSessionID)
+	}
+
+	res, err := query.Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error revoking sessions of user %s: %w", username, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	log.Info().Str("Username", username).Int64("Sessions", rowsAffected).Str("By", revokedBy).Msg("User sessions revoked")
+	return rowsAffected, nil
+}
+
+// MigrateSessionExpiry sets the absolute end of the sessions opened before it
+// was stored, maxLifetime after their login, and caps their expiry to it.
+func MigrateSessionExpiry(ctx context.Context, maxLifetime time.Duration) error {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*BackofficeSession)(nil)).
+		Set("session_expires_at = created_at + make_interval(secs => ?)", int64(maxLifetime/time.Second)).
+		Set("expires_at = LEAST(expires_at, created_at + make_interval(secs => ?))", int64(maxLifetime/time.Second)).
+		Where("session_expires_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error setting the end of the sessions: %w", err)
+	}
+
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected > 0 {
+		log.Info().Int64("Sessions", rowsAffected).Msg("Session ends set")
+	}
+	return nil
+}
+
+// DeleteExpiredBackofficeSessions removes sessions that expired before the
+// given time.
+func DeleteExpiredBackofficeSessions(ctx context.Context, before string) (int64, error) {
+	res, err := Db_GlobalVar.NewDelete().
+		Model((*BackofficeSession)(nil)).
+		Where("expires_at < ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired sessions: %w", err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+func parseSessionDates(session *BackofficeSession) {
+	session.CreatedAt, _ = functions.ParseTimeData(session.CreatedAt)
+	session.LastUsedAt, _ = functions.ParseTimeData(session.LastUsedAt)
+	session.ExpiresAt, _ = functions.ParseTimeData(session.ExpiresAt)
+	if session.SessionExpiresAt != "" {
+		session.SessionExpiresAt, _ = functions.ParseTimeData(session.SessionExpiresAt)
+	}
+	if session.RevokedAt != "" {
+		session.RevokedAt, _ = functions.ParseTimeData(session.RevokedAt)
+	}
+}
@@ -0,0 +1,57 @@
+package db
+
+import "sync"
+
+// Clients and cameras loaded at startup, replaced as a whole on every reload
+// and only read through the accessors below.
+var (
+	cacheMu        sync.RWMutex
+	clientList     = make(map[string]string)
+	clientDataList = make(map[string]ClientDetails)
+	camList        = make(map[string]CameraStarter)
+)
+
+// GetClientDetails returns the cached details of the client.
+func GetClientDetails(clientID string) (ClientDetails, bool) {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	client, exists := clientDataList[clientID]
+	return client, exists
+}
+
+// ClientDataList returns a copy of the cached client details by client ID.
+func ClientDataList() map[string]ClientDetails {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	clients := make(map[string]ClientDetails, len(clientDataList))
+	for id, client := range clientDataList {
+		clients[id] = client
+	}
+	return clients
+}
+
+// ClientCount returns the number of cached client credentials.
+func ClientCount() int {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	return len(clientList)
+}
+
+// GetCamStarter returns the cached camera with the IP.
+func GetCamStarter(camIP string) (CameraStarter, bool) {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	camera, exists := camList[camIP]
+	return camera, exists
+}
+
+// CamList returns a copy of the cached cameras by IP.
+func CamList() map[string]CameraStarter {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	cameras := make(map[string]CameraStarter, len(camList))
+	for ip, camera := range camList {
+		cameras[ip] = camera
+	}
+	return cameras
+}
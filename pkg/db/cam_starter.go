@@ -15,9 +15,19 @@ type CameraStarter struct {
 	ZoneIdIn  int    `json:"zone_in_id"`
 	ZoneIdOut int    `json:"zone_out_id"`
 	Direction string `json:"direction"`
+	// Authentication of the events pushed by the camera, see IngestAuth*
+	IngestAuth   string `json:"ingest_auth"`
+	IngestUser   string `json:"ingest_user"`
+	IngestSecret string `json:"-"`
 }
 
-var CamList = make(map[string]CameraStarter)
+// Authentication of the events pushed by a camera on /cam
+const (
+	IngestAuthNone   = "none"
+	IngestAuthBasic  = "basic"
+	IngestAuthDigest = "digest"
+	IngestAuthHMAC   = "hmac"
+)
 
 func CamStartup() {
 	ctx := context.Background()
@@ -37,8 +47,9 @@ func CamStartup() {
 		return
 	}
 
+	cameras := make(map[string]CameraStarter, len(Cam))
 	for _, camera := range Cam {
-		CamList[camera.CamIP] = CameraStarter{
+		cameras[camera.CamIP] = CameraStarter{
 			CamID:     camera.CamID,
 			CamIP:     camera.CamIP,
 			CamPORT:   camera.CamPORT,
@@ -47,6 +58,14 @@ func CamStartup() {
 			ZoneIdIn:  *camera.ZoneIdIn,
 			ZoneIdOut: *camera.ZoneIdOut,
 			Direction: camera.Direction,
+
+			IngestAuth:   camera.IngestAuth,
+			IngestUser:   camera.IngestUser,
+			IngestSecret: camera.IngestSecret,
 		}
 	}
+
+	cacheMu.Lock()
+	camList = cameras
+	cacheMu.Unlock()
 }
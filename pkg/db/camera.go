@@ -24,6 +24,9 @@ type Camera struct {
 	ZoneIdIn      *int                   `bun:"zone_in_id" json:"zone_in_id" binding:"required"`
 	ZoneIdOut     *int                   `bun:"zone_out_id" json:"zone_out_id" binding:"required"`
 	Direction     string                 `bun:"direction" json:"direction" binding:"required"`
+	IngestAuth    string                 `bun:"ingest_auth" json:"ingest_auth" binding:"omitempty,oneof=none basic digest hmac"`
+	IngestUser    string                 `bun:"ingest_user" json:"ingest_user"`
+	IngestSecret  string                 `bun:"ingest_secret" json:"-"`
 	IsEnabled     bool                   `bun:"is_enabled,type:bool" json:"is_enabled"`
 	IsDeleted     bool                   `bun:"is_deleted,type:bool" json:"-"`
 	LastUpdated   string                 `bun:"last_update,type:timestamp" json:"-"`
@@ -43,6 +46,9 @@ type ResponseCamera struct {
 	ZoneIdIn      *int   `bun:"zone_in_id"  json:"zone_in_id"`
 	ZoneIdOut     *int   `bun:"zone_out_id" json:"zone_out_id"`
 	Direction     string `bun:"direction" json:"direction"`
+	IngestAuth    string `bun:"ingest_auth" json:"ingest_auth"`
+	IngestUser    string `bun:"ingest_user" json:"ingest_user"`
+	IngestSecret  string `bun:"ingest_secret" json:"-"`
 	IsEnabled     bool   `bun:"is_enabled" json:"-"`
 	LastUpdated   string `bun:"last_update" json:"last_update"`
 	IsDeleted     bool   `bun:"is_deleted" json:"-"`
@@ -61,6 +67,9 @@ type CameraNoBind struct {
 	ZoneIdIn      *int                   `bun:"zone_in_id" json:"zone_in_id"`
 	ZoneIdOut     *int                   `bun:"zone_out_id" json:"zone_out_id"`
 	Direction     string                 `bun:"direction" json:"direction" `
+	IngestAuth    string                 `bun:"ingest_auth" json:"ingest_auth" binding:"omitempty,oneof=none basic digest hmac"`
+	IngestUser    string                 `bun:"ingest_user" json:"ingest_user"`
+	IngestSecret  string                 `bun:"ingest_secret" json:"-"`
 	IsEnabled     *bool                  `bun:"is_enabled,type:bool" json:"is_enabled"`
 	IsDeleted     *bool                  `bun:"is_deleted,type:bool" json:"-"`
 	LastUpdated   string                 `bun:"last_update,type:timestamp" json:"-"`
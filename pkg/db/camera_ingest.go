@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/functions"
+)
+
+// CameraRequest is the body of a camera creation. The ingest secret is
+// write-only: it is never read back, only returned once when issued.
+type CameraRequest struct {
+	Camera
+	IngestSecret string `json:"ingest_secret,omitempty"`
+}
+
+// CameraUpdateRequest is the body of a camera update, the ingest secret being
+// kept when not given.
+type CameraUpdateRequest struct {
+	CameraNoBind
+	IngestSecret string `json:"ingest_secret,omitempty"`
+}
+
+// IngestAuthSecret reports whether the ingest authentication needs a secret.
+func IngestAuthSecret(auth string) bool {
+	return auth == IngestAuthBasic || auth == IngestAuthDigest || auth == IngestAuthHMAC
+}
+
+// IssueIngestSecret returns the given secret, or a generated one when the
+// ingest authentication needs a secret and none is given. generated reports
+// whether the secret must be returned to the caller.
+func IssueIngestSecret(auth string, given string) (secret string, generated bool, err error) {
+	if given != "" || !IngestAuthSecret(auth) {
+		return given, false, nil
+	}
+	secret, err = GenerateClientSecret()
+	if err != nil {
+		return "", false, err
+	}
+	return secret, true, nil
+}
+
+// EnsureCameraIngestSecret generates the ingest secret of the camera when its
+// ingest authentication needs one and none is stored, returning it, or an
+// empty string when nothing was generated.
+func EnsureCameraIngestSecret(ctx context.Context, camID int) (string, error) {
+	camera, err := GetCameraByIDExtra(ctx, camID)
+	if err != nil {
+		return "", err
+	}
+	if camera.IngestSecret != "" || !IngestAuthSecret(camera.IngestAuth) {
+		return "", nil
+	}
+	return RotateCameraIngestSecret(ctx, camID)
+}
+
+// RotateCameraIngestSecret replaces the ingest secret of the camera with a
+// generated one, returned once.
+func RotateCameraIngestSecret(ctx context.Context, camID int) (string, error) {
+	secret, err := GenerateClientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*Camera)(nil)).
+		Set("ingest_secret = ?", secret).
+		Set("last_update = ?", functions.GetFormatedLocalTime()).
+		Where("cam_id = ?", camID).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error rotating ingest secret of camera %d: %w", camID, err)
+	}
+
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return "", fmt.Errorf("camera %d not found", camID)
+	}
+
+	log.Info().Int("Camera ID", camID).Msg("Camera ingest secret rotated")
+	CamStartup()
+	return secret, nil
+}
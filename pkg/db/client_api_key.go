@@ -2,53 +2,136 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"fyc/functions"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
 )
 
+// Third-party operations a client can be scoped to. A client without scopes
+// can use all of them, and a client without zones can see every zone.
+const (
+	ScopeFindMyCar   = "findmycar"
+	ScopeGetPicture  = "getpicture"
+	ScopeGetSettings = "getsettings"
+	ScopeWebhooks    = "webhooks"
+	ScopeKiosk       = "kiosk"
+)
+
+var AllScopes = []string{ScopeFindMyCar, ScopeGetPicture, ScopeGetSettings, ScopeWebhooks, ScopeKiosk}
+
 type ApiKey struct {
 	bun.BaseModel `json:"-" bun:"table:api_key"`
-	ID            int    `bun:"id,autoincrement,pk" json:"-"`
-	ClientName    string `bun:"client_name" json:"client_name"`
-	ClientID      string `bun:"client_id,unique" binding:"required" json:"client_id"`
-	ClientSecret  string `bun:"client_secret,unique" binding:"required" json:"client_secret"`
-	ApiKey        string `bun:"api_key" json:"-"`
-	GrantType     string `bun:"grant_type" binding:"required" json:"grant_type"`
-	FuzzyLogic    *bool  `bun:"fuzzy_logic,type:bool" json:"fuzzy_logic"`
-	IsEnabled     bool   `bun:"is_enabled,type:bool" json:"-"`
-	IsDeleted     bool   `bun:"is_deleted,type:bool" json:"-"`
-	LastUpdated   string `bun:"last_update,type:timestamp" json:"-"`
+	ID            int      `bun:"id,autoincrement,pk" json:"-"`
+	ClientName    string   `bun:"client_name" json:"client_name"`
+	ClientID      string   `bun:"client_id,unique" binding:"required" json:"client_id"`
+	ClientSecret  string   `bun:"client_secret" json:"client_secret,omitempty"`
+	ApiKey        string   `bun:"api_key" json:"-"`
+	GrantType     string   `bun:"grant_type" binding:"required" json:"grant_type"`
+	FuzzyLogic    *bool    `bun:"fuzzy_logic,type:bool" json:"fuzzy_logic"`
+	IsEnabled     bool     `bun:"is_enabled,type:bool" json:"-"`
+	IsDeleted     bool     `bun:"is_deleted,type:bool" json:"-"`
+	LastUpdated   string   `bun:"last_update,type:timestamp" json:"-"`
+	RateLimit     int      `bun:"rate_limit,default:0" json:"rate_limit"`
+	DailyQuota    int      `bun:"daily_quota,default:0" json:"daily_quota"`
+	ShowEntry     *bool    `bun:"show_entry_time,type:bool,default:true" json:"show_entry_time"`
+	ShowDwell     *bool    `bun:"show_dwell_time,type:bool,default:true" json:"show_dwell_time"`
+	ShowCamera    *bool    `bun:"show_last_camera,type:bool,default:true" json:"show_last_camera"`
+	ShowJourney   *bool    `bun:"show_journey,type:bool,default:false" json:"show_journey"`
+	Scopes        []string `bun:"scopes,type:jsonb" json:"scopes"`
+	AllowedZones  []int    `bun:"allowed_zones,type:jsonb" json:"allowed_zones"`
+	PrevSecret    string   `bun:"previous_secret,nullzero" json:"-"`
+	PrevExpires   string   `bun:"previous_secret_expires,type:timestamp,nullzero" json:"-"`
+	RotatedAt     string   `bun:"secret_rotated_at,type:timestamp,nullzero" json:"-"`
+	// SHA-256 fingerprint of the certificate the client must present, when
+	// client certificates are required
+	CertFingerprint string `bun:"cert_fingerprint,nullzero" json:"cert_fingerprint"`
 }
 
 type ApiKeyNoBind struct {
-	bun.BaseModel `json:"-" bun:"table:api_key"`
-	ID            int    `bun:"id,autoincrement" json:"_"`
-	ClientName    string `bun:"client_name" json:"client_name"`
-	ClientID      string `bun:"client_id,pk"  json:"client_id"`
-	ClientSecret  string `bun:"client_secret,pk" json:"client_secret"`
-	ApiKey        string `bun:"api_key" json:"-"`
-	GrantType     string `bun:"grant_type" json:"grant_type"`
-	FuzzyLogic    *bool  `bun:"fuzzy_logic,type:bool" json:"fuzzy_logic"`
-	IsEnabled     *bool  `bun:"is_enabled,type:bool" json:"is_enabled"`
-	IsDeleted     *bool  `bun:"is_deleted,type:bool" json:"-"`
-	LastUpdated   string `bun:"last_update,type:timestamp" json:"-"`
+	bun.BaseModel   `json:"-" bun:"table:api_key"`
+	ID              int      `bun:"id,autoincrement" json:"_"`
+	ClientName      string   `bun:"client_name" json:"client_name"`
+	ClientID        string   `bun:"client_id,pk"  json:"client_id"`
+	ClientSecret    string   `bun:"client_secret" json:"client_secret,omitempty"`
+	ApiKey          string   `bun:"api_key" json:"-"`
+	GrantType       string   `bun:"grant_type" json:"grant_type"`
+	FuzzyLogic      *bool    `bun:"fuzzy_logic,type:bool" json:"fuzzy_logic"`
+	IsEnabled       *bool    `bun:"is_enabled,type:bool" json:"is_enabled"`
+	IsDeleted       *bool    `bun:"is_deleted,type:bool" json:"-"`
+	LastUpdated     string   `bun:"last_update,type:timestamp" json:"-"`
+	RateLimit       *int     `bun:"rate_limit" json:"rate_limit"`
+	DailyQuota      *int     `bun:"daily_quota" json:"daily_quota"`
+	ShowEntry       *bool    `bun:"show_entry_time,type:bool" json:"show_entry_time"`
+	ShowDwell       *bool    `bun:"show_dwell_time,type:bool" json:"show_dwell_time"`
+	ShowCamera      *bool    `bun:"show_last_camera,type:bool" json:"show_last_camera"`
+	ShowJourney     *bool    `bun:"show_journey,type:bool" json:"show_journey"`
+	Scopes          []string `bun:"scopes,type:jsonb" json:"scopes"`
+	AllowedZones    []int    `bun:"allowed_zones,type:jsonb" json:"allowed_zones"`
+	CertFingerprint string   `bun:"cert_fingerprint" json:"cert_fingerprint"`
 }
 
 type ApiKeyResponse struct {
-	bun.BaseModel `json:"-" bun:"table:api_key"`
-	ID            int     `bun:"id,autoincrement,pk" json:"-"`
-	ClientName    string  `bun:"client_name" json:"client_name"`
-	ClientID      string  `bun:"client_id,unique" json:"client_id"`
-	ClientSecret  string  `bun:"client_secret,unique" json:"client_secret"`
-	ApiKey        *string `bun:"api_key" json:"-"`
-	GrantType     string  `bun:"grant_type" json:"grant_type"`
-	FuzzyLogic    *bool   `bun:"fuzzy_logic,type:bool" json:"fuzzy_logic"`
-	IsEnabled     bool    `bun:"is_enabled,type:bool" json:"is_enabled"`
-	IsDeleted     bool    `bun:"is_deleted,type:bool" json:"-"`
-	LastUpdated   string  `bun:"last_update,type:timestamp" json:"last_update"`
+	bun.BaseModel   `json:"-" bun:"table:api_key"`
+	ID              int      `bun:"id,autoincrement,pk" json:"-"`
+	ClientName      string   `bun:"client_name" json:"client_name"`
+	ClientID        string   `bun:"client_id,unique" json:"client_id"`
+	ClientSecret    string   `bun:"client_secret" json:"-"`
+	ApiKey          *string  `bun:"api_key" json:"-"`
+	GrantType       string   `bun:"grant_type" json:"grant_type"`
+	FuzzyLogic      *bool    `bun:"fuzzy_logic,type:bool" json:"fuzzy_logic"`
+	IsEnabled       bool     `bun:"is_enabled,type:bool" json:"is_enabled"`
+	IsDeleted       bool     `bun:"is_deleted,type:bool" json:"-"`
+	LastUpdated     string   `bun:"last_update,type:timestamp" json:"last_update"`
+	RateLimit       int      `bun:"rate_limit" json:"rate_limit"`
+	DailyQuota      int      `bun:"daily_quota" json:"daily_quota"`
+	ShowEntry       bool     `bun:"show_entry_time,type:bool" json:"show_entry_time"`
+	ShowDwell       bool     `bun:"show_dwell_time,type:bool" json:"show_dwell_time"`
+	ShowCamera      bool     `bun:"show_last_camera,type:bool" json:"show_last_camera"`
+	ShowJourney     bool     `bun:"show_journey,type:bool" json:"show_journey"`
+	Scopes          []string `bun:"scopes,type:jsonb" json:"scopes"`
+	AllowedZones    []int    `bun:"allowed_zones,type:jsonb" json:"allowed_zones"`
+	PrevSecret      string   `bun:"previous_secret,nullzero" json:"-"`
+	PrevExpires     string   `bun:"previous_secret_expires,type:timestamp,nullzero" json:"previous_secret_expires,omitempty"`
+	RotatedAt       string   `bun:"secret_rotated_at,type:timestamp,nullzero" json:"secret_rotated_at,omitempty"`
+	CertFingerprint string   `bun:"cert_fingerprint,nullzero" json:"cert_fingerprint,omitempty"`
+}
+
+// ValidateClientScopes checks that every scope is a known third-party operation.
+func ValidateClientScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !functions.ContainsStr(AllScopes, scope) {
+			return fmt.Errorf("invalid scope %s, expected one of %v", scope, AllScopes)
+		}
+	}
+	return nil
+}
+
+// NormalizeCertFingerprint checks the SHA-256 fingerprint of a client
+// certificate, accepting hexadecimal with or without colons, and stores it in
+// lowercase without separators.
+func NormalizeCertFingerprint(fingerprint *string) error {
+	if *fingerprint == "" {
+		return nil
+	}
+
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(*fingerprint), ":", ""))
+	if decoded, err := hex.DecodeString(normalized); err != nil || len(decoded) != sha256.Size {
+		return fmt.Errorf("invalid cert_fingerprint, expected the SHA-256 fingerprint of the client certificate")
+	}
+	*fingerprint = normalized
+	return nil
+}
+
+// CertFingerprint returns the SHA-256 fingerprint of a certificate in the
+// stored format.
+func CertFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
 }
 
 func GetAllDatas(ctx context.Context) (*ApiKeyResponse, error) {
@@ -149,26 +232,6 @@ func GetClientCredByIdFalse(ctx context.Context, clientID string) (*ApiKeyRespon
 	api.LastUpdated, _ = functions.ParseTimeData(api.LastUpdated)
 	return &api, nil
 }
-func GetClientCredBySecret(ctx context.Context, clientSecret string) (*ApiKeyResponse, error) {
-	var api ApiKeyResponse
-
-	err := Db_GlobalVar.NewSelect().
-		Model(&api).
-		Where("client_secret = ?", clientSecret).
-		Where("is_deleted = ?", false).
-		Where("is_enabled = ?", true).
-		Scan(ctx)
-
-	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			return nil, fmt.Errorf("client with Client Secret %s not found", clientSecret)
-		}
-		return nil, fmt.Errorf("error retrieving client cred with Client Secret %s: %w", clientSecret, err)
-	}
-	api.LastUpdated, _ = functions.ParseTimeData(api.LastUpdated)
-	return &api, nil
-}
-
 func GetAllClientCred(ctx context.Context) ([]ApiKeyResponse, error) {
 	var apm []ApiKeyResponse
 	err := Db_GlobalVar.NewSelect().
@@ -203,41 +266,83 @@ func GetAllClientDatas(ctx context.Context) ([]ApiKey, error) {
 	return api, nil
 }
 
-func AddClientCred(ctx context.Context, apimgnt *ApiKey) error {
+// AddClientCred stores the client with the hash of its secret, generating one
+// when none is given, and returns the plaintext secret to show once.
+func AddClientCred(ctx context.Context, apimgnt *ApiKey) (string, error) {
 	log.Debug().Str("Client API Added AT:", functions.GetFormatedLocalTime())
 	apimgnt.LastUpdated = functions.GetFormatedLocalTime()
 	apimgnt.IsDeleted = false
 	apimgnt.IsEnabled = true
 	//apimgnt.GrantType = "client_credentials"
 
-	_, err := Db_GlobalVar.NewInsert().Model(apimgnt).Exec(ctx)
+	secret := apimgnt.ClientSecret
+	if secret == "" {
+		var err error
+		if secret, err = GenerateClientSecret(); err != nil {
+			return "", err
+		}
+	}
+
+	hash, err := HashClientSecret(secret)
 	if err != nil {
-		return fmt.Errorf("error adding api_cred: %w", err)
+		return "", err
+	}
+	apimgnt.ClientSecret = hash
+
+	_, err = Db_GlobalVar.NewInsert().Model(apimgnt).Exec(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error adding api_cred: %w", err)
 	}
 
 	LoadClientDataList()
 	LoadClientsApi()
 	LoadClientlist()
-	return nil
+	return secret, nil
 }
 
 func UpdateClientCred(ctx context.Context, clientID string, updatedClientCred *ApiKeyNoBind) (int64, error) {
 	log.Debug().Str("Client Added AT:", functions.GetFormatedLocalTime()).Str("Client ID:", clientID)
 	updatedClientCred.LastUpdated = functions.GetFormatedLocalTime()
 
-	result, err := Db_GlobalVar.NewUpdate().
-		Model(updatedClientCred).
-		Where("client_id = ?", clientID).
-		Where("is_deleted = ?", false).
-		OmitZero().
-		Exec(ctx)
-	if err != nil {
-		return 0, fmt.Errorf("error updating client cred with ClientID %s: %w", clientID, err)
+	if updatedClientCred.ClientSecret != "" {
+		hash, err := HashClientSecret(updatedClientCred.ClientSecret)
+		if err != nil {
+			return 0, err
+		}
+		updatedClientCred.ClientSecret = hash
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	// A replaced secret ends the grace period of the previous one and the
+	// tokens issued with either
+	var rowsAffected int64
+	err := Db_GlobalVar.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model(updatedClientCred).
+			Where("client_id = ?", clientID).
+			Where("is_deleted = ?", false).
+			OmitZero().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected, err = result.RowsAffected(); err != nil || rowsAffected == 0 || updatedClientCred.ClientSecret == "" {
+			return err
+		}
+		_, err = tx.NewUpdate().
+			Model((*ApiKeyResponse)(nil)).
+			Set("previous_secret = NULL").
+			Set("previous_secret_expires = NULL").
+			Where("client_id = ?", clientID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = revokeClientTokens(ctx, tx, clientID)
+		return err
+	})
 	if err != nil {
-		return 0, fmt.Errorf("error fetching rows affected: %w", err)
+		return 0, fmt.Errorf("error updating client cred with ClientID %s: %w", clientID, err)
 	}
 
 	LoadClientDataList()
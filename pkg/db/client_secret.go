@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+
+	"fyc/functions"
+)
+
+// GenerateClientSecret returns a random URL safe client secret.
+func GenerateClientSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating client secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func HashClientSecret(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing client secret: %w", err)
+	}
+	return string(hash), nil
+}
+
+// IsHashedSecret reports whether the stored secret is already a bcrypt hash.
+func IsHashedSecret(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+func compareClientSecret(stored string, secret string) bool {
+	if stored == "" {
+		return false
+	}
+	if IsHashedSecret(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(secret)) == nil
+	}
+	// Rows not migrated yet still hold the plaintext secret
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(secret)) == 1
+}
+
+// VerifyClientSecret checks the secret against the current secret of the client,
+// and against the previous one while its rotation grace period is running.
+func VerifyClientSecret(ctx context.Context, client *ClientDetails, secret string) bool {
+	if compareClientSecret(client.ClientSecret, secret) {
+		if !IsHashedSecret(client.ClientSecret) {
+			migrateClientSecret(ctx, client.ClientID, secret)
+		}
+		return true
+	}
+
+	if client.PreviousSecret != "" && client.PreviousSecretExpires > functions.GetFormatedLocalTime() {
+		if compareClientSecret(client.PreviousSecret, secret) {
+			log.Info().Str("Client ID", client.ClientID).Str("Expires", client.PreviousSecretExpires).Msg("Client authenticated with previous secret")
+			return true
+		}
+	}
+
+	return false
+}
+
+// RotateClientSecret generates a new secret for the client and keeps the current
+// one valid for the grace period. The new plaintext secret is returned once.
+func RotateClientSecret(ctx context.Context, clientID string, grace time.Duration) (string, string, error) {
+	client, err := GetClientCredByIdFalse(ctx, clientID)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := GenerateClientSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	hash, err := HashClientSecret(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	previous := client.ClientSecret
+	if previous != "" && !IsHashedSecret(previous) {
+		if previous, err = HashClientSecret(previous); err != nil {
+			return "", "", err
+		}
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(grace).Format(time.DateTime)
+
+	query := Db_GlobalVar.NewUpdate().
+		Model((*ApiKeyResponse)(nil)).
+		Where("client_id = ?", clientID).
+		Where("is_deleted = ?", false).
+		Set("client_secret = ?", hash).
+		Set("secret_rotated_at = ?", now.Format(time.DateTime)).
+		Set("last_update = ?", functions.GetFormatedLocalTime())
+
+	if grace > 0 && previous != "" {
+		query.Set("previous_secret = ?", previous).Set("previous_secret_expires = ?", expires)
+	} else {
+		query.Set("previous_secret = NULL").Set("previous_secret_expires = NULL")
+		expires = ""
+	}
+
+	if _, err := query.Exec(ctx); err != nil {
+		return "", "", fmt.Errorf("error rotating secret of client %s: %w", clientID, err)
+	}
+
+	log.Info().Str("Client ID", clientID).Str("Previous secret valid until", expires).Msg("Client secret rotated")
+	LoadClientDataList()
+	LoadClientsApi()
+	return secret, expires, nil
+}
+
+// MigrateClientSecrets replaces the plaintext secrets still stored in the
+// api_key table by their hash, and drops the unique constraint the secrets
+// were created with, salted hashes being unique anyway.
+func MigrateClientSecrets(ctx context.Context) error {
+	_, err := Db_GlobalVar.ExecContext(ctx, `ALTER TABLE api_key DROP CONSTRAINT IF EXISTS api_key_client_secret_key`)
+	if err != nil {
+		return fmt.Errorf("error dropping unique constraint of client secrets: %w", err)
+	}
+
+	var clients []ApiKeyResponse
+	err = Db_GlobalVar.NewSelect().
+		Model(&clients).
+		Column("client_id", "client_secret").
+		Where("client_secret IS NOT NULL").
+		Where("client_secret <> ''").
+		Where("client_secret NOT LIKE ?", "$2_$%").
+		Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching clients with plaintext secret: %w", err)
+	}
+
+	for _, client := range clients {
+		if err := storeHashedSecret(ctx, client.ClientID, client.ClientSecret); err != nil {
+			return err
+		}
+	}
+
+	if len(clients) > 0 {
+		log.Info().Int("Clients", len(clients)).Msg("Plaintext client secrets migrated to hashes")
+	}
+	return nil
+}
+
+func migrateClientSecret(ctx context.Context, clientID string, secret string) {
+	if err := storeHashedSecret(ctx, clientID, secret); err != nil {
+		log.Err(err).Str("Client ID", clientID).Msg("Error migrating client secret")
+		return
+	}
+	LoadClientDataList()
+}
+
+func storeHashedSecret(ctx context.Context, clientID string, secret string) error {
+	hash, err := HashClientSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	_, err = Db_GlobalVar.NewUpdate().
+		Model((*ApiKeyResponse)(nil)).
+		Where("client_id = ?", clientID).
+		Where("client_secret = ?", secret).
+		Set("client_secret = ?", hash).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error storing hashed secret of client %s: %w", clientID, err)
+	}
+	return nil
+}
@@ -12,6 +12,8 @@ import (
 type ErrorMessage struct {
 	bun.BaseModel `json:"-" bun:"table:errors"`
 	Code          int               `bun:"code" json:"code"`
+	Key           string            `bun:"key" json:"key"`
+	Status        int               `bun:"http_status" json:"http_status"`
 	Messages      map[string]string `bun:"messages,type:jsonb" json:"messages" swaggertype:"object"`
 }
 
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"fyc/functions"
+)
+
+const (
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+)
+
+// OAuthToken is an issued third-party token. Only the SHA-256 hash of the token is stored.
+type OAuthToken struct {
+	bun.BaseModel `json:"-" bun:"table:oauth_token"`
+	ID            int    `bun:"id,pk,autoincrement" json:"id"`
+	TokenHash     string `bun:"token_hash,unique,notnull" json:"-"`
+	ClientID      string `bun:"client_id,notnull" json:"client_id"`
+	TokenType     string `bun:"token_type" json:"token_type"`
+	Format        string `bun:"format" json:"format"`
+	Scope         string `bun:"scope" json:"scope"`
+	IssuedAt      string `bun:"issued_at,type:timestamp" json:"issued_at"`
+	ExpiresAt     string `bun:"expires_at,type:timestamp" json:"expires_at"`
+	Revoked       bool   `bun:"revoked,type:bool" json:"revoked"`
+	RevokedAt     string `bun:"revoked_at,type:timestamp,nullzero" json:"revoked_at"`
+}
+
+func CreateOAuthToken(ctx context.Context, token *OAuthToken) error {
+	token.Revoked = false
+
+	_, err := Db_GlobalVar.NewInsert().Model(token).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error storing %s for client %s: %w", token.TokenType, token.ClientID, err)
+	}
+
+	log.Debug().Str("Client ID", token.ClientID).Str("Token Type", token.TokenType).Msg("Token stored")
+	return nil
+}
+
+// GetOAuthTokenByHash returns the stored token whatever its state, or nil when
+// the hash belongs to no issued token.
+func GetOAuthTokenByHash(ctx context.Context, tokenHash string) (*OAuthToken, error) {
+	var token OAuthToken
+
+	err := Db_GlobalVar.NewSelect().Model(&token).
+		Where("token_hash = ?", tokenHash).
+		Scan(ctx)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error retrieving token: %w", err)
+	}
+
+	token.IssuedAt, _ = functions.ParseTimeData(token.IssuedAt)
+	token.ExpiresAt, _ = functions.ParseTimeData(token.ExpiresAt)
+	if token.RevokedAt != "" {
+		token.RevokedAt, _ = functions.ParseTimeData(token.RevokedAt)
+	}
+	return &token, nil
+}
+
+// IsActive reports whether the token is neither revoked nor expired.
+func (t *OAuthToken) IsActive() bool {
+	return !t.Revoked && t.ExpiresAt > functions.GetFormatedLocalTime()
+}
+
+// RevokeOAuthToken revokes the token with the given hash if it belongs to the client.
+func RevokeOAuthToken(ctx context.Context, clientID string, tokenHash string) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*OAuthToken)(nil)).
+		Where("token_hash = ?", tokenHash).
+		Where("client_id = ?", clientID).
+		Where("revoked = ?", false).
+		Set("revoked = ?", true).
+		Set("revoked_at = ?", functions.GetFormatedLocalTime()).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error revoking token for client %s: %w", clientID, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+// RevokeClientTokens revokes every token issued to the client.
+func RevokeClientTokens(ctx context.Context, clientID string) (int64, error) {
+	return revokeClientTokens(ctx, Db_GlobalVar, clientID)
+}
+
+func revokeClientTokens(ctx context.Context, idb bun.IDB, clientID string) (int64, error) {
+	res, err := idb.NewUpdate().
+		Model((*OAuthToken)(nil)).
+		Where("client_id = ?", clientID).
+		Where("revoked = ?", false).
+		Set("revoked = ?", true).
+		Set("revoked_at = ?", functions.GetFormatedLocalTime()).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error revoking tokens for client %s: %w", clientID, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	log.Info().Str("Client ID", clientID).Int64("Tokens", rowsAffected).Msg("Client tokens revoked")
+	return rowsAffected, nil
+}
+
+// DeleteExpiredOAuthTokens removes tokens that expired before the given time.
+func DeleteExpiredOAuthTokens(ctx context.Context, before string) (int64, error) {
+	res, err := Db_GlobalVar.NewDelete().
+		Model((*OAuthToken)(nil)).
+		Where("expires_at < ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired tokens: %w", err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
@@ -2,12 +2,17 @@ package db
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
+	"strconv"
+	"unicode/utf8"
 
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
 
 	"fyc/functions"
+	"fyc/pkg/platematch"
 )
 
 type PresentCar struct {
@@ -22,6 +27,18 @@ type PresentCar struct {
 	Confidence      *int                   `bun:"confidence" json:"confidence" binding:"required"`
 	CarDetailsID    *int                   `bun:"car_details_id" json:"car_details_id" binding:"required"`
 	Extra           map[string]interface{} `bun:"extra,type:jsonb" json:"extra" swaggertype:"object"`
+	EntryDate       string                 `bun:"entry_date,type:timestamp,nullzero" json:"entry_date"`
+	// Random ID of the visit, given out instead of the sequential row ID
+	VisitID int64 `bun:"visit_id,nullzero" json:"-"`
+	// Normalized plate and its length, searched by the plate matching
+	LPNNorm   string `bun:"lpn_norm,nullzero" json:"-"`
+	LPNLength int    `bun:"lpn_len" json:"-"`
+}
+
+// setNormalizedPlate derives the searched plate columns from the plate.
+func (car *PresentCar) setNormalizedPlate() {
+	car.LPNNorm = platematch.Normalize(car.LPN)
+	car.LPNLength = utf8.RuneCountInString(car.LPNNorm)
 }
 
 type ResponsePC struct {
@@ -290,6 +307,7 @@ func GetPresentCarByLPN(ctx context.Context, lpn string) (*PresentCar, error) {
 		return nil, fmt.Errorf("error getting present car by LPN: %w", err)
 	}
 	car.TransactionDate, _ = functions.ParseTimeData(car.TransactionDate)
+	car.EntryDate, _ = functions.ParseTimeData(car.EntryDate)
 	return &car, nil
 }
 
@@ -325,6 +343,131 @@ func GetPresentCarByLPNFuzzy(ctx context.Context, lpn string) ([]PresentCar, err
 	return cars, nil
 }
 
+// PresentCarFilter selects present cars, unset fields are not filtered.
+type PresentCarFilter struct {
+	VisitID *int64
+	LPN     string
+	ZoneIDs []int
+	Limit   int
+	Offset  int
+}
+
+// GetPresentCarsByFilter returns the present cars matching the filter, the
+// most recent first.
+func GetPresentCarsByFilter(ctx context.Context, filter PresentCarFilter) ([]PresentCar, error) {
+	var cars []PresentCar
+	if filter.ZoneIDs != nil && len(filter.ZoneIDs) == 0 {
+		return cars, nil
+	}
+
+	query := Db_GlobalVar.NewSelect().Model(&cars)
+	if filter.VisitID != nil {
+		query = query.Where("visit_id = ?", *filter.VisitID)
+	}
+	if filter.LPN != "" {
+		query = query.Where("lpn = ?", filter.LPN)
+	}
+	if filter.ZoneIDs != nil {
+		query = query.Where("current_zone_id IN (?)", bun.In(filter.ZoneIDs))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Order("transaction_date DESC").Scan(ctx); err != nil {
+		return nil, fmt.Errorf("error getting present cars by filter: %w", err)
+	}
+
+	for i := range cars {
+		cars[i].TransactionDate, _ = functions.ParseTimeData(cars[i].TransactionDate)
+		cars[i].EntryDate, _ = functions.ParseTimeData(cars[i].EntryDate)
+	}
+	return cars, nil
+}
+
+// GetPresentCarCandidates returns at most limit present cars parked in one of
+// the zones, any zone when nil, whose normalized plate has between minLen and
+// maxLen characters and a trigram similarity of at least minSimilarity with
+// the normalized query, most similar first, to be scored by the fuzzy plate
+// matching. Without the trigram index the most recent cars of a close length
+// are returned.
+func GetPresentCarCandidates(ctx context.Context, query string, minLen int, maxLen int, minSimilarity float64, zoneIDs []int, limit int) ([]PresentCar, error) {
+	var cars []PresentCar
+	if zoneIDs != nil && len(zoneIDs) == 0 {
+		return cars, nil
+	}
+
+	err := Db_GlobalVar.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		selectQuery := tx.NewSelect().
+			Model(&cars).
+			Where("lpn_len BETWEEN ? AND ?", minLen, maxLen).
+			Limit(limit)
+		if zoneIDs != nil {
+			selectQuery = selectQuery.Where("current_zone_id IN (?)", bun.In(zoneIDs))
+		} else {
+			selectQuery = selectQuery.Where("current_zone_id IS NOT NULL")
+		}
+
+		if !trigramIndexed {
+			return selectQuery.Order("transaction_date DESC").Scan(ctx)
+		}
+
+		// The threshold of the % operator, which the trigram index serves, only
+		// applies to this transaction
+		if _, err := tx.ExecContext(ctx, "SELECT set_config('pg_trgm.similarity_threshold', ?, true)", strconv.FormatFloat(minSimilarity, 'f', -1, 64)); err != nil {
+			return err
+		}
+		return selectQuery.
+			Where("lpn_norm % ?", query).
+			OrderExpr("similarity(lpn_norm, ?) DESC", query).
+			Scan(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting present car candidates: %w", err)
+	}
+
+	formatPresentCarDates(cars)
+	return cars, nil
+}
+
+// GetPresentCarsByPartialPlate returns up to limit present cars parked in one
+// of the zones, any zone when nil, whose normalized plate contains the
+// normalized partial plate.
+func GetPresentCarsByPartialPlate(ctx context.Context, partial string, zoneIDs []int, limit int) ([]PresentCar, error) {
+	var cars []PresentCar
+	if zoneIDs != nil && len(zoneIDs) == 0 {
+		return cars, nil
+	}
+
+	// Normalized plates are made of letters and digits only, no LIKE wildcard
+	query := Db_GlobalVar.NewSelect().
+		Model(&cars).
+		Where("lpn_norm LIKE ?", "%"+platematch.Normalize(partial)+"%").
+		Limit(limit)
+	if zoneIDs != nil {
+		query = query.Where("current_zone_id IN (?)", bun.In(zoneIDs))
+	} else {
+		query = query.Where("current_zone_id IS NOT NULL")
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("error getting present cars by partial plate: %w", err)
+	}
+
+	formatPresentCarDates(cars)
+	return cars, nil
+}
+
+func formatPresentCarDates(cars []PresentCar) {
+	for i := range cars {
+		cars[i].TransactionDate, _ = functions.ParseTimeData(cars[i].TransactionDate)
+		cars[i].EntryDate, _ = functions.ParseTimeData(cars[i].EntryDate)
+	}
+}
+
 func GetPresentFound(ctx context.Context, lpn string) (bool, error) {
 	var car PresentCar
 	err := Db_GlobalVar.NewSelect().Model(&car).Where("lpn = ?", lpn).Scan(ctx)
@@ -336,6 +479,18 @@ func GetPresentFound(ctx context.Context, lpn string) (bool, error) {
 
 // Create a new present car
 func CreatePresentCar(ctx context.Context, car *PresentCar) error {
+	if car.EntryDate == "" {
+		car.EntryDate = car.TransactionDate
+	}
+	if car.VisitID == 0 {
+		visitID, err := newVisitID()
+		if err != nil {
+			return err
+		}
+		car.VisitID = visitID
+	}
+	car.setNormalizedPlate()
+
 	_, err := Db_GlobalVar.NewInsert().Model(car).Returning("id").Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("error creating present car: %w", err)
@@ -345,9 +500,20 @@ func CreatePresentCar(ctx context.Context, car *PresentCar) error {
 	return nil
 }
 
+// newVisitID returns a random positive visit ID of 52 bits, exact as a JSON
+// number.
+func newVisitID() (int64, error) {
+	var raw [8]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return 0, fmt.Errorf("error generating visit id: %w", err)
+	}
+	return int64(binary.BigEndian.Uint64(raw[:])>>12) | 1, nil
+}
+
 // Update a present car by ID and return rows affected
 func UpdatePresentCar(ctx context.Context, id int, updates *PresentCar) (int64, error) {
-	res, err := Db_GlobalVar.NewUpdate().Model(updates).Where("id = ?", id).Exec(ctx)
+	updates.setNormalizedPlate()
+	res, err := Db_GlobalVar.NewUpdate().Model(updates).ExcludeColumn("entry_date", "visit_id").Where("id = ?", id).Exec(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("error updating present car: %w", err)
 	}
@@ -363,7 +529,9 @@ func UpdatePresentCarByLpn(ctx context.Context, lpn string, updates *PresentCar)
 	log.Debug().Str("lpn", lpn).Msgf("Update Present Car by LPN")
 	//log.Debug().Interface("DATA", updates).Send()
 
-	res, err := Db_GlobalVar.NewUpdate().Model(updates).Where("lpn = ?", lpn).Exec(ctx)
+	// The entry date and visit ID are kept from the first read of the visit
+	updates.setNormalizedPlate()
+	res, err := Db_GlobalVar.NewUpdate().Model(updates).ExcludeColumn("entry_date", "visit_id").Where("lpn = ?", lpn).Exec(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("error updating present car: %w", err)
 	}
@@ -73,6 +73,31 @@ func GetPresentCarByLPNHistory(ctx context.Context, lpn string) (*PresentCarHist
 	return &car, nil
 }
 
+// GetPresentCarJourney returns the reads of the plate since the given date, oldest
+// first, limited to the last limit reads.
+func GetPresentCarJourney(ctx context.Context, lpn string, since string, limit int) ([]PresentCarHistory, error) {
+	var cars []PresentCarHistory
+
+	err := Db_GlobalVar.NewSelect().
+		Model(&cars).
+		Where("lpn = ?", lpn).
+		Where("transaction_date >= ?", since).
+		Order("transaction_date DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting journey of present car %s: %w", lpn, err)
+	}
+
+	for i, j := 0, len(cars)-1; i < j; i, j = i+1, j-1 {
+		cars[i], cars[j] = cars[j], cars[i]
+	}
+	for i := range cars {
+		cars[i].TransactionDate, _ = functions.ParseTimeData(cars[i].TransactionDate)
+	}
+	return cars, nil
+}
+
 // Create a new present car
 func CreatePresentCarHistory(ctx context.Context, car *PresentCarHistory) error {
 	// Insert and get the auto-generated ID from the database
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Whether the pg_trgm extension and the trigram index of the normalized plates
+// are available
+var trigramIndexed bool
+
+// IndexPresentCars creates the indexes of the present cars, again after every
+// reset of the table, and fills the normalized plates, visit IDs and entry
+// dates of the rows written before they were stored.
+func IndexPresentCars(ctx context.Context) error {
+	statements := []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS presentcar_visit_id_idx ON presentcar (visit_id)`,
+		`CREATE INDEX IF NOT EXISTS presentcar_lpn_len_idx ON presentcar (lpn_len)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := Db_GlobalVar.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("error indexing present cars: %w", err)
+		}
+	}
+
+	// The partial plate search works without the trigram index, scanning the
+	// table, and the fuzzy search on the plate length only, when the extension
+	// cannot be installed
+	trigram := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS presentcar_lpn_norm_trgm_idx ON presentcar USING gin (lpn_norm gin_trgm_ops)`,
+	}
+	trigramIndexed = true
+	for _, statement := range trigram {
+		if _, err := Db_GlobalVar.ExecContext(ctx, statement); err != nil {
+			log.Warn().Err(err).Msg("Trigram index of the present car plates not created")
+			trigramIndexed = false
+			break
+		}
+	}
+
+	if err := normalizePresentCarPlates(ctx); err != nil {
+		return err
+	}
+	if err := assignPresentCarVisitIDs(ctx); err != nil {
+		return err
+	}
+	return backfillPresentCarEntryDates(ctx)
+}
+
+// normalizePresentCarPlates fills the normalized plates missing from the
+// present cars.
+func normalizePresentCarPlates(ctx context.Context) error {
+	var cars []PresentCar
+	err := Db_GlobalVar.NewSelect().
+		Model(&cars).
+		Column("id", "lpn").
+		Where("lpn_norm IS NULL").
+		Where("lpn <> ''").
+		Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching present cars without normalized plate: %w", err)
+	}
+
+	for i := range cars {
+		cars[i].setNormalizedPlate()
+		_, err := Db_GlobalVar.NewUpdate().
+			Model(&cars[i]).
+			Column("lpn_norm", "lpn_len").
+			Where("id = ?", cars[i].ID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error normalizing plate of present car %d: %w", *cars[i].ID, err)
+		}
+	}
+
+	if len(cars) > 0 {
+		log.Info().Int("Cars", len(cars)).Msg("Present car plates normalized")
+	}
+	return nil
+}
+
+// assignPresentCarVisitIDs gives a visit ID to the present cars without one.
+func assignPresentCarVisitIDs(ctx context.Context) error {
+	var ids []int
+	err := Db_GlobalVar.NewSelect().
+		Model((*PresentCar)(nil)).
+		Column("id").
+		Where("visit_id IS NULL").
+		Scan(ctx, &ids)
+	if err != nil {
+		return fmt.Errorf("error fetching present cars without visit ID: %w", err)
+	}
+
+	for _, id := range ids {
+		visitID, err := newVisitID()
+		if err != nil {
+			return err
+		}
+		_, err = Db_GlobalVar.NewUpdate().
+			Model((*PresentCar)(nil)).
+			Set("visit_id = ?", visitID).
+			Where("id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error assigning visit ID of present car %d: %w", id, err)
+		}
+	}
+
+	if len(ids) > 0 {
+		log.Info().Int("Cars", len(ids)).Msg("Present car visit IDs assigned")
+	}
+	return nil
+}
+
+// backfillPresentCarEntryDates sets the entry date of the present cars without
+// one to their last read, the earliest time known of their visit, so that
+// their dwell no longer restarts with every read.
+func backfillPresentCarEntryDates(ctx context.Context) error {
+	result, err := Db_GlobalVar.NewUpdate().
+		Model((*PresentCar)(nil)).
+		Set("entry_date = transaction_date").
+		Where("entry_date IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error backfilling entry dates of present cars: %w", err)
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows > 0 {
+		log.Info().Int64("Cars", rows).Msg("Present car entry dates backfilled")
+	}
+	return nil
+}
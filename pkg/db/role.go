@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun"
+
+	"fyc/functions"
+)
+
+// Role grants its users a set of backoffice permissions, each formatted as
+// module:action. System roles are seeded at startup and cannot be modified.
+type Role struct {
+	bun.BaseModel `json:"-" bun:"table:role"`
+	ID            int      `bun:"id,autoincrement" json:"id"`
+	Name          string   `bun:"name,pk" binding:"required" json:"name"`
+	Description   string   `bun:"description" json:"description"`
+	Permissions   []string `bun:"permissions,type:jsonb" json:"permissions"`
+	IsSystem      bool     `bun:"is_system,type:bool" json:"is_system"`
+	// Users of the role must log in with two-factor authentication
+	RequireTwoFactor bool   `bun:"require_two_factor,type:bool" json:"require_two_factor"`
+	IsDeleted        bool   `bun:"is_deleted,type:bool" json:"-"`
+	LastUpdated      string `bun:"last_update,type:timestamp,nullzero" json:"-"`
+}
+
+func GetRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+	err := Db_GlobalVar.NewSelect().
+		Model(&roles).
+		Where("is_deleted = ?", false).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting roles: %w", err)
+	}
+	return roles, nil
+}
+
+func GetRoleByName(ctx context.Context, name string) (*Role, error) {
+	var role Role
+	err := Db_GlobalVar.NewSelect().
+		Model(&role).
+		Where("name = ?", name).
+		Where("is_deleted = ?", false).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("role %s not found", name)
+		}
+		return nil, fmt.Errorf("error retrieving role %s: %w", name, err)
+	}
+	return &role, nil
+}
+
+// AddRole creates the role, replacing a deleted role of the same name.
+func AddRole(ctx context.Context, role *Role) error {
+	role.IsDeleted = false
+	role.LastUpdated = functions.GetFormatedLocalTime()
+	res, err := Db_GlobalVar.NewInsert().
+		Model(role).
+		On("CONFLICT (name) DO UPDATE").
+		Set("description = EXCLUDED.description").
+		Set("permissions = EXCLUDED.permissions").
+		Set("is_system = EXCLUDED.is_system").
+		Set("is_deleted = EXCLUDED.is_deleted").
+		Set("last_update = EXCLUDED.last_update").
+		Set("require_two_factor = EXCLUDED.require_two_factor").
+		Where("role.is_deleted = ?", true).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error adding role %s: %w", role.Name, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error fetching rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("role %s already exists", role.Name)
+	}
+	return nil
+}
+
+// UpdateRole changes the description and permissions of a role that is not a
+// system role.
+func UpdateRole(ctx context.Context, role *Role) (int64, error) {
+	role.LastUpdated = functions.GetFormatedLocalTime()
+	res, err := Db_GlobalVar.NewUpdate().
+		Model(role).
+		Column("description", "permissions", "last_update").
+		Where("name = ?", role.Name).
+		Where("is_system = ?", false).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error updating role %s: %w", role.Name, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error fetching rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// SetRoleTwoFactor sets whether the users of the role, system roles included,
+// must use two-factor authentication.
+func SetRoleTwoFactor(ctx context.Context, name string, required bool) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*Role)(nil)).
+		Set("require_two_factor = ?", required).
+		Set("last_update = ?", functions.GetFormatedLocalTime()).
+		Where("name = ?", name).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error updating two-factor policy of role %s: %w", name, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+// DeleteRole deletes a role that is not a system role.
+func DeleteRole(ctx context.Context, name string) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*Role)(nil)).
+		Set("is_deleted = ?", true).
+		Where("name = ?", name).
+		Where("is_system = ?", false).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting role %s: %w", name, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error fetching rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// SaveSystemRole creates or overwrites a system role.
+func SaveSystemRole(ctx context.Context, role *Role) error {
+	role.IsSystem = true
+	role.IsDeleted = false
+	role.LastUpdated = functions.GetFormatedLocalTime()
+	_, err := Db_GlobalVar.NewInsert().
+		Model(role).
+		On("CONFLICT (name) DO UPDATE").
+		Set("description = EXCLUDED.description").
+		Set("permissions = EXCLUDED.permissions").
+		Set("is_system = EXCLUDED.is_system").
+		Set("is_deleted = EXCLUDED.is_deleted").
+		Set("last_update = EXCLUDED.last_update").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error saving system role %s: %w", role.Name, err)
+	}
+	return nil
+}
+
+// CountUsersWithRole returns the number of users holding the role.
+func CountUsersWithRole(ctx context.Context, name string) (int, error) {
+	count, err := Db_GlobalVar.NewSelect().
+		Model((*User)(nil)).
+		Where("role = ?", name).
+		Where("is_deleted = ?", false).
+		Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error counting users with role %s: %w", name, err)
+	}
+	return count, nil
+}
+
+// SetUserRole assigns the role to the user.
+func SetUserRole(ctx context.Context, username string, role string) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*User)(nil)).
+		Set("role = ?", role).
+		Where("username = ?", username).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error setting role of user %s: %w", username, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error fetching rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
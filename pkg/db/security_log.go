@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+
+	"fyc/functions"
+)
+
+// Reasons of a rejected request
+const (
+	SecurityUnknownIP        = "unknown_ip"
+	SecurityIPMismatch       = "ip_mismatch"
+	SecurityUnknownCamera    = "unknown_camera"
+	SecurityAuthMissing      = "auth_missing"
+	SecurityAuthFailed       = "auth_failed"
+	SecuritySignatureInvalid = "signature_invalid"
+	SecurityBodyTooLarge     = "body_too_large"
+	SecurityReplay           = "replay"
+)
+
+// SecurityLog records a request rejected by a security check, such as an
+// event pushed on /cam by an unregistered or unauthenticated camera.
+type SecurityLog struct {
+	bun.BaseModel `json:"-" bun:"table:security_log"`
+	ID            int64  `bun:"id,pk,autoincrement" json:"id"`
+	EventDate     string `bun:"event_date,type:timestamp" json:"event_date"`
+	Source        string `bun:"source" json:"source"`
+	ClientIP      string `bun:"client_ip" json:"client_ip"`
+	CameraIP      string `bun:"camera_ip" json:"camera_ip"`
+	CamID         int    `bun:"cam_id" json:"cam_id"`
+	Reason        string `bun:"reason" json:"reason"`
+	Detail        string `bun:"detail" json:"detail"`
+	UserAgent     string `bun:"user_agent" json:"user_agent"`
+}
+
+type SecurityLogFilter struct {
+	Source   string
+	ClientIP string
+	Reason   string
+	// From and To bound the event date, formatted as 2006-01-02 15:04:05 UTC
+	From   string
+	To     string
+	Limit  int
+	Offset int
+}
+
+func CreateSecurityLog(ctx context.Context, entry *SecurityLog) error {
+	if entry.EventDate == "" {
+		entry.EventDate = functions.GetFormatedLocalTime()
+	}
+
+	_, err := Db_GlobalVar.NewInsert().Model(entry).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error adding security log: %w", err)
+	}
+	return nil
+}
+
+// GetSecurityLogs returns the rejected requests matching the filter, most
+// recent first, and the number of matching requests ignoring the limit and
+// offset.
+func GetSecurityLogs(ctx context.Context, filter SecurityLogFilter) ([]SecurityLog, int, error) {
+	var entries []SecurityLog
+	query := Db_GlobalVar.NewSelect().Model(&entries)
+
+	if filter.Source != "" {
+		query.Where("source = ?", filter.Source)
+	}
+	if filter.ClientIP != "" {
+		query.Where("client_ip = ?", filter.ClientIP)
+	}
+	if filter.Reason != "" {
+		query.Where("reason = ?", filter.Reason)
+	}
+	if filter.From != "" {
+		query.Where("event_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query.Where("event_date <= ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query.Offset(filter.Offset)
+	}
+
+	total, err := query.Order("id DESC").ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting security logs: %w", err)
+	}
+
+	for i := range entries {
+		entries[i].EventDate, _ = functions.ParseTimeData(entries[i].EventDate)
+	}
+	return entries, total, nil
+}
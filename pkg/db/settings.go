@@ -9,6 +9,15 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// Default login brute-force protection, durations in seconds
+const (
+	DefaultLoginMaxAttempts   = 5
+	DefaultLoginIPMaxAttempts = 20
+	DefaultLoginWindow        = 900
+	DefaultLoginLockout       = 900
+	DefaultLoginDelay         = 1
+)
+
 type Settings struct {
 	bun.BaseModel      `json:"-" bun:"table:settings"`
 	CarParkID          int                    `bun:"carpark_id,pk" json:"carpark_id" binding:"required"`
@@ -21,7 +30,15 @@ type Settings struct {
 	IsFycEnabled       bool                   `bun:"is_fyc_enabled,type:bool" json:"is_fyc_enabled"`
 	CountingCleanCron  int                    `bun:"counting_clean_cron" binding:"required" json:"counting_clean_cron"`
 	IsCountingEnabled  bool                   `bun:"is_counting_enabled,type:bool" json:"is_counting_enabled"`
+	SignRefreshCron    int                    `bun:"sign_refresh_cron,default:5" json:"sign_refresh_cron"`
+	IsSignRefresh      bool                   `bun:"is_sign_refresh_enabled,type:bool,default:true" json:"is_sign_refresh_enabled"`
 	TC                 string                 `bun:"tc" json:"tc"`
+	// Login brute-force protection, durations in seconds
+	LoginMaxAttempts   int `bun:"login_max_attempts,default:5" json:"login_max_attempts"`
+	LoginIPMaxAttempts int `bun:"login_ip_max_attempts,default:20" json:"login_ip_max_attempts"`
+	LoginWindow        int `bun:"login_window,default:900" json:"login_window"`
+	LoginLockout       int `bun:"login_lockout,default:900" json:"login_lockout"`
+	LoginDelay         int `bun:"login_delay,default:1" json:"login_delay"`
 }
 
 type SettingsNoBind struct {
@@ -36,7 +53,14 @@ type SettingsNoBind struct {
 	IsFycEnabled       *bool                  `bun:"is_fyc_enabled,type:bool" json:"is_fyc_enabled"`
 	CountingCleanCron  int                    `bun:"counting_clean_cron" json:"counting_clean_cron"`
 	IsCountingEnabled  *bool                  `bun:"is_counting_enabled,type:bool" json:"is_counting_enabled"`
+	SignRefreshCron    int                    `bun:"sign_refresh_cron" json:"sign_refresh_cron"`
+	IsSignRefresh      *bool                  `bun:"is_sign_refresh_enabled,type:bool" json:"is_sign_refresh_enabled"`
 	TC                 string                 `bun:"tc" json:"tc"`
+	LoginMaxAttempts   int                    `bun:"login_max_attempts" json:"login_max_attempts"`
+	LoginIPMaxAttempts int                    `bun:"login_ip_max_attempts" json:"login_ip_max_attempts"`
+	LoginWindow        int                    `bun:"login_window" json:"login_window"`
+	LoginLockout       int                    `bun:"login_lockout" json:"login_lockout"`
+	LoginDelay         *int                   `bun:"login_delay" json:"login_delay"`
 }
 
 type SettingsResponse struct {
@@ -66,6 +90,8 @@ type CronInfo struct {
 	CountingCleanCron int  `json:"counting_clean_cron"`
 	IsFycEnabled      bool `json:"is_fyc_enabled"`
 	IsCountingEnabled bool `json:"is_counting_enabled"`
+	SignRefreshCron   int  `json:"sign_refresh_cron"`
+	IsSignRefresh     bool `json:"is_sign_refresh_enabled"`
 }
 
 type KioskInfo struct {
@@ -90,6 +116,22 @@ func CreateSettings(ctx context.Context, settings *Settings) error {
 	settings.DefaultLang = strings.ToLower(settings.DefaultLang)
 	settings.IsCountingEnabled = true
 	settings.IsFycEnabled = true
+	settings.IsSignRefresh = true
+	if settings.LoginMaxAttempts == 0 {
+		settings.LoginMaxAttempts = DefaultLoginMaxAttempts
+	}
+	if settings.LoginIPMaxAttempts == 0 {
+		settings.LoginIPMaxAttempts = DefaultLoginIPMaxAttempts
+	}
+	if settings.LoginWindow == 0 {
+		settings.LoginWindow = DefaultLoginWindow
+	}
+	if settings.LoginLockout == 0 {
+		settings.LoginLockout = DefaultLoginLockout
+	}
+	if settings.LoginDelay == 0 {
+		settings.LoginDelay = DefaultLoginDelay
+	}
 
 	_, err := Db_GlobalVar.NewInsert().Model(settings).Exec(ctx)
 	return err
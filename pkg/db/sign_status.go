@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"fyc/functions"
+)
+
+type SignStatus struct {
+	bun.BaseModel       `json:"-" bun:"table:sign_status"`
+	SignID              int    `bun:"sign_id,pk" json:"sign_id"`
+	ZoneID              int    `bun:"zone_id" json:"zone_id"`
+	LastValue           string `bun:"last_value" json:"last_value"`
+	LastSent            string `bun:"last_sent,type:timestamp,nullzero" json:"last_sent"`
+	LastAck             string `bun:"last_ack,type:timestamp,nullzero" json:"last_ack"`
+	LastError           string `bun:"last_error" json:"last_error"`
+	TotalFailures       int    `bun:"total_failures" json:"total_failures"`
+	ConsecutiveFailures int    `bun:"consecutive_failures" json:"consecutive_failures"`
+	LastUpdated         string `bun:"last_update,type:timestamp" json:"last_update"`
+}
+
+// SaveSignDelivery records the outcome of a delivery attempt for a sign.
+// A nil deliveryErr marks the value as acknowledged, meaning it reached at
+// least one subscribed sign driver.
+func SaveSignDelivery(ctx context.Context, signID int, zoneID int, value string, deliveryErr error) error {
+	now := functions.GetFormatedLocalTime()
+
+	status := SignStatus{
+		SignID:      signID,
+		ZoneID:      zoneID,
+		LastValue:   value,
+		LastSent:    now,
+		LastUpdated: now,
+	}
+
+	query := Db_GlobalVar.NewInsert().
+		Model(&status).
+		On("CONFLICT (sign_id) DO UPDATE").
+		Set("zone_id = EXCLUDED.zone_id").
+		Set("last_value = EXCLUDED.last_value").
+		Set("last_sent = EXCLUDED.last_sent").
+		Set("last_update = EXCLUDED.last_update")
+
+	if deliveryErr != nil {
+		status.LastError = deliveryErr.Error()
+		status.TotalFailures = 1
+		status.ConsecutiveFailures = 1
+		query = query.
+			Set("last_error = EXCLUDED.last_error").
+			Set("total_failures = sign_status.total_failures + 1").
+			Set("consecutive_failures = sign_status.consecutive_failures + 1")
+	} else {
+		status.LastAck = now
+		query = query.
+			Set("last_ack = EXCLUDED.last_ack").
+			Set("last_error = ''").
+			Set("consecutive_failures = 0")
+	}
+
+	if _, err := query.Exec(ctx); err != nil {
+		log.Err(err).Int("sign_id", signID).Msg("Error saving sign delivery status")
+		return fmt.Errorf("error saving delivery status for sign %d: %w", signID, err)
+	}
+
+	return nil
+}
+
+func GetSignStatusByID(ctx context.Context, signID int) (*SignStatus, error) {
+	var status SignStatus
+
+	err := Db_GlobalVar.NewSelect().Model(&status).
+		Where("sign_id = ?", signID).
+		Scan(ctx)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, fmt.Errorf("no delivery status for sign %d", signID)
+		}
+		return nil, fmt.Errorf("error retrieving delivery status for sign %d: %w", signID, err)
+	}
+
+	formatSignStatusTimes(&status)
+	return &status, nil
+}
+
+func GetAllSignStatus(ctx context.Context) ([]SignStatus, error) {
+	var statuses []SignStatus
+
+	err := Db_GlobalVar.NewSelect().Model(&statuses).
+		Order("sign_id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting signs delivery status: %w", err)
+	}
+
+	for i := range statuses {
+		formatSignStatusTimes(&statuses[i])
+	}
+	return statuses, nil
+}
+
+func formatSignStatusTimes(status *SignStatus) {
+	if status.LastSent != "" {
+		status.LastSent, _ = functions.ParseTimeData(status.LastSent)
+	}
+	if status.LastAck != "" {
+		status.LastAck, _ = functions.ParseTimeData(status.LastAck)
+	}
+	status.LastUpdated, _ = functions.ParseTimeData(status.LastUpdated)
+}
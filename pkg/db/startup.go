@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"fyc/functions"
 
 	_ "github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
@@ -17,14 +18,24 @@ var ClientListAPI []string
 var SettingsList = make(map[int]string)
 var Token string = ""
 var Db_GlobalVar *bun.DB
-var ClientList = make(map[string]string)
 
 type ClientDetails struct {
-	ClientID        string
-	ClientSecret    string
-	ClientGrantType string
-	ClientActive    bool
-	FuzzyLogic      bool
+	ClientID              string
+	ClientSecret          string `json:"-"`
+	PreviousSecret        string `json:"-"`
+	PreviousSecretExpires string
+	ClientGrantType       string
+	ClientActive          bool
+	FuzzyLogic            bool
+	RateLimit             int
+	DailyQuota            int
+	ShowEntry             bool
+	ShowDwell             bool
+	ShowCamera            bool
+	ShowJourney           bool
+	Scopes                []string
+	AllowedZones          []int
+	CertFingerprint       string
 }
 
 func LoadSignlist() {
@@ -32,9 +43,11 @@ func LoadSignlist() {
 	//log.Debug().Msgf("Prepare Sign List ...")
 	ctx := context.Background()
 	SignService, _ := GetSigns(ctx)
+	var signs []int
 	for _, v := range SignService {
-		SignList = append(SignList, v.SignID)
+		signs = append(signs, v.SignID)
 	}
+	SignList = signs
 }
 
 /*
@@ -55,9 +68,11 @@ func LoadCameralist() {
 	ctx := context.Background()
 
 	CameraService, _ := GetCameras(ctx)
+	var cameras []int
 	for _, v := range CameraService {
-		CameraList = append(CameraList, v.CamID)
+		cameras = append(cameras, v.CamID)
 	}
+	CameraList = cameras
 }
 
 func LoadClientsApi() {
@@ -68,9 +83,14 @@ func LoadClientsApi() {
 		fmt.Println("Error fetching client from DB:", err)
 		return
 	}
+	secrets := make(map[string]string, len(apikey))
 	for _, row := range apikey {
-		ClientList[row.ClientID] = row.ClientSecret
+		secrets[row.ClientID] = row.ClientSecret
 	}
+
+	cacheMu.Lock()
+	clientList = secrets
+	cacheMu.Unlock()
 }
 
 func LoadClientlist() {
@@ -79,13 +99,13 @@ func LoadClientlist() {
 	ctx := context.Background()
 
 	ClientService, _ := GetAllClientCred(ctx)
+	var clients []string
 	for _, v := range ClientService {
-		ClientListAPI = append(ClientListAPI, v.ClientID)
+		clients = append(clients, v.ClientID)
 	}
+	ClientListAPI = clients
 }
 
-var ClientDataList = make(map[string]ClientDetails)
-
 func LoadClientDataList() {
 	ctx := context.Background()
 
@@ -95,29 +115,66 @@ func LoadClientDataList() {
 		return
 	}
 
+	clients := make(map[string]ClientDetails, len(apikey))
 	for _, row := range apikey {
-		ClientDataList[row.ClientID] = ClientDetails{
+		details := ClientDetails{
 			ClientID:        row.ClientID,
 			ClientSecret:    row.ClientSecret,
 			ClientGrantType: row.GrantType,
 			ClientActive:    row.IsEnabled,
 			FuzzyLogic:      *row.FuzzyLogic,
+			RateLimit:       row.RateLimit,
+			DailyQuota:      row.DailyQuota,
+			ShowEntry:       row.ShowEntry == nil || *row.ShowEntry,
+			ShowDwell:       row.ShowDwell == nil || *row.ShowDwell,
+			ShowCamera:      row.ShowCamera == nil || *row.ShowCamera,
+			ShowJourney:     row.ShowJourney != nil && *row.ShowJourney,
+			Scopes:          row.Scopes,
+			AllowedZones:    row.AllowedZones,
+			CertFingerprint: row.CertFingerprint,
+		}
+		if row.PrevSecret != "" {
+			details.PreviousSecret = row.PrevSecret
+			details.PreviousSecretExpires, _ = functions.ParseTimeData(row.PrevExpires)
 		}
+		clients[row.ClientID] = details
 	}
+
+	cacheMu.Lock()
+	clientDataList = clients
+	cacheMu.Unlock()
 }
 
 func LoadzoneList() {
 	ctx := context.Background()
 	ZoneService, _ := GetZones(ctx)
+	var zones []int
 	for _, v := range ZoneService {
-		AllZonelist = append(AllZonelist, *v.ZoneID)
+		zones = append(zones, *v.ZoneID)
 	}
+	AllZonelist = zones
 }
 
 func LoadAllZonelist() {
 	ctx := context.Background()
 	ZoneSer, _ := GetAllZone(ctx)
+	var zones []int
 	for _, v := range ZoneSer {
-		Zonelist = append(Zonelist, *v.ZoneID)
+		zones = append(zones, *v.ZoneID)
 	}
+	Zonelist = zones
+}
+
+// ReloadCache reloads every in-memory list loaded at startup. Each list is
+// built apart and replaces the previous one once complete, so that readers
+// never see an empty or partial list.
+func ReloadCache() {
+	LoadzoneList()
+	LoadAllZonelist()
+	LoadCameralist()
+	LoadClientsApi()
+	LoadClientDataList()
+	LoadClientlist()
+	CamStartup()
+	LoadSignlist()
 }
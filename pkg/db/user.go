@@ -6,24 +6,46 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
+
+	"fyc/functions"
 )
 
+// User is a backoffice user. Password holds the bcrypt hash of the password and
+// is never serialized.
 type User struct {
-	bun.BaseModel `json:"-" bun:"table:user"`
-	ID            int    `bun:"id,autoincrement" json:"id"`
-	UserName      string `bun:"username,pk" binding:"required" json:"username"`
-	Password      string `bun:"password" binding:"required" json:"password"`
-	FirstName     string `bun:"first_name" binding:"required" json:"first_name"`
-	LastName      string `bun:"last_name" binding:"required" json:"last_name"`
-	Role          string `bun:"role" binding:"required" json:"role"`
-	IsEnabled     bool   `bun:"is_enabled,type:bool" json:"-" `
-	IsDeleted     bool   `bun:"is_deleted,type:bool" json:"-"`
+	bun.BaseModel      `json:"-" bun:"table:user"`
+	ID                 int    `bun:"id,autoincrement" json:"id"`
+	UserName           string `bun:"username,pk" binding:"required" json:"username"`
+	Password           string `bun:"password" json:"-"`
+	FirstName          string `bun:"first_name" binding:"required" json:"first_name"`
+	LastName           string `bun:"last_name" binding:"required" json:"last_name"`
+	Role               string `bun:"role" binding:"required" json:"role"`
+	IsEnabled          bool   `bun:"is_enabled,type:bool" json:"-" `
+	IsDeleted          bool   `bun:"is_deleted,type:bool" json:"-"`
+	MustChangePassword bool   `bun:"must_change_password,type:bool" json:"must_change_password"`
+	PasswordChangedAt  string `bun:"password_changed_at,type:timestamp,nullzero" json:"password_changed_at,omitempty"`
+	// Two-factor authentication, recovery codes being stored as SHA-256 hashes
+	TwoFactorEnabled  bool     `bun:"two_factor_enabled,type:bool" json:"two_factor_enabled"`
+	TOTPSecret        string   `bun:"totp_secret,nullzero" json:"-"`
+	TOTPPendingSecret string   `bun:"totp_pending_secret,nullzero" json:"-"`
+	TOTPLastStep      int64    `bun:"totp_last_step" json:"-"`
+	RecoveryCodes     []string `bun:"recovery_codes,type:jsonb" json:"-"`
 }
 
+// AddUser creates the user, storing the hash of its password.
 func AddUser(ctx context.Context, user *User) error {
 	user.IsDeleted = false
 	user.IsEnabled = true
 
+	if !IsHashedSecret(user.Password) {
+		hash, err := HashUserPassword(user.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = hash
+	}
+	user.PasswordChangedAt = functions.GetFormatedLocalTime()
+
 	_, err := Db_GlobalVar.NewInsert().Model(user).Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("error adding user: %w", err)
@@ -58,6 +80,9 @@ func GetUserByUsername(ctx context.Context, username string) (*User, error) {
 		}
 		return nil, fmt.Errorf("error retrieving User cred with username %s: %w", username, err)
 	}
+	if user.PasswordChangedAt != "" {
+		user.PasswordChangedAt, _ = functions.ParseTimeData(user.PasswordChangedAt)
+	}
 	return user, nil
 }
 
@@ -67,13 +92,23 @@ func GetAllUsers(ctx context.Context) ([]User, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error getting all Users: %w", err)
 	}
+	for i := range user {
+		if user[i].PasswordChangedAt != "" {
+			user[i].PasswordChangedAt, _ = functions.ParseTimeData(user[i].PasswordChangedAt)
+		}
+	}
 	return user, nil
 }
 
 func UpdateUser(ctx context.Context, username string, updatedUser *User) (int64, error) {
 	log.Debug().Msgf("Updating user with Username: %s\n", username)
+	// Passwords are only changed by SetUserPassword, roles by SetUserRole, the
+	// state by SetUserEnabled and two-factor settings by the functions of
+	// user_two_factor.go
 	result, err := Db_GlobalVar.NewUpdate().
 		Model(updatedUser).
+		ExcludeColumn("role", "is_enabled", "is_deleted", "password", "must_change_password", "password_changed_at",
+			"two_factor_enabled", "totp_secret", "totp_pending_secret", "totp_last_step", "recovery_codes").
 		Where("is_deleted = ?", false).
 		Where("username = ?", username).
 		Exec(ctx)
@@ -89,23 +124,57 @@ func UpdateUser(ctx context.Context, username string, updatedUser *User) (int64,
 	return rowsAffected, nil
 }
 
+// DeleteUser deletes the user and revokes its sessions.
 func DeleteUser(ctx context.Context, username string) (int64, error) {
 	log.Debug().Msgf("Deleting User with username: %s", username)
 
-	result, err := Db_GlobalVar.NewUpdate().
-		Model(&User{}).
-		Set("is_deleted = ?", true).
-		Where("username = ?", username).
-		Exec(ctx)
+	var rowsAffected int64
+	err := Db_GlobalVar.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model(&User{}).
+			Set("is_deleted = ?", true).
+			Where("username = ?", username).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
 
+		if rowsAffected, err = result.RowsAffected(); err != nil || rowsAffected == 0 {
+			return err
+		}
+		_, err = revokeUserSessions(ctx, tx, username, "", "user deleted")
+		return err
+	})
 	if err != nil {
-
-		return 0, fmt.Errorf("error deleting client cred with ClientID %s: %w", username, err)
+		return 0, fmt.Errorf("error deleting user %s: %w", username, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return rowsAffected, nil
+}
+
+// SetUserEnabled enables or disables the user, revoking its sessions when
+// disabled. revokedBy is the user at the origin of the change.
+func SetUserEnabled(ctx context.Context, username string, enabled bool, revokedBy string) (int64, error) {
+	var rowsAffected int64
+	err := Db_GlobalVar.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model((*User)(nil)).
+			Set("is_enabled = ?", enabled).
+			Where("is_deleted = ?", false).
+			Where("username = ?", username).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected, err = result.RowsAffected(); err != nil || rowsAffected == 0 || enabled {
+			return err
+		}
+		_, err = revokeUserSessions(ctx, tx, username, "", revokedBy)
+		return err
+	})
 	if err != nil {
-		return 0, fmt.Errorf("error fetching rows affected: %w", err)
+		return 0, fmt.Errorf("error setting state of user %s: %w", username, err)
 	}
 
 	return rowsAffected, nil
@@ -7,6 +7,8 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// UserAudit holds the legacy audit entries, kept readable. Changes are now
+// recorded in AuditLog.
 type UserAudit struct {
 	bun.BaseModel `json:"-" bun:"table:user_audit"`
 	ID            int                    `bun:"id,autoincrement" json:"id"`
@@ -17,14 +19,6 @@ type UserAudit struct {
 	Module        string                 `bun:"module" binding:"required" json:"module"`
 }
 
-func CreateUserAudit(ctx context.Context, userAudit *UserAudit) error {
-	_, err := Db_GlobalVar.NewInsert().Model(userAudit).Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("error adding userAudit: %w", err)
-	}
-	return nil
-}
-
 func GetUserAuditById(ctx context.Context, UserAuditID int) (*UserAudit, error) {
 	var UserAudit UserAudit
 
@@ -49,43 +43,3 @@ func GetAllUserAudits(ctx context.Context) ([]UserAudit, error) {
 	}
 	return UserAudits, nil
 }
-
-func UpdateUserAudit(ctx context.Context, UserAuditID int, updatedUserAudit *UserAudit) (int64, error) {
-	//log.Debug().Str("Time", global.FormattedTime).Msgf("Updating UserAudit with ID: %d\n", UserAuditID)
-	//updatedUserAudit.ActionDate = global.FormattedTime
-
-	result, err := Db_GlobalVar.NewUpdate().
-		Model(updatedUserAudit).
-		Where("user_id = ?", UserAuditID).
-		Exec(ctx)
-	if err != nil {
-		return 0, fmt.Errorf("error updating UserAudit with UserAuditID %d: %w", UserAuditID, err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("error fetching rows affected: %w", err)
-	}
-
-	return rowsAffected, nil
-}
-
-func DeleteUserAudit(ctx context.Context, UserAuditID int) (int64, error) {
-	//log.Debug().Str("Time ", global.FormattedTime).Msgf("Deleting UserAudit with UserAuditID: %d", UserAuditID)
-
-	result, err := Db_GlobalVar.NewDelete().
-		Model(&UserAudit{}).
-		Where("user_id = ?", UserAuditID).
-		Exec(ctx)
-
-	if err != nil {
-		return 0, fmt.Errorf("error deleting UserAudit with UserAuditID %d: %w", UserAuditID, err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("error fetching rows affected: %w", err)
-	}
-
-	return rowsAffected, nil
-}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+
+	"fyc/functions"
+)
+
+func HashUserPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing user password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// VerifyUserPassword checks the password of the user. Users still holding a
+// plaintext password get it replaced by its hash on success.
+func VerifyUserPassword(ctx context.Context, user *User, password string) bool {
+	if user.Password == "" {
+		return false
+	}
+
+	if IsHashedSecret(user.Password) {
+		return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		return false
+	}
+	migrateUserPassword(ctx, user.UserName, password)
+	return true
+}
+
+// SetUserPassword stores the hash of password for the user. mustChange forces
+// the user to change it on the next login.
+func SetUserPassword(ctx context.Context, username string, password string, mustChange bool) (int64, error) {
+	hash, err := HashUserPassword(password)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*User)(nil)).
+		Set("password = ?", hash).
+		Set("must_change_password = ?", mustChange).
+		Set("password_changed_at = ?", functions.GetFormatedLocalTime()).
+		Where("username = ?", username).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error setting password of user %s: %w", username, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error fetching rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// MigrateUserPasswords replaces the plaintext passwords still stored in the
+// user table by their hash.
+func MigrateUserPasswords(ctx context.Context) error {
+	var users []User
+	err := Db_GlobalVar.NewSelect().
+		Model(&users).
+		Column("username", "password").
+		Where("password IS NOT NULL").
+		Where("password <> ''").
+		Where("password NOT LIKE ?", "$2_$%").
+		Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching users with plaintext password: %w", err)
+	}
+
+	for _, user := range users {
+		if err := storeHashedPassword(ctx, user.UserName, user.Password); err != nil {
+			return err
+		}
+	}
+
+	if len(users) > 0 {
+		log.Info().Int("Users", len(users)).Msg("Plaintext user passwords migrated to hashes")
+	}
+	return nil
+}
+
+func migrateUserPassword(ctx context.Context, username string, password string) {
+	if err := storeHashedPassword(ctx, username, password); err != nil {
+		log.Err(err).Str("Username", username).Msg("Error migrating user password")
+	}
+}
+
+// storeHashedPassword hashes the password in place, keeping the other password
+// fields as they are.
+func storeHashedPassword(ctx context.Context, username string, password string) error {
+	hash, err := HashUserPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = Db_GlobalVar.NewUpdate().
+		Model((*User)(nil)).
+		Set("password = ?", hash).
+		Where("username = ?", username).
+		Where("password = ?", password).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error storing password hash of user %s: %w", username, err)
+	}
+	return nil
+}
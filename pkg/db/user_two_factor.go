@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SetUserPendingTOTP stores the secret being enrolled, two-factor
+// authentication being enabled only once a code of it is confirmed.
+func SetUserPendingTOTP(ctx context.Context, username string, secret string) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*User)(nil)).
+		Set("totp_pending_secret = ?", secret).
+		Where("username = ?", username).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error storing totp secret of user %s: %w", username, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+// EnableUserTwoFactor makes the pending secret the secret of the user, step
+// being the time step of the code that confirmed it.
+func EnableUserTwoFactor(ctx context.Context, username string, secret string, step int64, recoveryHashes []string) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*User)(nil)).
+		Set("two_factor_enabled = ?", true).
+		Set("totp_secret = ?", secret).
+		Set("totp_pending_secret = NULL").
+		Set("totp_last_step = ?", step).
+		Set("recovery_codes = ?::jsonb", jsonArray(recoveryHashes)).
+		Where("username = ?", username).
+		Where("totp_pending_secret = ?", secret).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error enabling two-factor authentication of user %s: %w", username, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected > 0 {
+		log.Info().Str("Username", username).Msg("Two-factor authentication enabled")
+	}
+	return rowsAffected, nil
+}
+
+// DisableUserTwoFactor removes the secret and recovery codes of the user.
+func DisableUserTwoFactor(ctx context.Context, username string) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*User)(nil)).
+		Set("two_factor_enabled = ?", false).
+		Set("totp_secret = NULL").
+		Set("totp_pending_secret = NULL").
+		Set("totp_last_step = 0").
+		Set("recovery_codes = NULL").
+		Where("username = ?", username).
+		Where("is_deleted = ?", false).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error disabling two-factor authentication of user %s: %w", username, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected > 0 {
+		log.Info().Str("Username", username).Msg("Two-factor authentication disabled")
+	}
+	return rowsAffected, nil
+}
+
+// UseTOTPStep records the time step of an accepted code. It affects no row
+// when a code of this step or a later one was already used, so that a code
+// cannot be replayed.
+func UseTOTPStep(ctx context.Context, username string, step int64) (int64, error) {
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*User)(nil)).
+		Set("totp_last_step = ?", step).
+		Where("username = ?", username).
+		Where("totp_last_step < ?", step).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error recording totp code of user %s: %w", username, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+// SetUserRecoveryCodes replaces the recovery codes of the user, expected to
+// be the hashes read along with the user so that a code is only used once.
+func SetUserRecoveryCodes(ctx context.Context, username string, expectedHashes []string, recoveryHashes []string) (int64, error) {
+	query := Db_GlobalVar.NewUpdate().
+		Model((*User)(nil)).
+		Set("recovery_codes = ?::jsonb", jsonArray(recoveryHashes)).
+		Where("username = ?", username).
+		Where("two_factor_enabled = ?", true)
+	if expectedHashes != nil {
+		query.Where("recovery_codes = ?::jsonb", jsonArray(expectedHashes))
+	}
+
+	res, err := query.Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error storing recovery codes of user %s: %w", username, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+// jsonArray encodes the strings as a JSON array, nil being an empty array.
+func jsonArray(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+	raw, _ := json.Marshal(values)
+	return string(raw)
+}
@@ -0,0 +1,289 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"fyc/functions"
+)
+
+// Event types a webhook subscription can be registered for
+const (
+	WebhookEntry      = "vehicle.entry"
+	WebhookZoneChange = "vehicle.zone_changed"
+	WebhookExit       = "vehicle.exit"
+	WebhookZoneFull   = "zone.full"
+)
+
+var WebhookEvents = []string{WebhookEntry, WebhookZoneChange, WebhookExit, WebhookZoneFull}
+
+// Delivery states. Dead deliveries exhausted their attempts and form the
+// dead-letter store, they are only sent again when replayed.
+// Sending deliveries are claimed by a worker until their lock expires, being
+// claimed again past it when the worker stopped before recording the attempt.
+const (
+	DeliveryPending   = "pending"
+	DeliverySending   = "sending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type WebhookSubscription struct {
+	bun.BaseModel `json:"-" bun:"table:webhook_subscription"`
+	ID            int      `bun:"id,pk,autoincrement" json:"id"`
+	ClientID      string   `bun:"client_id,notnull" json:"client_id"`
+	URL           string   `bun:"url,notnull" json:"url"`
+	Events        []string `bun:"events,type:jsonb" json:"events"`
+	Secret        string   `bun:"secret" json:"-"`
+	IsEnabled     bool     `bun:"is_enabled,type:bool" json:"is_enabled"`
+	CreatedAt     string   `bun:"created_at,type:timestamp" json:"created_at"`
+	LastUpdated   string   `bun:"last_update,type:timestamp" json:"-"`
+}
+
+// WebhookDelivery is one delivery of an event to a subscription, kept as the
+// delivery log once sent.
+type WebhookDelivery struct {
+	bun.BaseModel  `json:"-" bun:"table:webhook_delivery"`
+	ID             int             `bun:"id,pk,autoincrement" json:"id"`
+	SubscriptionID int             `bun:"subscription_id,notnull" json:"subscription_id"`
+	ClientID       string          `bun:"client_id,notnull" json:"client_id"`
+	EventID        string          `bun:"event_id" json:"event_id"`
+	EventType      string          `bun:"event_type" json:"event_type"`
+	Payload        json.RawMessage `bun:"payload,type:jsonb" json:"payload" swaggertype:"object"`
+	Status         string          `bun:"status" json:"status"`
+	Attempts       int             `bun:"attempts" json:"attempts"`
+	NextAttempt    string          `bun:"next_attempt,type:timestamp,nullzero" json:"next_attempt,omitempty"`
+	LastAttempt    string          `bun:"last_attempt,type:timestamp,nullzero" json:"last_attempt,omitempty"`
+	LockedUntil    string          `bun:"locked_until,type:timestamp,nullzero" json:"-"`
+	ResponseCode   int             `bun:"response_code" json:"response_code"`
+	LastError      string          `bun:"last_error" json:"last_error"`
+	ReplayOf       *int            `bun:"replay_of" json:"replay_of,omitempty"`
+	CreatedAt      string          `bun:"created_at,type:timestamp" json:"created_at"`
+	DeliveredAt    string          `bun:"delivered_at,type:timestamp,nullzero" json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryFilter struct {
+	ClientID       string
+	SubscriptionID int
+	Status         string
+	Limit          int
+}
+
+func ValidateWebhookEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("at least one event is required, expected some of %v", WebhookEvents)
+	}
+	for _, event := range events {
+		if !functions.ContainsStr(WebhookEvents, event) {
+			return fmt.Errorf("invalid event %s, expected one of %v", event, WebhookEvents)
+		}
+	}
+	return nil
+}
+
+func CreateWebhookSubscription(ctx context.Context, subscription *WebhookSubscription) error {
+	now := functions.GetFormatedLocalTime()
+	subscription.IsEnabled = true
+	subscription.CreatedAt = now
+	subscription.LastUpdated = now
+
+	_, err := Db_GlobalVar.NewInsert().Model(subscription).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating webhook subscription for client %s: %w", subscription.ClientID, err)
+	}
+
+	log.Info().Str("Client ID", subscription.ClientID).Int("Subscription ID", subscription.ID).Str("URL", subscription.URL).Msg("Webhook subscription created")
+	return nil
+}
+
+// GetWebhookSubscriptions returns the subscriptions of a client, or of every
+// client when clientID is empty.
+func GetWebhookSubscriptions(ctx context.Context, clientID string) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+
+	query := Db_GlobalVar.NewSelect().Model(&subscriptions).Order("id ASC")
+	if clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("error getting webhook subscriptions: %w", err)
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].CreatedAt, _ = functions.ParseTimeData(subscriptions[i].CreatedAt)
+	}
+	return subscriptions, nil
+}
+
+func GetEnabledWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+
+	err := Db_GlobalVar.NewSelect().Model(&subscriptions).
+		Where("is_enabled = ?", true).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting enabled webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// GetWebhookSubscriptionByID returns nil when the subscription does not exist.
+func GetWebhookSubscriptionByID(ctx context.Context, id int) (*WebhookSubscription, error) {
+	var subscription WebhookSubscription
+
+	err := Db_GlobalVar.NewSelect().Model(&subscription).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting webhook subscription %d: %w", id, err)
+	}
+
+	subscription.CreatedAt, _ = functions.ParseTimeData(subscription.CreatedAt)
+	return &subscription, nil
+}
+
+// DeleteWebhookSubscription deletes the subscription, restricted to the client
+// when clientID is not empty. Its delivery log is kept.
+func DeleteWebhookSubscription(ctx context.Context, clientID string, id int) (int64, error) {
+	query := Db_GlobalVar.NewDelete().
+		Model((*WebhookSubscription)(nil)).
+		Where("id = ?", id)
+	if clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+
+	res, err := query.Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting webhook subscription %d: %w", id, err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	return rowsAffected, nil
+}
+
+func CreateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	now := functions.GetFormatedLocalTime()
+	delivery.Status = DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttempt = now
+	delivery.CreatedAt = now
+
+	_, err := Db_GlobalVar.NewInsert().Model(delivery).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating webhook delivery for subscription %d: %w", delivery.SubscriptionID, err)
+	}
+	return nil
+}
+
+// ClaimDueWebhookDeliveries claims the pending deliveries whose next attempt
+// is due, and the sending ones whose lock expired, oldest first, marking them
+// as sending until lockedUntil. Rows claimed by a concurrent worker are
+// skipped, so that a delivery is claimed by a single worker.
+func ClaimDueWebhookDeliveries(ctx context.Context, now string, lockedUntil string, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+
+	due := Db_GlobalVar.NewSelect().
+		Model((*WebhookDelivery)(nil)).
+		Column("id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("status = ?", DeliveryPending).Where("next_attempt <= ?", now)
+				}).
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("status = ?", DeliverySending).Where("locked_until <= ?", now)
+				})
+		}).
+		Order("next_attempt ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	err := Db_GlobalVar.NewUpdate().
+		Model((*WebhookDelivery)(nil)).
+		Set("status = ?", DeliverySending).
+		Set("locked_until = ?", lockedUntil).
+		Where("id IN (?)", due).
+		Returning("*").
+		Scan(ctx, &deliveries)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming due webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// SaveWebhookAttempt records the outcome of a delivery attempt, releasing its
+// claim.
+func SaveWebhookAttempt(ctx context.Context, delivery *WebhookDelivery) error {
+	_, err := Db_GlobalVar.NewUpdate().
+		Model(delivery).
+		Column("status", "attempts", "next_attempt", "last_attempt", "locked_until", "response_code", "last_error", "delivered_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error saving attempt of webhook delivery %d: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+func GetWebhookDeliveryByID(ctx context.Context, id int) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := Db_GlobalVar.NewSelect().Model(&delivery).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting webhook delivery %d: %w", id, err)
+	}
+
+	formatDeliveryTimes(&delivery)
+	return &delivery, nil
+}
+
+// GetWebhookDeliveries returns the delivery log, newest first.
+func GetWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+
+	query := Db_GlobalVar.NewSelect().Model(&deliveries).Order("id DESC").Limit(filter.Limit)
+	if filter.ClientID != "" {
+		query = query.Where("client_id = ?", filter.ClientID)
+	}
+	if filter.SubscriptionID != 0 {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("error getting webhook deliveries: %w", err)
+	}
+
+	for i := range deliveries {
+		formatDeliveryTimes(&deliveries[i])
+	}
+	return deliveries, nil
+}
+
+func formatDeliveryTimes(delivery *WebhookDelivery) {
+	delivery.CreatedAt, _ = functions.ParseTimeData(delivery.CreatedAt)
+	if delivery.NextAttempt != "" {
+		delivery.NextAttempt, _ = functions.ParseTimeData(delivery.NextAttempt)
+	}
+	if delivery.LastAttempt != "" {
+		delivery.LastAttempt, _ = functions.ParseTimeData(delivery.LastAttempt)
+	}
+	if delivery.DeliveredAt != "" {
+		delivery.DeliveredAt, _ = functions.ParseTimeData(delivery.DeliveredAt)
+	}
+}
@@ -22,6 +22,9 @@ type Zone struct {
 	IsEnabled     bool                   `bun:"is_enabled,type:bool" json:"-"`
 	IsDeleted     bool                   `bun:"is_deleted,type:bool" json:"-"`
 	Extra         map[string]interface{} `bun:"extra,type:jsonb" json:"extra" swaggertype:"object"`
+	PkaBayID      *int                   `bun:"pka_bay_id" json:"pka_bay_id"`
+	PkaX          *float64               `bun:"pka_x" json:"pka_x"`
+	PkaY          *float64               `bun:"pka_y" json:"pka_y"`
 }
 
 type ZoneNoBind struct {
@@ -35,6 +38,9 @@ type ZoneNoBind struct {
 	IsEnabled     *bool                  `bun:"is_enabled,type:bool" json:"is_enabled"`
 	IsDeleted     *bool                  `bun:"is_deleted,type:bool" json:"is_deleted"`
 	Extra         map[string]interface{} `bun:"extra" json:"extra" swaggertype:"object"`
+	PkaBayID      *int                   `bun:"pka_bay_id" json:"pka_bay_id"`
+	PkaX          *float64               `bun:"pka_x" json:"pka_x"`
+	PkaY          *float64               `bun:"pka_y" json:"pka_y"`
 }
 
 type ZoneName struct {
@@ -53,6 +59,9 @@ type ResponseZone struct {
 	IsEnabled     bool                   `bun:"is_enabled" json:"is_enabled"`
 	IsDeleted     bool                   `bun:"is_deleted" json:"-"`
 	Extra         map[string]interface{} `bun:"extra,type:jsonb" json:"extra" swaggertype:"object"`
+	PkaBayID      *int                   `bun:"pka_bay_id" json:"pka_bay_id"`
+	PkaX          *float64               `bun:"pka_x" json:"pka_x"`
+	PkaY          *float64               `bun:"pka_y" json:"pka_y"`
 }
 
 type ResponseZoneExtra struct {
@@ -429,3 +438,51 @@ func UpdateZoneCapacity(ctx context.Context, zoneID int, meth string) (int64, er
 
 	return rowsAffected, nil
 }
+
+// AdjustZoneFreeCapacity adds delta to the free capacity of a zone, a missing
+// one counting as 0, bounded by 0 and its max capacity. The count is adjusted
+// in a single statement so that concurrent updates are not lost.
+func AdjustZoneFreeCapacity(ctx context.Context, zoneID int, delta int) (int64, error) {
+	log.Debug().Int("Zone ID", zoneID).Int("Delta", delta).Msg("Adjusting zone free capacity")
+
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*Zone)(nil)).
+		Where("zone_id = ?", zoneID).
+		Where("is_deleted = ?", false).
+		Set("free_capacity = LEAST(GREATEST(COALESCE(free_capacity, 0) + ?, 0), max_capacity)", delta).
+		Set("last_update = ?", functions.GetFormatedLocalTime()).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error adjusting free capacity of zone with id %d: %w", zoneID, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving rows affected for zone with id %d: %w", zoneID, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// SetZoneFreeCapacity sets the free capacity of a zone, bounded by 0 and its max capacity.
+func SetZoneFreeCapacity(ctx context.Context, zoneID int, freeCapacity int) (int64, error) {
+	log.Debug().Int("Zone ID", zoneID).Int("Free Capacity", freeCapacity).Msg("Setting zone free capacity")
+
+	res, err := Db_GlobalVar.NewUpdate().
+		Model((*Zone)(nil)).
+		Where("zone_id = ?", zoneID).
+		Where("is_deleted = ?", false).
+		Set("free_capacity = LEAST(GREATEST(?, 0), max_capacity)", freeCapacity).
+		Set("last_update = ?", functions.GetFormatedLocalTime()).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error setting free capacity of zone with id %d: %w", zoneID, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving rows affected for zone with id %d: %w", zoneID, err)
+	}
+
+	return rowsAffected, nil
+}
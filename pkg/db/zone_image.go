@@ -7,6 +7,8 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
+
+	"fyc/functions"
 )
 
 type ImageZone struct {
@@ -17,6 +19,9 @@ type ImageZone struct {
 	ImageSm       string                 `bun:"image_s,type:bytea" json:"image_s" binding:"required"`
 	ImageLg       string                 `bun:"image_l,type:bytea" json:"image_l" binding:"required"`
 	Extra         map[string]interface{} `bun:"extra,type:jsonb" json:"extra" swaggertype:"object"`
+	LastUpdate    string                 `bun:"last_update,type:timestamp,nullzero" json:"-"`
+	// MD5 of the stored pictures, set by hashZoneImages on every write
+	ContentHash string `bun:"content_hash,nullzero" json:"-"`
 }
 
 type ResponseImageZone struct {
@@ -28,6 +33,14 @@ type ResponseImageZone struct {
 	ImageLg       string `bun:"image_l,type:bytea" json:"image_l"`
 }
 
+// ZoneImageRef identifies a zone picture without loading its data
+type ZoneImageRef struct {
+	bun.BaseModel `json:"-" bun:"table:zone_images"`
+	ID            int    `bun:"id" json:"id"`
+	ZoneID        *int   `bun:"zone_id" json:"zone_id"`
+	Language      string `bun:"language" json:"language"`
+}
+
 type ResponseImageLg struct {
 	bun.BaseModel `json:"-" bun:"table:zone_images"`
 	ID            int    `bun:"id" json:"id"`
@@ -52,6 +65,35 @@ type ImageZoneNoBind struct {
 	ImageSm       string                 `bun:"image_s,type:bytea" json:"image_s"`
 	ImageLg       string                 `bun:"image_l,type:bytea" json:"image_l" binding:"required"`
 	Extra         map[string]interface{} `bun:"extra,type:jsonb" json:"extra" swaggertype:"object"`
+	LastUpdate    string                 `bun:"last_update,type:timestamp,nullzero" json:"-"`
+}
+
+// ZoneImageVersion describes a zone picture without loading its data, telling
+// which sizes are stored and when the picture last changed.
+type ZoneImageVersion struct {
+	bun.BaseModel `json:"-" bun:"table:zone_images"`
+	ID            int    `bun:"id"`
+	ZoneID        *int   `bun:"zone_id"`
+	Language      string `bun:"language"`
+	LastUpdate    string `bun:"last_update,nullzero"`
+	ContentHash   string `bun:"content_hash,nullzero"`
+	HasSmall      bool   `bun:"has_small,scanonly"`
+	HasLarge      bool   `bun:"has_large,scanonly"`
+}
+
+// Version identifies the stored data of one size of the picture, changing
+// whenever the pictures change. The last update stands in for the hash of
+// the pictures not hashed yet.
+func (v *ZoneImageVersion) Version(large bool) string {
+	size := "s"
+	if large {
+		size = "l"
+	}
+	version := v.ContentHash
+	if version == "" {
+		version = v.LastUpdate
+	}
+	return fmt.Sprintf("%d-%s-%s", v.ID, size, version)
 }
 
 // Get all Zones with extra data
@@ -74,6 +116,16 @@ func GetAllZoneImage(ctx context.Context) ([]ResponseImageZone, error) {
 	return Rzi, nil
 }
 
+// GetZoneImageRefs returns the ID and language of every zone picture
+func GetZoneImageRefs(ctx context.Context) ([]ZoneImageRef, error) {
+	var refs []ZoneImageRef
+	err := Db_GlobalVar.NewSelect().Model(&refs).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Zone Image references : %w", err)
+	}
+	return refs, nil
+}
+
 // Get all zoneImage Small
 func GetAllZoneImageSm(ctx context.Context) ([]ResponseImageSm, error) {
 	var Zlg []ResponseImageSm
@@ -107,6 +159,61 @@ func GetZoneImageByID(ctx context.Context, id int) (*ImageZone, error) {
 	return zoneImg, nil
 }
 
+// GetZoneImageVersionByID returns the version of the zone picture, without
+// its data.
+func GetZoneImageVersionByID(ctx context.Context, id int) (*ZoneImageVersion, error) {
+	version, err := getZoneImageVersion(ctx, "id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Zone Image version by id : %w", err)
+	}
+	return version, nil
+}
+
+// GetZoneImageVersionByZoneLang returns the version of the picture of the zone
+// in the language, without its data.
+func GetZoneImageVersionByZoneLang(ctx context.Context, zoneID int, language string) (*ZoneImageVersion, error) {
+	version, err := getZoneImageVersion(ctx, "zone_id = ? AND language = ?", zoneID, language)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Zone Image version by Language and id :%d, lang: %s err %w", zoneID, language, err)
+	}
+	return version, nil
+}
+
+func getZoneImageVersion(ctx context.Context, where string, args ...interface{}) (*ZoneImageVersion, error) {
+	version := new(ZoneImageVersion)
+	err := Db_GlobalVar.NewSelect().
+		Model(version).
+		Column("id", "zone_id", "language", "last_update", "content_hash").
+		ColumnExpr("COALESCE(octet_length(image_s), 0) > 0 AS has_small").
+		ColumnExpr("COALESCE(octet_length(image_l), 0) > 0 AS has_large").
+		Where(where, args...).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// GetZoneImageData returns the stored data of one size of the zone picture.
+func GetZoneImageData(ctx context.Context, id int, large bool) (string, error) {
+	column := "image_s"
+	if large {
+		column = "image_l"
+	}
+
+	var data string
+	err := Db_GlobalVar.NewSelect().
+		Model((*ImageZone)(nil)).
+		Column(column).
+		Where("id = ?", id).
+		Scan(ctx, &data)
+	if err != nil {
+		return "", fmt.Errorf("error getting Zone Image data by id %d: %w", id, err)
+	}
+	return data, nil
+}
+
 func GetZoneImageByZONEIDLang(ctx context.Context, id int, language string) (*ImageZone, error) {
 	var zoneImg ImageZone
 	err := Db_GlobalVar.NewSelect().
@@ -169,6 +276,7 @@ func GetZoneImageByZoneID(ctx context.Context, zone_id int) (*ImageZone, error)
 // create a new zone
 func CreateZoneImage(ctx context.Context, zoneImg *ImageZone) error {
 	zoneImg.Language = strings.ToLower(zoneImg.Language)
+	zoneImg.LastUpdate = functions.GetFormatedLocalTime()
 
 	// Insert and get the auto-generated ID from the database
 	_, err := Db_GlobalVar.NewInsert().Model(zoneImg).Returning("id").Exec(ctx)
@@ -177,12 +285,13 @@ func CreateZoneImage(ctx context.Context, zoneImg *ImageZone) error {
 	}
 	log.Debug().Msgf("New zone image added with ID: %d", zoneImg.ID)
 
-	return nil
+	return hashZoneImages(ctx, "id = ?", zoneImg.ID)
 }
 
 // Update a zone img by ID
 func UpdateZoneImage(ctx context.Context, zone_id int, updates *ImageZoneNoBind) (int64, error) {
 	updates.Language = strings.ToLower(updates.Language)
+	updates.LastUpdate = functions.GetFormatedLocalTime()
 
 	var rowsAffected int64
 	log.Debug().Int("id", updates.ID).Int("Zoneid", updates.ZoneID).Str("lang", updates.Language)
@@ -217,9 +326,32 @@ func UpdateZoneImage(ctx context.Context, zone_id int, updates *ImageZoneNoBind)
 		log.Warn().Int("zone_id", zone_id).Int64("Affected", rowsAffected).Msg("ROW affected by insert it")
 	}
 
+	// Changes the version of the picture, and so its ETag
+	if err := hashZoneImages(ctx, "zone_id = ? AND language = ?", updates.ZoneID, updates.Language); err != nil {
+		return 0, err
+	}
 	return rowsAffected, nil
 }
 
+// HashZoneImages sets the hash of the zone pictures stored before it was.
+func HashZoneImages(ctx context.Context) error {
+	return hashZoneImages(ctx, "content_hash IS NULL")
+}
+
+// hashZoneImages stores the hash of the pictures of the zone images matching
+// where, identifying their version.
+func hashZoneImages(ctx context.Context, where string, args ...interface{}) error {
+	_, err := Db_GlobalVar.NewUpdate().
+		Model((*ImageZone)(nil)).
+		Set("content_hash = md5(COALESCE(image_s, ''::bytea) || COALESCE(image_l, ''::bytea))").
+		Where(where, args...).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error hashing zone images: %w", err)
+	}
+	return nil
+}
+
 // Delete a zone img by ID
 func DeleteZoneImage(ctx context.Context, id int) (int64, error) {
 	res, err := Db_GlobalVar.NewDelete().Model(&ImageZone{}).Where("zone_id = ?", id).Exec(ctx)
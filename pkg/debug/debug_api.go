@@ -13,15 +13,17 @@ import (
 //	@Summary	Debug API
 //	@Tags		Debug
 //	@Produce	json
+//	@Security	BearerAuthBackOffice
+//	@Security	ServiceToken
 //	@Router		/fyc/debug [get]
 func Debuger_api(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"ZoneList":   db.Zonelist,
 		"CameraList": db.CameraList,
-		"CamList":    db.CamList,
+		"CamList":    db.CamList(),
 		"SignsList":  db.SignList,
-		"ClientList": db.ClientDataList,
+		"ClientList": db.ClientDataList(),
 		"Clients":    db.ClientListAPI,
 	})
 
@@ -0,0 +1,223 @@
+// Package events publishes typed vehicle, capacity and sign events on a Valkey
+// stream so that downstream services can consume them durably through consumer
+// groups and rebuild their state by replaying the stream from any ID.
+//
+// The JSON schema of the events is documented in docs/events.schema.json.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/functions"
+	"fyc/pkg/valkey"
+)
+
+const SchemaVersion = 1
+
+const (
+	TypeVehicleMovement = "vehicle.movement"
+	TypeCapacityChange  = "zone.capacity_changed"
+	TypeSignUpdate      = "sign.updated"
+)
+
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt string          `json:"occurred_at"`
+	Data       json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+type VehicleMovement struct {
+	LPN             string `json:"lpn"`
+	CameraID        int    `json:"camera_id"`
+	Direction       string `json:"direction"`
+	CurrZoneID      *int   `json:"curr_zone_id"`
+	LastZoneID      *int   `json:"last_zone_id"`
+	Confidence      *int   `json:"confidence"`
+	TransactionDate string `json:"transaction_date"`
+	// FirstSeen is set when the plate had no present car record
+	FirstSeen bool `json:"first_seen"`
+}
+
+type CapacityChange struct {
+	ZoneID       int    `json:"zone_id"`
+	FreeCapacity int    `json:"free_capacity"`
+	MaxCapacity  int    `json:"max_capacity"`
+	Operation    string `json:"operation"`
+}
+
+type SignUpdate struct {
+	SignID int    `json:"sign_id"`
+	ZoneID int    `json:"zone_id"`
+	Value  string `json:"value"`
+	// Delivered is set once the value reached a subscribed sign driver, which
+	// does not confirm that the sign shows it
+	Delivered bool   `json:"delivered"`
+	Error     string `json:"error,omitempty"`
+}
+
+// InitStream creates the stream and the configured consumer groups.
+func InitStream(ctx context.Context) {
+	stream := config.Configvar.Valkey.Stream
+
+	for _, group := range config.Configvar.Valkey.StreamGroups {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+
+		if err := valkey.Valkey_GlobalVar.StreamCreateGroup(ctx, stream, group, "$"); err != nil {
+			log.Err(err).Str("stream", stream).Str("group", group).Msg("Error creating stream consumer group")
+			continue
+		}
+		log.Info().Str("stream", stream).Str("group", group).Msg("Stream consumer group ready")
+	}
+}
+
+// Publish appends a typed event to the stream and returns its ID.
+func Publish(ctx context.Context, eventType string, data interface{}) (string, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling %s event: %w", eventType, err)
+	}
+
+	id, err := valkey.Valkey_GlobalVar.StreamAdd(ctx, config.Configvar.Valkey.Stream, config.Configvar.Valkey.StreamMaxLen, map[string]string{
+		"type":        eventType,
+		"version":     strconv.Itoa(SchemaVersion),
+		"occurred_at": functions.GetFormatedLocalTime(),
+		"data":        string(payload),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error publishing %s event: %w", eventType, err)
+	}
+
+	log.Debug().Str("type", eventType).Str("id", id).Msg("Event published")
+	return id, nil
+}
+
+func PublishVehicleMovement(ctx context.Context, movement VehicleMovement) {
+	publishLogged(ctx, TypeVehicleMovement, movement)
+}
+
+func PublishCapacityChange(ctx context.Context, change CapacityChange) {
+	publishLogged(ctx, TypeCapacityChange, change)
+}
+
+func PublishSignUpdate(ctx context.Context, update SignUpdate) {
+	publishLogged(ctx, TypeSignUpdate, update)
+}
+
+// publishLogged publishes an event whose publication must not fail the change
+// it reports, the error being logged only.
+func publishLogged(ctx context.Context, eventType string, data interface{}) {
+	if _, err := Publish(ctx, eventType, data); err != nil {
+		log.Err(err).Str("type", eventType).Msg("Error publishing event")
+	}
+}
+
+// ValidID reports whether id is a stream ID, "<milliseconds>-<sequence>" or
+// "<milliseconds>", or "0" for the beginning of the stream.
+func ValidID(id string) bool {
+	ms, seq, found := strings.Cut(id, "-")
+	if _, err := strconv.ParseUint(ms, 10, 64); err != nil {
+		return false
+	}
+	if found {
+		if _, err := strconv.ParseUint(seq, 10, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Replay returns up to count events published after the given ID ("0" or
+// empty for the beginning of the stream), optionally filtered by type, with the
+// ID of the last entry scanned to resume from. The stream is read until count
+// events match or its end is reached, so that a filtered page is only short at
+// the end of the stream.
+func Replay(ctx context.Context, afterID string, count int64, eventType string) ([]Event, string, error) {
+	cursor := afterID
+	result := make([]Event, 0, count)
+
+	for int64(len(result)) < count {
+		start := "-"
+		if cursor != "" && cursor != "0" {
+			start = "(" + cursor
+		}
+
+		entries, err := valkey.Valkey_GlobalVar.StreamRange(ctx, config.Configvar.Valkey.Stream, start, "+", count)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, entry := range entries {
+			cursor = entry.ID
+			event := fromEntry(entry)
+			if eventType != "" && event.Type != eventType {
+				continue
+			}
+			result = append(result, event)
+			if int64(len(result)) == count {
+				break
+			}
+		}
+
+		if int64(len(entries)) < count {
+			break
+		}
+	}
+	return result, cursor, nil
+}
+
+// Consume delivers the events of the consumer group to handler and acknowledges
+// those handled without error, until ctx is cancelled. Events left pending by a
+// previous run of the consumer are delivered again first.
+func Consume(ctx context.Context, group string, consumer string, handler func(Event) error) {
+	stream := config.Configvar.Valkey.Stream
+	readID := "0"
+
+	for ctx.Err() == nil {
+		entries, err := valkey.Valkey_GlobalVar.StreamReadGroup(ctx, stream, group, consumer, readID, 100, 5000)
+		if err != nil {
+			log.Err(err).Str("group", group).Str("consumer", consumer).Msg("Error reading events")
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		readID = ">"
+
+		for _, entry := range entries {
+			if err := handler(fromEntry(entry)); err != nil {
+				log.Err(err).Str("group", group).Str("id", entry.ID).Msg("Event handling failed, left pending")
+				continue
+			}
+
+			if err := valkey.Valkey_GlobalVar.StreamAck(ctx, stream, group, entry.ID); err != nil {
+				log.Err(err).Str("group", group).Str("id", entry.ID).Msg("Error acknowledging event")
+			}
+		}
+	}
+}
+
+func fromEntry(entry valkey.StreamEntry) Event {
+	version, _ := strconv.Atoi(entry.Fields["version"])
+
+	return Event{
+		ID:         entry.ID,
+		Type:       entry.Fields["type"],
+		Version:    version,
+		OccurredAt: entry.Fields["occurred_at"],
+		Data:       json.RawMessage(entry.Fields["data"]),
+	}
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/audit"
+	"fyc/pkg/db"
+)
+
+// Entries exported at most, the most recent ones
+const maxAuditExport = 10000
+
+// @Summary		Export Audit Log
+// @Description	Export the audit log entries matching the filters, in PDF, Excel or JSON format based on the `file_type` query parameter. The PDF lists the changes without their snapshots.
+// @Tags			BackOffice - Export
+// @Produce		application/pdf, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet, application/json
+// @Security		BearerAuthBackOffice
+// @Param			file_type	query		string	false	"The type of the export file"	Enums(pdf, excel, json)	default(pdf)
+// @Param			username	query		string	false	"Acting user"
+// @Param			module		query		string	false	"Module"
+// @Param			action		query		string	false	"Action"
+// @Param			entity_id	query		string	false	"ID of the changed entity"
+// @Param			from		query		string	false	"From UTC date, 2006-01-02 or 2006-01-02 15:04:05"
+// @Param			to			query		string	false	"To UTC date, 2006-01-02 or 2006-01-02 15:04:05"
+// @Success		200			{string}	string	"Export successful"
+// @Failure		500			{string}	string	"Internal Server Error"
+// @Router			/backoffice/export_audit [get]
+func ExportAudit(c *gin.Context) {
+	log.Debug().Msg(" / / / / # Exporting Audit Log # / / / /  ")
+	ctx := c.Request.Context()
+
+	fileType := c.DefaultQuery("file_type", "pdf")
+
+	filter, err := audit.ParseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+	filter.Limit = maxAuditExport
+
+	entries, _, err := db.GetAuditLogs(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching audit log")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching audit log"})
+		return
+	}
+
+	switch fileType {
+	case "json":
+		if entries == nil {
+			entries = []db.AuditLog{}
+		}
+		c.Header("Content-Disposition", "attachment; filename=Audit_Log.json")
+		c.JSON(http.StatusOK, entries)
+	case "excel":
+		headers := []string{"ID", "Date", "User", "Role", "Client IP", "Module", "Action", "Entity", "Path", "Before", "After"}
+		var data [][]string
+		for _, entry := range entries {
+			data = append(data, append(auditRow(entry), snapshotString(entry.OldValue), snapshotString(entry.NewValue)))
+		}
+		ExportToExcel(c, data, headers, "Audit_Log")
+	default:
+		headers := []string{"ID", "Date", "User", "Role", "Client IP", "Module", "Action", "Entity", "Path"}
+		widths := []float64{15, 40, 30, 25, 30, 25, 20, 20, 70}
+		var data [][]string
+		for _, entry := range entries {
+			data = append(data, auditRow(entry))
+		}
+		ExportToPDF(c, "L", data, headers, widths, "Audit_Log", "Audit Log Export", "./font/Cairo-Regular.ttf")
+	}
+}
+
+func auditRow(entry db.AuditLog) []string {
+	return []string{
+		fmt.Sprintf("%d", entry.ID),
+		entry.ActionDate,
+		entry.Username,
+		entry.Role,
+		entry.ClientIP,
+		entry.Module,
+		entry.Action,
+		entry.EntityID,
+		entry.Path,
+	}
+}
+
+func snapshotString(snapshot map[string]interface{}) string {
+	if snapshot == nil {
+		return ""
+	}
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
@@ -1,7 +1,6 @@
 package export
 
 import (
-	"context"
 	"fmt"
 	"fyc/pkg/db"
 	"net/http"
@@ -23,7 +22,7 @@ import (
 // @Router			/backoffice/export_client [post]
 func ExportClient(c *gin.Context) {
 	log.Debug().Msg(" / / / / # Exporting Client Data # / / / / ")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	fileType := c.DefaultQuery("file_type", "pdf")
 	var (
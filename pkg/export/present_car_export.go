@@ -1,7 +1,6 @@
 package export
 
 import (
-	"context"
 	"fmt"
 	"fyc/pkg/db"
 	"net/http"
@@ -23,7 +22,7 @@ import (
 // @Router			/backoffice/export_cars [post]
 func ExportCars(c *gin.Context) {
 	log.Debug().Msg(" / / / / # Exporting Present Cars Data # / / / /  ")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Query params
 	fileType := c.DefaultQuery("file_type", "pdf")
@@ -1,7 +1,6 @@
 package export
 
 import (
-	"context"
 	"fmt"
 	"fyc/pkg/db"
 	"net/http"
@@ -24,7 +23,7 @@ import (
 // @Router			/backoffice/export_sign [post]
 func ExportSign(c *gin.Context) {
 	log.Debug().Msg(" / / / / # Exporting Sign Data # / / / /  ")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Query params
 	fileType := c.DefaultQuery("file_type", "pdf")
@@ -1,7 +1,6 @@
 package export
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 
@@ -25,7 +24,7 @@ import (
 // @Router			/backoffice/export_zone [post]
 func ExportZone(c *gin.Context) {
 	log.Debug().Msg(" / / / / # Exporting Zone Data # / / / /  ")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	fileType := c.DefaultQuery("file_type", "pdf")
 
 	var (
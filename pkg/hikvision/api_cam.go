@@ -76,6 +76,10 @@ func HikvisionHandler(c *gin.Context) {
 		log.Error().Msgf("Error getting Data from eventNotification: %v", err)
 	}
 
+	if !authorizeCamera(c, DataCapt.CamIP) {
+		return
+	}
+
 	if config.Configvar.App.SaveXml == "true" {
 		go SaveFile(anprfil[0], "anpr_"+"_"+DataCapt.LicensePlate+".xml", clientIP)
 		log.Debug().Msgf("Saving File Locaally assoc with Licence Plate Number:  %v", DataCapt.LicensePlate)
@@ -105,7 +109,11 @@ func HikvisionHandler(c *gin.Context) {
 	*/
 	//log.Debug().Interface("* - * - *- * DATA *- *- *- *- ", Cdet).Send()
 
-	go DataCapt.ProcessPresentCar(formattedTime, DataCapt)
+	ingestion.Add(1)
+	go func() {
+		defer ingestion.Done()
+		DataCapt.ProcessPresentCar(formattedTime, DataCapt)
+	}()
 
 	jsonData, err := json.Marshal(DataCapt)
 	if err != nil {
@@ -3,12 +3,15 @@ package hikvision
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"fyc/config"
 	"fyc/functions"
 	"fyc/pkg/counting"
 	"fyc/pkg/db"
+	"fyc/pkg/events"
 )
 
 type EventNotificationAlert struct {
@@ -88,11 +91,10 @@ type Capture struct {
 	//PictureInfoList []PictureInfo `xml:"pictureInfo" json:"pictureInfoList,omitempty"`
 }
 
-func Proces_PrCar(captr Capture, camData db.CameraStarter) db.PresentCar {
+func Proces_PrCar(ctx context.Context, captr Capture, camData db.CameraStarter) db.PresentCar {
 
 	var direction = captr.Direction
 	var currZone, lastZone *int
-	ctx := context.Background()
 	//var direction = camData.Direction
 
 	switch captr.Direction {
@@ -104,7 +106,7 @@ func Proces_PrCar(captr Capture, camData db.CameraStarter) db.PresentCar {
 		direction = "forward"
 
 		capacity := counting.Decrease_Zone_Capacity(ctx, *currZone, captr.LicensePlate)
-		counting.Sign_Data_Values(*currZone, "dec", fmt.Sprintf("%d", capacity))
+		counting.Sign_Data_Values(ctx, *currZone, "dec", fmt.Sprintf("%d", capacity))
 
 	case "reverse":
 		log.Debug().Str("Direction", direction).Msg("* * * Camera detect car  * * *  ")
@@ -113,7 +115,7 @@ func Proces_PrCar(captr Capture, camData db.CameraStarter) db.PresentCar {
 		direction = "reverse"
 
 		capacity := counting.Increase_Zone_Capacity(ctx, *currZone, captr.LicensePlate)
-		counting.Sign_Data_Values(*currZone, "inc", fmt.Sprintf("%d", capacity))
+		counting.Sign_Data_Values(ctx, *currZone, "inc", fmt.Sprintf("%d", capacity))
 
 	default:
 		log.Debug().Str("Direction", direction).Msg("- - -  Camera detect car  - - -  ")
@@ -136,14 +138,17 @@ func Proces_PrCar(captr Capture, camData db.CameraStarter) db.PresentCar {
 	return PCam
 }
 
+// ProcessPresentCar processes the capture independently of the request that
+// pushed it, within the request timeout.
 func (c *Capture) ProcessPresentCar(captTime string, dataCapture Capture) {
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Configvar.Server.RequestTimeout)*time.Second)
+	defer cancel()
 
-	if exists, cameras := isCamExist(db.CamList, dataCapture.CamIP); exists {
+	if exists, cameras := isCamExist(dataCapture.CamIP); exists {
 		log.Debug().Str("CamIP", dataCapture.CamIP).Str("LPN", dataCapture.LicensePlate).Str("Direction", dataCapture.Direction).Msg("Camera Existed")
 
 		// Process DATA CAPTURE
-		ProcessCar := Proces_PrCar(dataCapture, *cameras)
+		ProcessCar := Proces_PrCar(ctx, dataCapture, *cameras)
 
 		// Check if present car already exists or not
 		exists, _ := db.GetPresentFound(ctx, ProcessCar.LPN)
@@ -173,6 +178,17 @@ func (c *Capture) ProcessPresentCar(captTime string, dataCapture Capture) {
 		}
 		c.ProcessHistory(ctx, ProcessCar)
 
+		events.PublishVehicleMovement(ctx, events.VehicleMovement{
+			LPN:             ProcessCar.LPN,
+			CameraID:        ProcessCar.CameraID,
+			Direction:       ProcessCar.Direction,
+			CurrZoneID:      ProcessCar.CurrZoneID,
+			LastZoneID:      ProcessCar.LastZoneID,
+			Confidence:      ProcessCar.Confidence,
+			TransactionDate: ProcessCar.TransactionDate,
+			FirstSeen:       !exists,
+		})
+
 	} else {
 		log.Warn().Str("CamIP", dataCapture.CamIP).Str("LPN", dataCapture.LicensePlate).Str("Direction", dataCapture.Direction).Msg("Camera NOT Existed")
 	}
@@ -0,0 +1,26 @@
+package hikvision
+
+import (
+	"context"
+	"sync"
+)
+
+// Captures accepted on /cam and still being processed
+var ingestion sync.WaitGroup
+
+// Drain waits for the captures being processed, or until ctx is done. The
+// server must not accept captures anymore.
+func Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		ingestion.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
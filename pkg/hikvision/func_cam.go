@@ -112,8 +112,8 @@ func SaveDataInCamBody(data []byte) (string, error) {
 	return "Success", nil
 }
 
-func isCamExist(camList map[string]db.CameraStarter, capture string) (bool, *db.CameraStarter) {
-	if cameraStr, exists := camList[capture]; exists {
+func isCamExist(capture string) (bool, *db.CameraStarter) {
+	if cameraStr, exists := db.GetCamStarter(capture); exists {
 		return true, &cameraStr
 	}
 	return false, nil
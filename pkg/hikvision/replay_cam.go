@@ -0,0 +1,52 @@
+package hikvision
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/valkey"
+)
+
+const replayKeyPrefix = "fyc:cam:replay:"
+
+// usedCredentials remembers the credentials used while Valkey is unavailable,
+// so that replays are still rejected by each instance.
+var usedCredentials = struct {
+	sync.Mutex
+	expires map[string]time.Time
+	pruned  time.Time
+}{expires: map[string]time.Time{}}
+
+// firstUse records the use of a credential for ttl, reporting whether it was
+// not used before.
+func firstUse(ctx context.Context, credential string, ttl time.Duration) bool {
+	first, err := valkey.Valkey_GlobalVar.SetIfAbsent(ctx, replayKeyPrefix+credential, "1", ttl)
+	if err != nil {
+		log.Warn().Err(err).Msg("Camera credential use recorded in process")
+	}
+
+	now := time.Now()
+	usedCredentials.Lock()
+	defer usedCredentials.Unlock()
+
+	if now.Sub(usedCredentials.pruned) > time.Minute {
+		for key, expires := range usedCredentials.expires {
+			if !now.Before(expires) {
+				delete(usedCredentials.expires, key)
+			}
+		}
+		usedCredentials.pruned = now
+	}
+
+	if err == nil {
+		return first
+	}
+	if expires, ok := usedCredentials.expires[credential]; ok && now.Before(expires) {
+		return false
+	}
+	usedCredentials.expires[credential] = now.Add(ttl)
+	return true
+}
@@ -0,0 +1,313 @@
+package hikvision
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/db"
+	"fyc/pkg/security"
+)
+
+const (
+	// Headers of the events signed with the HMAC of the camera secret
+	TimestampHeader = "X-FYC-Timestamp"
+	SignatureHeader = "X-FYC-Signature"
+
+	contextBody = "cam_body"
+
+	// Digest nonces are accepted for this long
+	nonceValidity = 5 * time.Minute
+)
+
+// Key of the digest nonces, a restart invalidating the nonces issued before
+var nonceKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("error generating digest nonce key: %v", err))
+	}
+	return key
+}()
+
+// CameraGuard rejects the clients that are not a registered camera, when the
+// client IP check is enabled, and limits the size of the events pushed on
+// /cam. The body is kept to verify the signature of the event.
+func CameraGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Unknown clients are rejected before their body is read
+		if config.Configvar.Camera.CheckClientIP {
+			if _, exists := db.GetCamStarter(c.ClientIP()); !exists {
+				rejectCamera(c, http.StatusForbidden, db.SecurityLog{Reason: db.SecurityUnknownIP})
+				return
+			}
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, config.Configvar.Camera.MaxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				rejectCamera(c, http.StatusRequestEntityTooLarge, db.SecurityLog{Reason: db.SecurityBodyTooLarge, Detail: fmt.Sprintf("body over %d bytes", maxBytesErr.Limit)})
+				return
+			}
+			log.Warn().Err(err).Str("Camera IP", c.ClientIP()).Msg("Error reading camera event")
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Multipart form required",
+				"code":  12,
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Set(contextBody, body)
+
+		c.Next()
+	}
+}
+
+// authorizeCamera checks that the event of the camera at camIP, as read from
+// the event, comes from this camera, authenticated as configured. Rejected
+// events are answered and recorded in the security log.
+func authorizeCamera(c *gin.Context, camIP string) bool {
+	entry := db.SecurityLog{CameraIP: camIP}
+
+	if config.Configvar.Camera.CheckClientIP && camIP != c.ClientIP() {
+		entry.Reason = db.SecurityIPMismatch
+		rejectCamera(c, http.StatusForbidden, entry)
+		return false
+	}
+
+	camera, exists := db.GetCamStarter(camIP)
+	if !exists {
+		entry.Reason = db.SecurityUnknownCamera
+		rejectCamera(c, http.StatusForbidden, entry)
+		return false
+	}
+	entry.CamID = camera.CamID
+
+	switch camera.IngestAuth {
+	case "", db.IngestAuthNone:
+		return true
+	case db.IngestAuthBasic:
+		username, password, ok := c.Request.BasicAuth()
+		if !ok {
+			entry.Reason = db.SecurityAuthMissing
+		} else if !equalSecret(username, camera.IngestUser) || !equalSecret(password, camera.IngestSecret) {
+			entry.Reason = db.SecurityAuthFailed
+		} else {
+			return true
+		}
+		c.Header("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", config.Configvar.Camera.DigestRealm))
+	case db.IngestAuthDigest:
+		reason, stale := verifyDigest(c.Request, camera)
+		if reason == "" {
+			return true
+		}
+		entry.Reason = reason
+		c.Header("WWW-Authenticate", digestChallenge(stale))
+	case db.IngestAuthHMAC:
+		reason, detail := verifySignature(c, camera)
+		if reason == "" {
+			return true
+		}
+		entry.Reason, entry.Detail = reason, detail
+	default:
+		entry.Reason = db.SecurityAuthFailed
+		entry.Detail = "unsupported ingest auth " + camera.IngestAuth
+	}
+
+	rejectCamera(c, http.StatusUnauthorized, entry)
+	return false
+}
+
+func rejectCamera(c *gin.Context, status int, entry db.SecurityLog) {
+	entry.Source = security.SourceCamera
+	entry.ClientIP = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+	security.Record(c.Request.Context(), entry)
+
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": "Camera not authorized",
+		"code":  13,
+	})
+}
+
+func equalSecret(given string, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
+
+// verifySignature checks the HMAC-SHA256 of the timestamp and body of the
+// event, keyed with the camera secret: hex(HMAC(secret, timestamp + "." + body)).
+func verifySignature(c *gin.Context, camera db.CameraStarter) (string, string) {
+	timestamp := c.GetHeader(TimestampHeader)
+	signature := strings.TrimPrefix(c.GetHeader(SignatureHeader), "sha256=")
+	if timestamp == "" || signature == "" {
+		return db.SecurityAuthMissing, ""
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return db.SecuritySignatureInvalid, "invalid timestamp"
+	}
+	if skew := time.Since(time.Unix(unix, 0)).Abs(); skew > time.Duration(config.Configvar.Camera.HMACMaxSkew)*time.Second {
+		return db.SecuritySignatureInvalid, fmt.Sprintf("timestamp skew %s", skew.Round(time.Second))
+	}
+
+	given, err := hex.DecodeString(signature)
+	if err != nil {
+		return db.SecuritySignatureInvalid, "invalid signature encoding"
+	}
+
+	mac := hmac.New(sha256.New, []byte(camera.IngestSecret))
+	mac.Write([]byte(timestamp + "."))
+	body, _ := c.Get(contextBody)
+	raw, _ := body.([]byte)
+	mac.Write(raw)
+	if !hmac.Equal(given, mac.Sum(nil)) {
+		return db.SecuritySignatureInvalid, ""
+	}
+
+	// A signature is accepted until its timestamp is out of the skew on
+	// either side, and only once meanwhile
+	maxSkew := time.Duration(config.Configvar.Camera.HMACMaxSkew) * time.Second
+	if !firstUse(c.Request.Context(), "hmac:"+hex.EncodeToString(given), 2*maxSkew) {
+		return db.SecurityReplay, "signature already used"
+	}
+	return "", ""
+}
+
+// verifyDigest checks the digest authentication (RFC 7616, MD5) of the
+// request, reporting whether the nonce is stale when it only expired.
+func verifyDigest(r *http.Request, camera db.CameraStarter) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Digest ") {
+		return db.SecurityAuthMissing, false
+	}
+	params := parseDigestParams(strings.TrimPrefix(header, "Digest "))
+
+	if params["realm"] != config.Configvar.Camera.DigestRealm || params["uri"] != r.RequestURI {
+		return db.SecurityAuthFailed, false
+	}
+	if valid, expired := checkNonce(params["nonce"]); !valid {
+		return db.SecurityAuthFailed, expired
+	}
+	if !equalSecret(params["username"], camera.IngestUser) {
+		return db.SecurityAuthFailed, false
+	}
+
+	ha1 := md5Hex(camera.IngestUser + ":" + config.Configvar.Camera.DigestRealm + ":" + camera.IngestSecret)
+	ha2 := md5Hex(r.Method + ":" + params["uri"])
+	var expected string
+	switch params["qop"] {
+	case "auth":
+		expected = md5Hex(strings.Join([]string{ha1, params["nonce"], params["nc"], params["cnonce"], "auth", ha2}, ":"))
+	case "":
+		expected = md5Hex(ha1 + ":" + params["nonce"] + ":" + ha2)
+	default:
+		return db.SecurityAuthFailed, false
+	}
+
+	if !equalSecret(params["response"], expected) {
+		return db.SecurityAuthFailed, false
+	}
+
+	// Each nonce count is accepted once per nonce, and a nonce without qop
+	// once, until the nonce expires. Replays get a fresh nonce as stale.
+	used := "digest:" + params["nonce"]
+	if params["qop"] != "" {
+		used += ":" + strings.ToLower(params["nc"])
+	}
+	if !firstUse(r.Context(), used, nonceValidity) {
+		return db.SecurityReplay, true
+	}
+	return "", false
+}
+
+func digestChallenge(stale bool) string {
+	challenge := fmt.Sprintf("Digest realm=%q, qop=\"auth\", algorithm=MD5, nonce=%q", config.Configvar.Camera.DigestRealm, newNonce(time.Now()))
+	if stale {
+		challenge += ", stale=true"
+	}
+	return challenge
+}
+
+// newNonce returns a stateless nonce: the issue time and its HMAC.
+func newNonce(now time.Time) string {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, nonceKey)
+	mac.Write([]byte(timestamp))
+	return base64.RawURLEncoding.EncodeToString([]byte(timestamp + ":" + hex.EncodeToString(mac.Sum(nil))))
+}
+
+// checkNonce reports whether the nonce was issued by newNonce and is not
+// expired, or whether it only expired.
+func checkNonce(nonce string) (bool, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil {
+		return false, false
+	}
+	timestamp, _, found := strings.Cut(string(raw), ":")
+	if !found {
+		return false, false
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false, false
+	}
+
+	issued := time.Unix(unix, 0)
+	if !equalSecret(nonce, newNonce(issued)) {
+		return false, false
+	}
+	if time.Since(issued) > nonceValidity {
+		return false, true
+	}
+	return true, false
+}
+
+// parseDigestParams parses the comma-separated key=value pairs of a digest
+// authorization, values being quoted or not.
+func parseDigestParams(value string) map[string]string {
+	params := make(map[string]string)
+	for len(value) > 0 {
+		value = strings.TrimLeft(value, " ,")
+		key, rest, found := strings.Cut(value, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			params[key] = rest[1 : end+1]
+			value = rest[end+2:]
+		} else {
+			item, next, _ := strings.Cut(rest, ",")
+			params[key] = strings.TrimSpace(item)
+			value = next
+		}
+	}
+	return params
+}
+
+func md5Hex(value string) string {
+	sum := md5.Sum([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+package imaging
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache keeps the rendered pictures in memory, evicting the least recently used
+// ones once the total size exceeds its capacity.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	size     int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).data, true
+}
+
+func (c *Cache) Put(key string, data []byte) {
+	if len(data) > c.capacity {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.size -= len(element.Value.(*cacheEntry).data)
+		element.Value.(*cacheEntry).data = data
+		c.size += len(data)
+		c.order.MoveToFront(element)
+	} else {
+		c.entries[key] = c.order.PushFront(&cacheEntry{key: key, data: data})
+		c.size += len(data)
+	}
+
+	for c.size > c.capacity {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.data)
+	}
+}
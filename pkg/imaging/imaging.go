@@ -0,0 +1,226 @@
+// Package imaging decodes the zone pictures stored as base64 data URIs, resizes
+// them and encodes them in the format negotiated with the client.
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+)
+
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	WEBP = "image/webp"
+)
+
+// Source is a stored picture decoded from its data URI.
+type Source struct {
+	Data        []byte
+	ContentType string
+}
+
+// DecodeDataURI decodes a picture stored as "data:image/...;base64,..." or as
+// plain base64.
+func DecodeDataURI(value string) (*Source, error) {
+	if value == "" {
+		return nil, fmt.Errorf("empty image data")
+	}
+
+	payload := value
+	contentType := ""
+	if strings.HasPrefix(value, "data:") {
+		comma := strings.Index(value, ",")
+		if comma < 0 || !strings.HasSuffix(value[:comma], ";base64") {
+			return nil, fmt.Errorf("invalid image data URI")
+		}
+		contentType = strings.TrimSuffix(strings.TrimPrefix(value[:comma], "data:"), ";base64")
+		payload = value[comma+1:]
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image data: %w", err)
+	}
+
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	switch contentType {
+	case JPEG, PNG, WEBP:
+		return &Source{Data: data, ContentType: contentType}, nil
+	default:
+		return nil, fmt.Errorf("unsupported image type %s", contentType)
+	}
+}
+
+// canEncode reports whether the pipeline can produce the format. WebP can only
+// be served as stored since the standard library has no WebP codec.
+func canEncode(format string, source *Source, width int) bool {
+	switch format {
+	case JPEG, PNG:
+		return source.ContentType != WEBP
+	case WEBP:
+		return source.ContentType == WEBP && width == 0
+	}
+	return false
+}
+
+// Negotiate picks the output format from the Accept header, preferring the
+// stored format when the client accepts it.
+func Negotiate(accept string, source *Source, width int) (string, bool) {
+	if accept == "" {
+		accept = "*/*"
+	}
+
+	bestQuality := 0.0
+	best := ""
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, quality := parseAcceptPart(part)
+		if quality <= 0 {
+			continue
+		}
+
+		var candidates []string
+		switch mediaType {
+		case "*/*", "image/*":
+			candidates = []string{source.ContentType, JPEG, PNG}
+		case JPEG, PNG, WEBP:
+			candidates = []string{mediaType}
+		case "image/jpg":
+			candidates = []string{JPEG}
+		}
+
+		for _, candidate := range candidates {
+			if !canEncode(candidate, source, width) {
+				continue
+			}
+			if quality > bestQuality || (quality == bestQuality && candidate == source.ContentType) {
+				bestQuality = quality
+				best = candidate
+			}
+			break
+		}
+	}
+
+	return best, best != ""
+}
+
+func parseAcceptPart(part string) (string, float64) {
+	fields := strings.Split(part, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+	quality := 1.0
+	for _, param := range fields[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if _, err := fmt.Sscanf(param[2:], "%g", &quality); err != nil {
+				quality = 0
+			}
+		}
+	}
+	return mediaType, quality
+}
+
+// Render resizes the source to width pixels, 0 keeping the original width, and
+// encodes it in format. Pictures are never enlarged.
+func Render(source *Source, width int, format string, jpegQuality int) ([]byte, error) {
+	if width == 0 && format == source.ContentType {
+		return source.Data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(source.Data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %w", err)
+	}
+
+	if width > 0 && width < img.Bounds().Dx() {
+		img = Resize(img, width)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case JPEG:
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: jpegQuality})
+	case PNG:
+		err = png.Encode(&buf, img)
+	default:
+		err = fmt.Errorf("cannot encode image to %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// flatten draws transparent pictures on a white background for JPEG output.
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+// Resize scales the picture down to width pixels keeping its aspect ratio,
+// averaging the source pixels covered by each destination pixel.
+func Resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	height := srcH * width / srcW
+	if height < 1 {
+		height = 1
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := (y + 1) * srcH / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := (x + 1) * srcW / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				offset := sy*src.Stride + x0*4
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[offset])
+					g += uint32(src.Pix[offset+1])
+					b += uint32(src.Pix[offset+2])
+					a += uint32(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,146 @@
+package imaging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"fyc/config"
+)
+
+// Requested widths are rounded up to a multiple of widthStep to bound the
+// number of renditions cached per picture.
+const widthStep = 50
+
+var ErrNotAcceptable = errors.New("no acceptable image format")
+
+var (
+	cacheOnce   sync.Once
+	renderCache *Cache
+)
+
+func getCache() *Cache {
+	cacheOnce.Do(func() {
+		renderCache = NewCache(config.Configvar.Image.CacheSize * 1024 * 1024)
+	})
+	return renderCache
+}
+
+type Options struct {
+	// Width in pixels, 0 for the original width
+	Width int
+	// Format forced by the request, e.g. from the file extension, the request
+	// being refused with ErrNotAcceptable when it cannot be produced
+	Format string
+	// Public allows shared caches to store the response
+	Public bool
+}
+
+// NormalizeWidth bounds and rounds the requested width.
+func NormalizeWidth(width int) int {
+	if width <= 0 {
+		return 0
+	}
+	if maxWidth := config.Configvar.Image.MaxWidth; maxWidth > 0 && width > maxWidth {
+		width = maxWidth
+	}
+	return (width + widthStep - 1) / widthStep * widthStep
+}
+
+// Serve writes the picture identified by version in the negotiated format
+// and width, with ETag and Cache-Control headers, answering 304 when the client
+// copy is current. The ETag is derived from the version and the request, so
+// that the stored picture is only loaded, with load, when the rendition is
+// neither current on the client nor cached.
+func Serve(c *gin.Context, version string, load func() (string, error), opts Options) error {
+	width := NormalizeWidth(opts.Width)
+
+	// The response varies with the Accept header when the format is negotiated
+	sum := sha256.Sum256([]byte(strings.Join([]string{version, opts.Format, c.GetHeader("Accept")}, "|")))
+	etag := fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:8]), width)
+
+	cacheControl := "private"
+	if opts.Public {
+		cacheControl = "public"
+	}
+	setHeaders := func() {
+		c.Header("Cache-Control", fmt.Sprintf("%s, max-age=%d", cacheControl, config.Configvar.Image.MaxAge))
+		c.Header("ETag", etag)
+		c.Header("Vary", "Accept")
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		setHeaders()
+		c.Status(http.StatusNotModified)
+		return nil
+	}
+
+	cache := getCache()
+	if data, ok := cache.Get(etag); ok {
+		setHeaders()
+		c.Data(http.StatusOK, http.DetectContentType(data), data)
+		return nil
+	}
+
+	stored, err := load()
+	if err != nil {
+		return err
+	}
+	source, err := DecodeDataURI(stored)
+	if err != nil {
+		return err
+	}
+
+	format := opts.Format
+	if format != "" && !canEncode(format, source, width) {
+		return ErrNotAcceptable
+	}
+	if format == "" {
+		var ok bool
+		if format, ok = Negotiate(c.GetHeader("Accept"), source, width); !ok {
+			return ErrNotAcceptable
+		}
+	}
+
+	data, err := Render(source, width, format, config.Configvar.Image.JpegQuality)
+	if err != nil {
+		return err
+	}
+	cache.Put(etag, data)
+
+	setHeaders()
+	c.Data(http.StatusOK, format, data)
+	return nil
+}
+
+func etagMatches(header string, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatFromName returns the format matching the extension of a picture name.
+func FormatFromName(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".png"):
+		return PNG
+	case strings.HasSuffix(name, ".jpeg"), strings.HasSuffix(name, ".jpg"):
+		return JPEG
+	case strings.HasSuffix(name, ".webp"):
+		return WEBP
+	}
+	return ""
+}
@@ -4,20 +4,26 @@ package models
 
 // AddZone represents the data structure for a new zone
 type AddZoneModel struct {
-	ZoneID       int    `json:"zone_id"`
-	Name         Name   `json:"name"`
-	MaxCapacity  int    `json:"max_capacity"`
-	FreeCapacity int    `json:"free_capacity"`
-	Images       Images `json:"images"`
+	ZoneID       int     `json:"zone_id"`
+	Name         Name    `json:"name"`
+	MaxCapacity  int     `json:"max_capacity"`
+	FreeCapacity int     `json:"free_capacity"`
+	Images       Images  `json:"images"`
+	PkaBayID     int     `json:"pka_bay_id"`
+	PkaX         float64 `json:"pka_x"`
+	PkaY         float64 `json:"pka_y"`
 }
 
 type UpdateZoneModel struct {
-	ZoneID       int    `json:"-"`
-	Name         Name   `json:"name"`
-	MaxCapacity  int    `json:"max_capacity"`
-	FreeCapacity int    `json:"free_capacity"`
-	Images       Images `json:"images"`
-	IsEnabled    bool   `json:"is_enabled"`
+	ZoneID       int     `json:"-"`
+	Name         Name    `json:"name"`
+	MaxCapacity  int     `json:"max_capacity"`
+	FreeCapacity int     `json:"free_capacity"`
+	Images       Images  `json:"images"`
+	IsEnabled    bool    `json:"is_enabled"`
+	PkaBayID     int     `json:"pka_bay_id"`
+	PkaX         float64 `json:"pka_x"`
+	PkaY         float64 `json:"pka_y"`
 }
 
 // Name represents the name of the zone in multiple languages
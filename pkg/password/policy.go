@@ -0,0 +1,131 @@
+// Package password enforces the password policy of backoffice users.
+package password
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"fyc/config"
+)
+
+// bcrypt ignores the bytes after the 72nd
+const maxLength = 72
+
+type Policy struct {
+	MinLength     int  `json:"min_length"`
+	MaxLength     int  `json:"max_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+}
+
+// CurrentPolicy returns the policy configured for the backoffice.
+func CurrentPolicy() Policy {
+	cfg := config.Configvar.Password
+	return Policy{
+		MinLength:     cfg.MinLength,
+		MaxLength:     maxLength,
+		RequireUpper:  cfg.RequireUpper,
+		RequireLower:  cfg.RequireLower,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+	}
+}
+
+// Validate checks the password of the user against the policy, the error
+// listing every rule not met.
+func Validate(username string, password string) error {
+	policy := CurrentPolicy()
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	var problems []string
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		problems = append(problems, fmt.Sprintf("at least %d characters", policy.MinLength))
+	}
+	if len(password) > policy.MaxLength {
+		problems = append(problems, fmt.Sprintf("at most %d bytes", policy.MaxLength))
+	}
+	if policy.RequireUpper && !upper {
+		problems = append(problems, "an upper case letter")
+	}
+	if policy.RequireLower && !lower {
+		problems = append(problems, "a lower case letter")
+	}
+	if policy.RequireDigit && !digit {
+		problems = append(problems, "a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		problems = append(problems, "a symbol")
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		problems = append(problems, "not contain the username")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("password must have " + strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Generate returns a random password meeting the policy, used for resets.
+func Generate() (string, error) {
+	const (
+		uppers  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+		lowers  = "abcdefghijkmnopqrstuvwxyz"
+		digits  = "23456789"
+		symbols = "!#$%&*+-=?@_"
+	)
+
+	length := min(max(CurrentPolicy().MinLength, 16), maxLength)
+	// One character of each class, the rest from all of them
+	sets := []string{uppers, lowers, digits, symbols}
+	for len(sets) < length {
+		sets = append(sets, uppers+lowers+digits+symbols)
+	}
+
+	chars := make([]byte, length)
+	for i, set := range sets {
+		c, err := randomIndex(len(set))
+		if err != nil {
+			return "", err
+		}
+		chars[i] = set[c]
+	}
+
+	// Shuffle so that the class of each position is not predictable
+	for i := len(chars) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars), nil
+}
+
+func randomIndex(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("error generating password: %w", err)
+	}
+	return int(v.Int64()), nil
+}
@@ -2,8 +2,10 @@ package pka
 
 import (
 	"context"
-	"encoding/base64"
+	"errors"
+	"fyc/pkg/apierror"
 	"fyc/pkg/db"
+	"fyc/pkg/imaging"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,16 +17,18 @@ import (
 // PkaImageAPI handles the request to get an image.
 //
 //	@Summary		PKA SYSTEM API - Search Image
-//	@Description	Retrieve an image from the PKA system by image name.
+//	@Description	Retrieve a map from the PKA system by image name, the map ID returned by bays.json. When a language is requested, the picture of the same zone in that language is served if there is one. The format is taken from the extension or negotiated with the Accept header, and the image can be resized to the requested width. WebP is only served for pictures stored as WebP, at their original width, and a format that cannot be produced is answered 406.
 //	@Tags			PKA - API
-//	@Produce		image/jpeg, image/png
-//	@Param			imagename	path	string	true	"Image Name"	"The name of the image to retrieve, without the '.png' or '.jpeg' extension."
+//	@Produce		image/jpeg, image/png, image/webp
+//	@Param			imagename	path	string	true	"Image Name"	"The name of the image to retrieve, optionally with a '.png', '.jpeg' or '.webp' extension."
+//	@Param			width		query	int		false	"Width in pixels, the image is never enlarged"
+//	@Param			language	query	string	false	"Language of the map, else Accept-Language, else the language of the picture"
 //	@Success		200			{file}	string	"Image retrieved successfully."
+//	@Success		304			"Not Modified"
 //	@Router			/v2/maps/{imagename} [get]
 func PkaImageAPI(c *gin.Context) {
 	image_name := c.Param("imagename")
-	ctx := context.Background()
-	var imageData string
+	ctx := c.Request.Context()
 
 	log.Debug().Str(" Name", image_name).Msg("Requesting image")
 
@@ -38,9 +42,11 @@ func PkaImageAPI(c *gin.Context) {
 		return
 	}
 
-	// Remove the ".png" or ".jpeg" extension if present
-	image_name = strings.TrimSuffix(image_name, ".png")
-	image_name = strings.TrimSuffix(image_name, ".jpeg")
+	// The extension, if any, selects the output format
+	format := imaging.FormatFromName(image_name)
+	if dot := strings.LastIndex(image_name, "."); dot >= 0 {
+		image_name = image_name[:dot]
+	}
 
 	// Parse the remaining part as an integer
 	pictureName, err := strconv.Atoi(image_name)
@@ -54,7 +60,12 @@ func PkaImageAPI(c *gin.Context) {
 		return
 	}
 
-	zoneImg, err := db.GetZoneImageByZONEIDLang(ctx, pictureName, "en")
+	width, _ := strconv.Atoi(c.Query("width"))
+
+	zoneImg, err := db.GetZoneImageVersionByID(ctx, pictureName)
+	if err == nil {
+		zoneImg = translatedMap(ctx, c, zoneImg)
+	}
 	if err != nil {
 		log.Err(err).Int("Image ID", pictureName).Msg("Image not found for the specified ID")
 		c.JSON(http.StatusNotFound, gin.H{
@@ -65,23 +76,32 @@ func PkaImageAPI(c *gin.Context) {
 		return
 	}
 
+	large := true
 	settingsData, err := db.GetAllSettings(ctx)
 	if err != nil || settingsData.PkaImageSize == "" {
-		log.Warn().Int("Image ID", pictureName).Msg("Settings not found or empty -- using small image size")
-		imageData = zoneImg.ImageLg
+		log.Warn().Int("Image ID", pictureName).Msg("Settings not found or empty -- using large image size")
 	} else {
-		switch settingsData.PkaImageSize {
-		case "large":
-			imageData = zoneImg.ImageLg
-		case "small":
-			imageData = zoneImg.ImageSm
-		default:
-			imageData = zoneImg.ImageSm
-		}
+		large = settingsData.PkaImageSize == "large"
+	}
+
+	// Resizing starts from the large picture for a better quality
+	if width > 0 && zoneImg.HasLarge {
+		large = true
 	}
 
-	if len(imageData) == 0 || (!strings.HasPrefix(imageData, "data:image/jpeg;base64,") && !strings.HasPrefix(imageData, "data:image/png;base64,")) {
-		log.Err(err).Int("Image Data for ZoneID", *zoneImg.ZoneID).Msg("Invalid image data format")
+	err = imaging.Serve(c, zoneImg.Version(large), func() (string, error) {
+		return db.GetZoneImageData(ctx, zoneImg.ID, large)
+	}, imaging.Options{Width: width, Format: format, Public: true})
+	if errors.Is(err, imaging.ErrNotAcceptable) {
+		c.JSON(http.StatusNotAcceptable, gin.H{
+			"success": false,
+			"code":    -5,
+			"message": "The image can be served as image/jpeg, image/png or, when stored as WebP at its original width, image/webp",
+		})
+		return
+	}
+	if err != nil {
+		log.Err(err).Int("Image ID", zoneImg.ID).Interface("Zone ID", zoneImg.ZoneID).Msg("Invalid image data format")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"code":    -500,
@@ -90,28 +110,24 @@ func PkaImageAPI(c *gin.Context) {
 		return
 	}
 
-	var decodedData []byte
-	var contentType string
+	log.Info().Str(" Name", image_name).Interface("Zone ID", zoneImg.ZoneID).Msg("Image Successfully Fetched")
+}
 
-	if strings.HasPrefix(imageData, "data:image/jpeg;base64,") {
-		contentType = "image/jpeg"
-		decodedData, err = base64.StdEncoding.DecodeString(imageData[len("data:image/jpeg;base64,"):])
-	} else if strings.HasPrefix(imageData, "data:image/png;base64,") {
-		contentType = "image/png"
-		decodedData, err = base64.StdEncoding.DecodeString(imageData[len("data:image/png;base64,"):])
+// translatedMap returns the picture of the same zone in the language requested
+// by the parameter or the Accept-Language header, if any, else zoneImg.
+func translatedMap(ctx context.Context, c *gin.Context, zoneImg *db.ZoneImageVersion) *db.ZoneImageVersion {
+	language := strings.ToLower(strings.TrimSpace(c.Query("language")))
+	if language == "" {
+		language = apierror.PreferredLanguage(c.GetHeader("Accept-Language"))
+	}
+	if language == "" || zoneImg.ZoneID == nil || strings.EqualFold(zoneImg.Language, language) {
+		return zoneImg
 	}
 
+	translated, err := db.GetZoneImageVersionByZoneLang(ctx, *zoneImg.ZoneID, language)
 	if err != nil {
-		log.Err(err).Msg("Error decoding the image data")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"code":    -500,
-			"message": "An unexpected error occurred while decoding the image data.",
-		})
-		return
+		log.Debug().Int("Image ID", zoneImg.ID).Str("Language", language).Msg("No map in the requested language")
+		return zoneImg
 	}
-
-	log.Info().Str(" Name", image_name).Int("Zone ID", *zoneImg.ZoneID).Msg("Image Successfully Fetched")
-	c.Header("Content-Type", contentType)
-	c.Data(http.StatusOK, contentType, decodedData)
+	return translated
 }
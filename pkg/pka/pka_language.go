@@ -0,0 +1,86 @@
+package pka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"fyc/functions"
+	"fyc/pkg/apierror"
+	"fyc/pkg/db"
+)
+
+const fallbackLanguage = "en"
+
+// pkaLanguages returns the languages to try for the request, most preferred
+// first: the language parameter, the Accept-Language header, the default
+// language of the car park and English.
+func pkaLanguages(ctx context.Context, c *gin.Context) []string {
+	candidates := []string{
+		strings.ToLower(strings.TrimSpace(c.Query("language"))),
+		apierror.PreferredLanguage(c.GetHeader("Accept-Language")),
+	}
+	if settings, err := db.GetAllSettings(ctx); err == nil {
+		candidates = append(candidates, strings.ToLower(settings.DefaultLang))
+	}
+	candidates = append(candidates, fallbackLanguage)
+
+	var languages []string
+	for _, language := range candidates {
+		if language != "" && !functions.ContainsStr(languages, language) {
+			languages = append(languages, language)
+		}
+	}
+	return languages
+}
+
+// localizedName returns the zone name in the first available language.
+func localizedName(names map[string]interface{}, languages []string) string {
+	for _, language := range languages {
+		if name, ok := names[language]; ok && name != nil && fmt.Sprint(name) != "" {
+			return fmt.Sprint(name)
+		}
+	}
+	return ""
+}
+
+// zoneMaps links the zones to their map pictures
+type zoneMaps struct {
+	// pictures holds the map picture of each zone in the first available language
+	pictures map[int]int
+	// zones holds the zone of every map picture, whatever its language
+	zones map[int]int
+}
+
+func loadZoneMaps(ctx context.Context, languages []string) (*zoneMaps, error) {
+	refs, err := db.GetZoneImageRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	maps := &zoneMaps{pictures: make(map[int]int), zones: make(map[int]int)}
+	rank := make(map[int]int)
+	for _, ref := range refs {
+		if ref.ZoneID == nil {
+			continue
+		}
+		maps.zones[ref.ID] = *ref.ZoneID
+
+		// Pictures in languages not requested are only used as last resort
+		r := len(languages)
+		for i, language := range languages {
+			if strings.EqualFold(ref.Language, language) {
+				r = i
+				break
+			}
+		}
+
+		if current, ok := rank[*ref.ZoneID]; !ok || r < current {
+			maps.pictures[*ref.ZoneID] = ref.ID
+			rank[*ref.ZoneID] = r
+		}
+	}
+	return maps, nil
+}
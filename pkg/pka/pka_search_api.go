@@ -1,71 +1,191 @@
 package pka
 
 import (
-	"context"
 	"fmt"
-	"fyc/pkg/db"
-	"fyc/pkg/third_party"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/db"
+)
+
+const (
+	defaultBayLimit = 100
+	maxBayLimit     = 500
 )
 
+type Bay struct {
+	ID             int         `json:"id"`
+	IsInViolation  bool        `json:"is_in_violation"`
+	IsOccupied     bool        `json:"is_occupied"`
+	IsOutOfService bool        `json:"is_out_of_service"`
+	IsReserved     bool        `json:"is_reserved"`
+	Map            BayRef      `json:"map"`
+	Position       BayPosition `json:"position"`
+	Visit          BayVisit    `json:"visit"`
+	Zone           BayRef      `json:"zone"`
+}
+
+type BayRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type BayPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type BayVisit struct {
+	// Dwell is formatted as a .NET TimeSpan, [d.]hh:mm:ss.fffffff
+	Dwell          string   `json:"dwell"`
+	EntryTimestamp string   `json:"entry_timestamp"`
+	ID             int64    `json:"id"`
+	Plate          BayPlate `json:"plate"`
+}
+
+type BayPlate struct {
+	Confidence int    `json:"confidence"`
+	Text       string `json:"text"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// bayQuery holds the PKA v2 query parameters of bays.json
+type bayQuery struct {
+	plate      string
+	visitID    *int64
+	bayID      *int
+	mapID      *int
+	zoneID     *int
+	flags      map[string]bool
+	limit      int
+	offset     int
+	singleBay  bool
+	filterZone bool
+}
+
 // @Summary		PKA SYSTEM API - Search Car
 //
-// @Description	Get ZONE with PKA SYSTEM
+// @Description	Emulates the PKA v2 bays.json API, each present car being reported as an occupied bay of its zone. The bay ID and position are taken from the PKA settings of the zone, the zone ID being used as bay ID when unset. The map ID is the ID of the zone picture to get from /v2/maps, in the requested language when available. Lookups by visit.plate.text or visit.id return a single bay, or 404 when the car is not present. Other queries return a list of bays, possibly empty, without the plates and visit IDs. The visit ID is a random ID of the visit, not a sequence.
 // @Tags			PKA - API
 // @Produce		json
-// @Param			visit.plate.text	query	string	true	"visit.plate.text"
+// @Param			visit.plate.text	query	string	false	"Exact license plate"
+// @Param			language			query	string	false	"Language of the zone names and maps, else Accept-Language, else the car park default language"
+// @Param			visit.id			query	int		false	"Visit ID"
+// @Param			id					query	int		false	"Bay ID"
+// @Param			map.id				query	int		false	"Map ID"
+// @Param			zone.id				query	int		false	"Zone ID"
+// @Param			is_occupied			query	bool	false	"Occupied bays only (true) or free bays only (false)"
+// @Param			is_out_of_service	query	bool	false	"Bays of disabled zones"
+// @Param			is_reserved			query	bool	false	"Reserved bays"
+// @Param			is_in_violation		query	bool	false	"Bays in violation"
+// @Param			limit				query	int		false	"Maximum number of bays"	default(100)
+// @Param			offset				query	int		false	"Number of bays to skip"
+// @Success		200					{object}	Bay
+// @Failure		400					{object}	map[string]interface{}
+// @Failure		404					{object}	map[string]interface{}
 // @Router			/v2/bays.json [get]
 func PkaSearchAPI(c *gin.Context) {
-	ctx := context.Background()
-	visitPlate := c.Query("visit.plate.text")
+	ctx := c.Request.Context()
+	languages := pkaLanguages(ctx, c)
 
-	if visitPlate == "" {
-		log.Error().Msg("No visit plate provided.")
+	query, err := parseBayQuery(c)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid PKA bays query")
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "No visit plate provided.",
+			"error":   err.Error(),
 			"success": false,
-			"code":    -4,
+			"code":    -5,
 		})
 		return
 	}
 
-	// Fetch data
-	car, err := db.GetPresentCarByLPN(ctx, visitPlate)
+	zones, err := db.GetZoneData(ctx)
 	if err != nil {
-		log.Warn().Str("Error : ", err.Error()).Str("license_plate", visitPlate).Msg("Error retrieving car by LPN")
-		c.JSON(http.StatusNotFound, gin.H{
+		log.Err(err).Msg("Error retrieving zones for PKA bays")
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"code":    -4,
-			"message": "No data found !",
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
 		})
 		return
 	}
 
-	log.Info().Str("License Plate", visitPlate).Msg("Car found with license plate")
-	log.Debug().Int("zone", *car.CurrZoneID).Msg("Last Zone ID")
-	//log.Debug().Interface("zone", car).Msg("Present Data")
+	maps, err := loadZoneMaps(ctx, languages)
+	if err != nil {
+		log.Err(err).Msg("Error retrieving map pictures for PKA bays")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
 
-	spotID := *car.CurrZoneID
-	visitPlate = car.LPN
-	language := "en"
+	zoneByID := make(map[int]db.Zone, len(zones))
+	for _, zone := range zones {
+		if !zone.IsDeleted {
+			zoneByID[zone.ZoneID] = zone
+		}
+	}
 
-	log.Debug().Str("Spot ID", fmt.Sprint(spotID)).Str("License Plate", visitPlate).Msg("Zone Found")
+	// The zones are filtered in the query, so that the limit and offset apply
+	// to the bays returned
+	filter := db.PresentCarFilter{VisitID: query.visitID, LPN: query.plate, ZoneIDs: []int{}, Limit: query.limit, Offset: query.offset}
+	for zoneID, zone := range zoneByID {
+		if query.filterZone && !query.matchesZone(zone, maps) {
+			continue
+		}
+		if query.flag("is_out_of_service", !zone.IsEnabled) {
+			filter.ZoneIDs = append(filter.ZoneIDs, zoneID)
+		}
+	}
 
-	// Retrieve the zone image by ID and language
-	zoneImage, err := db.GetZoneImageByZONEIDLang(ctx, spotID, language)
-	if err != nil {
-		log.Warn().Str("Error : ", err.Error()).Str("Language Provided", language).Int("Car Detail ID", *car.CarDetailsID).Msg("Error retrieving zone image")
-		c.JSON(http.StatusOK, []third_party.CarLocation{})
+	// Only occupied bays are known, free bays are never reported
+	var cars []db.PresentCar
+	if query.flag("is_occupied", true) && query.flag("is_reserved", false) && query.flag("is_in_violation", false) {
+		cars, err = db.GetPresentCarsByFilter(ctx, filter)
+		if err != nil {
+			log.Err(err).Msg("Error retrieving present cars for PKA bays")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"code":    -500,
+				"message": "An unexpected error occurred. Please try again later.",
+			})
+			return
+		}
+	}
+
+	bays := []Bay{}
+	for _, car := range cars {
+		if car.CurrZoneID == nil {
+			continue
+		}
+		zone, ok := zoneByID[*car.CurrZoneID]
+		if !ok || !query.flag("is_out_of_service", !zone.IsEnabled) {
+			continue
+		}
+		bay := newBay(car, zone, maps.pictures[zone.ZoneID], languages)
+		// The plates are only given to whom already knows the plate or visit
+		if !query.singleBay {
+			bay.Visit.ID = 0
+			bay.Visit.Plate = BayPlate{}
+		}
+		bays = append(bays, bay)
+	}
+
+	if !query.singleBay {
+		log.Info().Int("Bays", len(bays)).Msg("Data Found in PKA API")
+		c.JSON(http.StatusOK, bays)
 		return
 	}
 
-	// Get Zone name
-	zoneData, err := db.GetZoneByID(ctx, spotID)
-	if err != nil {
-		log.Warn().Str("Error: ", err.Error()).Int("Zone ID", *car.CurrZoneID).Msg("Error retrieving zone")
+	if len(bays) == 0 {
+		log.Warn().Str("license_plate", query.plate).Msg("No present car found in PKA API")
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
 			"code":    -4,
@@ -74,57 +194,162 @@ func PkaSearchAPI(c *gin.Context) {
 		return
 	}
 
-	// Debug log for zone names
-	log.Debug().Interface("Zone Names", zoneData.Name)
+	log.Info().Str("License Plate", bays[0].Visit.Plate.Text).Msg("Data Found in PKA API")
+	log.Debug().Interface("Data Found in PKA API", bays[0]).Send()
+	c.JSON(http.StatusOK, bays[0])
+}
+
+func parseBayQuery(c *gin.Context) (*bayQuery, error) {
+	query := &bayQuery{plate: c.Query("visit.plate.text"), flags: map[string]bool{}, limit: defaultBayLimit}
 
-	if zoneName, ok := zoneData.Name[language]; ok {
-		log.Info().Str("Language", language).Interface("Name", zoneName).Interface("Name", zoneData.Name[language]).Msg("Zone Name")
-	} else {
-		log.Warn().Msg("Zone name not found for the specified language")
+	if value := c.Query("visit.id"); value != "" {
+		visitID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("visit.id must be a valid integer")
+		}
+		query.visitID = &visitID
 	}
 
-	log.Debug().Str("Found Picture for Car with license plate", visitPlate).Str("Picture Name", fmt.Sprint(zoneImage.ID))
+	ints := map[string]**int{"id": &query.bayID, "map.id": &query.mapID, "zone.id": &query.zoneID}
+	for name, target := range ints {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a valid integer", name)
+		}
+		*target = &parsed
+	}
 
-	// Prepare the successful response
+	for _, name := range []string{"is_occupied", "is_out_of_service", "is_reserved", "is_in_violation"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", name)
+		}
+		query.flags[name] = parsed
+	}
 
-	response := third_party.CarLocation{
-		ZoneName:     fmt.Sprint(zoneData.Name[language]),
-		LicensePlate: visitPlate,
-		SpotID:       fmt.Sprint(spotID),
-		PictureName:  fmt.Sprint(zoneImage.ID),
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("limit must be a positive integer")
+		}
+		query.limit = min(limit, maxBayLimit)
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("offset must be a positive integer")
+		}
+		query.offset = offset
 	}
 
-	log.Info().Str("License Plate", visitPlate).Msg("Data Found in PKA API")
-	log.Debug().Interface("Data Found in PKA API", response).Send()
+	// A plate or a visit designates one car, kiosks expect a single bay
+	query.singleBay = query.plate != "" || query.visitID != nil
+	query.filterZone = query.bayID != nil || query.mapID != nil || query.zoneID != nil
+	return query, nil
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":                24,
-		"is_in_violation":   false,
-		"is_occupied":       true,
-		"is_out_of_service": false,
-		"is_reserved":       false,
-		"map": gin.H{
-			"id":   zoneData.ZoneID,         // to change
-			"name": zoneData.Name[language], // to change
-		},
-		"position": gin.H{
-			"x": 340.025390625,
-			"y": 215.331237792969,
-		},
-		"visit": gin.H{
-			"dwell":           "15:29:08.3940000",
-			"entry_timestamp": car.TransactionDate, // to change
-			"id":              5260304,
-			"plate": gin.H{
-				"confidence": 99,                  // to change
-				"text":       visitPlate,          // to change
-				"timestamp":  car.TransactionDate, // to change
+// flag reports whether value passes the boolean filter name, if given.
+func (q *bayQuery) flag(name string, value bool) bool {
+	expected, ok := q.flags[name]
+	return !ok || expected == value
+}
+
+func (q *bayQuery) matchesZone(zone db.Zone, maps *zoneMaps) bool {
+	if q.bayID != nil && *q.bayID != bayID(zone) {
+		return false
+	}
+	// A map is a picture of the zone, in any language
+	if q.mapID != nil && maps.zones[*q.mapID] != zone.ZoneID {
+		return false
+	}
+	return q.zoneID == nil || *q.zoneID == zone.ZoneID
+}
+
+func bayID(zone db.Zone) int {
+	if zone.PkaBayID != nil {
+		return *zone.PkaBayID
+	}
+	return zone.ZoneID
+}
+
+func newBay(car db.PresentCar, zone db.Zone, mapID int, languages []string) Bay {
+	name := localizedName(zone.Name, languages)
+
+	entry := car.EntryDate
+	if entry == "" {
+		entry = car.TransactionDate
+	}
+
+	bay := Bay{
+		ID:             bayID(zone),
+		IsOccupied:     true,
+		IsOutOfService: !zone.IsEnabled,
+		Map:            BayRef{ID: mapID, Name: name},
+		Zone:           BayRef{ID: zone.ZoneID, Name: name},
+		Visit: BayVisit{
+			EntryTimestamp: pkaTimestamp(entry),
+			Plate: BayPlate{
+				Text:      car.LPN,
+				Timestamp: pkaTimestamp(car.TransactionDate),
 			},
 		},
-		"zone": gin.H{
-			"id":   zoneData.ZoneID,         // to change
-			"name": zoneData.Name[language], // to change
-		},
-	})
+	}
+
+	bay.Visit.ID = car.VisitID
+	if car.Confidence != nil {
+		bay.Visit.Plate.Confidence = *car.Confidence
+	}
+	if zone.PkaX != nil {
+		bay.Position.X = *zone.PkaX
+	}
+	if zone.PkaY != nil {
+		bay.Position.Y = *zone.PkaY
+	}
+
+	if entryTime, err := config.FormatDate(entry); err == nil {
+		bay.Visit.Dwell = formatDwell(time.Now().UTC().Sub(entryTime))
+	} else {
+		bay.Visit.Dwell = formatDwell(0)
+	}
+
+	return bay
+}
+
+// pkaTimestamp converts a stored UTC time to ISO 8601.
+func pkaTimestamp(value string) string {
+	t, err := config.FormatDate(value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format(time.RFC3339)
+}
 
+// formatDwell formats d as a .NET TimeSpan, as returned by PKA.
+func formatDwell(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+
+	days := int(d / (24 * time.Hour))
+	d -= time.Duration(days) * 24 * time.Hour
+	hours := int(d / time.Hour)
+	d -= time.Duration(hours) * time.Hour
+	minutes := int(d / time.Minute)
+	d -= time.Duration(minutes) * time.Minute
+	seconds := int(d / time.Second)
+	ticks := int((d - time.Duration(seconds)*time.Second) / 100)
+
+	dwell := fmt.Sprintf("%02d:%02d:%02d.%07d", hours, minutes, seconds, ticks)
+	if days > 0 {
+		dwell = fmt.Sprintf("%d.%s", days, dwell)
+	}
+	return dwell
 }
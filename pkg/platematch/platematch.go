@@ -0,0 +1,185 @@
+// Package platematch scores how likely two license plate reads are the same
+// plate, taking into account the usual ANPR misreads.
+package platematch
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// Cost of substituting two characters that OCR commonly confuses
+	confusionCost = 0.3
+	// Cost of a character missing from one of the reads
+	missingCost    = 0.8
+	substituteCost = 1.0
+)
+
+// Arabic letters used on plates and their official Latin equivalents
+var arabicToLatin = map[rune]rune{
+	'ا': 'A', 'أ': 'A', 'إ': 'A', 'آ': 'A',
+	'ب': 'B',
+	'ح': 'J',
+	'د': 'D',
+	'ر': 'R',
+	'س': 'S',
+	'ص': 'X',
+	'ط': 'T',
+	'ع': 'E',
+	'ق': 'G',
+	'ك': 'K',
+	'ل': 'L',
+	'م': 'Z',
+	'ن': 'N',
+	'ه': 'H', 'ة': 'H',
+	'و': 'U',
+	'ى': 'V', 'ي': 'V',
+}
+
+// Characters OCR engines confuse, mapped to a representative of their class
+var confusionClass = map[rune]rune{
+	'O': '0', 'Q': '0', 'D': '0',
+	'B': '8',
+	'I': '1', 'L': '1',
+	'S': '5',
+	'Z': '2',
+	'G': '6',
+}
+
+// Normalize upper-cases the plate, drops separators and maps Arabic letters and
+// Arabic-Indic digits to their Latin equivalents.
+func Normalize(lpn string) string {
+	var b strings.Builder
+	b.Grow(len(lpn))
+
+	for _, r := range lpn {
+		switch {
+		case r >= '٠' && r <= '٩':
+			b.WriteRune('0' + (r - '٠'))
+		case r >= '۰' && r <= '۹':
+			b.WriteRune('0' + (r - '۰'))
+		case arabicToLatin[r] != 0:
+			b.WriteRune(arabicToLatin[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+
+	return b.String()
+}
+
+func class(r rune) rune {
+	if c, ok := confusionClass[r]; ok {
+		return c
+	}
+	return r
+}
+
+func cost(a, b rune) float64 {
+	if a == b {
+		return 0
+	}
+	if class(a) == class(b) {
+		return confusionCost
+	}
+	return substituteCost
+}
+
+// Distance is a weighted edit distance between two normalized plates. The
+// computation stops as soon as the distance exceeds max, in which case a value
+// greater than max is returned.
+func Distance(a, b string, max float64) float64 {
+	ra, rb := []rune(a), []rune(b)
+
+	lengthDiff := len(ra) - len(rb)
+	if lengthDiff < 0 {
+		lengthDiff = -lengthDiff
+	}
+	if float64(lengthDiff)*missingCost > max {
+		return max + 1
+	}
+
+	prev := make([]float64, len(rb)+1)
+	curr := make([]float64, len(rb)+1)
+	for j := range prev {
+		prev[j] = float64(j) * missingCost
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = float64(i) * missingCost
+		rowMin := curr[0]
+
+		for j := 1; j <= len(rb); j++ {
+			d := prev[j-1] + cost(ra[i-1], rb[j-1])
+			if v := prev[j] + missingCost; v < d {
+				d = v
+			}
+			if v := curr[j-1] + missingCost; v < d {
+				d = v
+			}
+			curr[j] = d
+			if d < rowMin {
+				rowMin = d
+			}
+		}
+
+		if rowMin > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// Score returns the similarity of two plates between 0 and 1, 1 meaning the
+// normalized plates are identical. Scores below minScore are reported as 0.
+func Score(query, candidate string, minScore float64) float64 {
+	a, b := Normalize(query), Normalize(candidate)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	longest := len([]rune(a))
+	if n := len([]rune(b)); n > longest {
+		longest = n
+	}
+
+	maxDistance := (1 - minScore) * float64(longest)
+	distance := Distance(a, b, maxDistance)
+	if distance > maxDistance {
+		return 0
+	}
+
+	return 1 - distance/float64(longest)
+}
+
+// Mask hides the characters of the normalized plate except the part matching
+// the normalized partial plate and the last character, e.g. "**34*7".
+func Mask(lpn string, partial string) string {
+	plate := []rune(Normalize(lpn))
+	if len(plate) == 0 {
+		return ""
+	}
+
+	start, end := -1, -1
+	if query := Normalize(partial); query != "" {
+		if i := strings.Index(string(plate), query); i >= 0 {
+			start = len([]rune(string(plate)[:i]))
+			end = start + len([]rune(query))
+		}
+	}
+
+	masked := make([]rune, len(plate))
+	for i, r := range plate {
+		if (i >= start && i < end) || i == len(plate)-1 {
+			masked[i] = r
+		} else {
+			masked[i] = '*'
+		}
+	}
+	return string(masked)
+}
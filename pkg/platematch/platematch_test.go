@@ -0,0 +1,115 @@
+package platematch
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		lpn  string
+		want string
+	}{
+		{"latin", "ABC123", "ABC123"},
+		{"lowercase", "abc123", "ABC123"},
+		{"separators", " AB-12.3 4/5 ", "AB12345"},
+		{"arabic letters", "12345 ب 6", "12345B6"},
+		{"arabic alef forms", "ا أ إ آ", "AAAA"},
+		{"arabic ha and ta marbuta", "هة", "HH"},
+		{"arabic ya forms", "ىي", "VV"},
+		{"arabic-indic digits", "١٢٣٤٥٦٧٨٩٠", "1234567890"},
+		{"persian digits", "۱۲۳۴۵۶۷۸۹۰", "1234567890"},
+		{"mixed arabic plate", "٣٤٥ - د - ٦٧", "345D67"},
+		{"empty", "", ""},
+		{"separators only", " - . ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.lpn); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.lpn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	const minScore = 0.7
+
+	tests := []struct {
+		name      string
+		query     string
+		candidate string
+		want      float64
+	}{
+		{"identical", "ABC123", "ABC123", 1},
+		{"identical after normalization", "ab-c 123", "ABC123", 1},
+		{"arabic and latin", "12345 ب 6", "12345B6", 1},
+		{"arabic-indic digits", "AB ١٢٣", "AB123", 1},
+		{"confusion B and 8", "ABC123", "A8C123", 1 - 0.3/6},
+		{"confusion O and 0", "AO1234", "A01234", 1 - 0.3/6},
+		{"confusion I and 1", "AB1234", "ABI234", 1 - 0.3/6},
+		{"confusion S and 5", "AS1234", "A51234", 1 - 0.3/6},
+		{"two confusions", "AB1234", "A81Z34", 1 - 0.6/6},
+		{"missing character", "ABC123", "ABC12", 1 - 0.8/6},
+		{"substitution", "ABC123", "ABC124", 1 - 1.0/6},
+		{"different plates", "ABC123", "XYZ789", 0},
+		{"below minimum score", "ABC123", "ABX789", 0},
+		{"empty query", "", "ABC123", 0},
+		{"empty candidate", "ABC123", " - ", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.query, tt.candidate, minScore)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score(%q, %q) = %v, want %v", tt.query, tt.candidate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreConfusionRanksAboveSubstitution(t *testing.T) {
+	confused := Score("ABC123", "A8C123", 0)
+	substituted := Score("ABC123", "AXC123", 0)
+	if confused <= substituted {
+		t.Errorf("confusion score %v not above substitution score %v", confused, substituted)
+	}
+}
+
+func TestDistanceStopsAboveMax(t *testing.T) {
+	if got := Distance("ABC123", "XYZ789", 1); got <= 1 {
+		t.Errorf("Distance = %v, want more than 1", got)
+	}
+	if got := Distance("ABCDEFGH", "AB", 2); got <= 2 {
+		t.Errorf("Distance with length difference = %v, want more than 2", got)
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name    string
+		lpn     string
+		partial string
+		want    string
+	}{
+		{"match in the middle", "AB-1234", "23", "***234"},
+		{"match at the start", "AB1234", "ab", "AB***4"},
+		{"match at the end", "AB1234", "34", "****34"},
+		{"no match", "AB1234", "XY", "*****4"},
+		{"empty partial", "AB1234", "", "*****4"},
+		{"arabic plate", "12 ب 34", "ب3", "**B34"},
+		{"arabic partial", "12B34", "ب", "**B*4"},
+		{"arabic-indic partial", "AB1234", "٢٣", "***234"},
+		{"empty plate", "", "12", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Mask(tt.lpn, tt.partial); got != tt.want {
+				t.Errorf("Mask(%q, %q) = %q, want %q", tt.lpn, tt.partial, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// localCounters count the requests in the process while Valkey is unavailable,
+// so that the limits still hold for each instance.
+type localCounters struct {
+	mu       sync.Mutex
+	counters map[string]localCounter
+	pruned   time.Time
+}
+
+type localCounter struct {
+	count   int64
+	expires time.Time
+}
+
+var local = &localCounters{counters: map[string]localCounter{}}
+
+// incr counts a request on key, the counter expiring ttl after its last
+// request, its expiry being renewed on each increment like the EXPIRE of the
+// Valkey ones.
+func (l *localCounters) incr(key string, ttl time.Duration, now time.Time) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.pruned) > time.Minute {
+		for k, counter := range l.counters {
+			if !now.Before(counter.expires) {
+				delete(l.counters, k)
+			}
+		}
+		l.pruned = now
+	}
+
+	counter, ok := l.counters[key]
+	if !ok || !now.Before(counter.expires) {
+		counter = localCounter{}
+	}
+	counter.count++
+	counter.expires = now.Add(ttl)
+	l.counters[key] = counter
+	return counter.count
+}
+
+// lock sets key for the duration, as the lock of a throttled key.
+func (l *localCounters) lock(key string, duration time.Duration, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.counters[key] = localCounter{count: 1, expires: now.Add(duration)}
+}
+
+// ttl returns the time left before key expires, 0 when it is not set.
+func (l *localCounters) ttl(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	counter, ok := l.counters[key]
+	if !ok || !now.Before(counter.expires) {
+		return 0
+	}
+	return counter.expires.Sub(now)
+}
+
+// decr gives back a request counted on key.
+func (l *localCounters) decr(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if counter, ok := l.counters[key]; ok && counter.count > 0 {
+		counter.count--
+		l.counters[key] = counter
+	}
+}
+
+func (l *localCounters) delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		delete(l.counters, key)
+	}
+}
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/valkey"
+)
+
+const (
+	keyPrefix = "fyc:ratelimit"
+
+	// Daily counters are kept for the usage report of the backoffice
+	dailyRetention = 35 * 24 * time.Hour
+)
+
+// Decision is the outcome of a rate limit check. RetryAfter is set when the
+// request is rejected.
+type Decision struct {
+	Allowed bool
+	// Unavailable is set when the request is rejected because it could not
+	// be counted, rather than for exceeding a limit
+	Unavailable bool
+	Reason      string
+	RetryAfter  time.Duration
+	DailyCount  int64
+	Remaining   int64
+}
+
+type DailyUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+	Rejected int64  `json:"rejected"`
+}
+
+func secondKey(clientID string, now time.Time) string {
+	return fmt.Sprintf("%s:%s:rps:%d", keyPrefix, clientID, now.Unix())
+}
+
+func dailyKey(clientID string, day string) string {
+	return fmt.Sprintf("%s:%s:day:%s", keyPrefix, clientID, day)
+}
+
+func rejectedKey(clientID string, day string) string {
+	return fmt.Sprintf("%s:%s:rejected:%s", keyPrefix, clientID, day)
+}
+
+// Allow counts the request of the client and checks it against its requests per
+// second limit and daily quota, 0 or less meaning unlimited. Counters are shared
+// through Valkey; when Valkey is unavailable the request is rejected in the
+// fail-closed mode, and otherwise counted in the process.
+func Allow(ctx context.Context, clientID string, perSecond int, dailyQuota int) Decision {
+	now := time.Now().UTC()
+	day := now.Format(time.DateOnly)
+
+	if perSecond > 0 {
+		count, err := incr(ctx, clientID, secondKey(clientID, now), 2*time.Second, now)
+		if err != nil {
+			return unavailable()
+		}
+
+		if count > int64(perSecond) {
+			reject(ctx, clientID, day)
+			return Decision{
+				Allowed:    false,
+				Reason:     "rate limit exceeded",
+				RetryAfter: now.Truncate(time.Second).Add(time.Second).Sub(now),
+			}
+		}
+	}
+
+	count, err := incr(ctx, clientID, dailyKey(clientID, day), dailyRetention, now)
+	if err != nil {
+		return unavailable()
+	}
+
+	if dailyQuota <= 0 {
+		return Decision{Allowed: true, DailyCount: count, Remaining: -1}
+	}
+
+	if count > int64(dailyQuota) {
+		reject(ctx, clientID, day)
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return Decision{
+			Allowed:    false,
+			Reason:     "daily quota exceeded",
+			RetryAfter: midnight.Sub(now),
+			DailyCount: count,
+		}
+	}
+
+	return Decision{Allowed: true, DailyCount: count, Remaining: int64(dailyQuota) - count}
+}
+
+// incr counts the request on key in Valkey, falling back to the process
+// counters when Valkey is unavailable unless the limiter fails closed, in which
+// case the error is returned.
+func incr(ctx context.Context, clientID string, key string, ttl time.Duration, now time.Time) (int64, error) {
+	count, err := valkey.Valkey_GlobalVar.IncrWithExpiry(ctx, key, ttl)
+	if err == nil {
+		return count, nil
+	}
+
+	if config.Configvar.RateLimit.FailClosed {
+		log.Error().Err(err).Str("Client ID", clientID).Msg("Rate limit unavailable, request rejected")
+		return 0, err
+	}
+	log.Warn().Err(err).Str("Client ID", clientID).Msg("Rate limit counted in process")
+	return local.incr(key, ttl, now), nil
+}
+
+// unavailable rejects a request that could not be counted.
+func unavailable() Decision {
+	return Decision{
+		Allowed:     false,
+		Unavailable: true,
+		Reason:      "rate limit unavailable",
+		RetryAfter:  time.Second,
+	}
+}
+
+func reject(ctx context.Context, clientID string, day string) {
+	if _, err := valkey.Valkey_GlobalVar.IncrWithExpiry(ctx, rejectedKey(clientID, day), dailyRetention); err != nil {
+		log.Warn().Err(err).Str("Client ID", clientID).Msg("Error counting rejected request")
+	}
+}
+
+// Usage returns the daily requests and rejections of the client over the last
+// days, most recent first.
+func Usage(ctx context.Context, clientID string, days int) ([]DailyUsage, error) {
+	now := time.Now().UTC()
+
+	keys := make([]string, 0, days*2)
+	usage := make([]DailyUsage, days)
+	for i := 0; i < days; i++ {
+		day := now.AddDate(0, 0, -i).Format(time.DateOnly)
+		usage[i].Date = day
+		keys = append(keys, dailyKey(clientID, day), rejectedKey(clientID, day))
+	}
+
+	counters, err := valkey.Valkey_GlobalVar.GetCounters(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range usage {
+		usage[i].Requests = counters[i*2]
+		usage[i].Rejected = counters[i*2+1]
+	}
+
+	return usage, nil
+}
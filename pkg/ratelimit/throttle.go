@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/valkey"
+)
+
+func attemptsKey(key string) string {
+	return fmt.Sprintf("%s:throttle:%s", keyPrefix, key)
+}
+
+func lockKey(key string) string {
+	return fmt.Sprintf("%s:lock:%s", keyPrefix, key)
+}
+
+// Locked reports whether the key is locked out, with the remaining lockout
+// time as RetryAfter. Locks set in the process while Valkey was unavailable
+// are honoured as well.
+func Locked(ctx context.Context, key string) Decision {
+	ttl, err := valkey.Valkey_GlobalVar.TTL(ctx, lockKey(key))
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Lockout checked in process")
+	}
+	if localTTL := local.ttl(lockKey(key), time.Now()); localTTL > ttl {
+		ttl = localTTL
+	}
+
+	if ttl > 0 {
+		return Decision{Allowed: false, Reason: "too many attempts", RetryAfter: ttl}
+	}
+	return Decision{Allowed: true}
+}
+
+// Throttle counts an attempt on key. Once more than limit attempts are counted
+// within window the key is locked out for the lockout duration, and every
+// attempt is rejected until the lock expires. A limit of 0 only checks the lock.
+// Attempts are counted before being made, so that concurrent ones cannot exceed
+// the limit. When Valkey is unavailable the attempts and the lock are kept in
+// the process, so that the lockout still holds for each instance.
+func Throttle(ctx context.Context, key string, limit int, window time.Duration, lockout time.Duration) Decision {
+	if decision := Locked(ctx, key); !decision.Allowed || limit <= 0 {
+		return decision
+	}
+
+	now := time.Now()
+	count, err := valkey.Valkey_GlobalVar.IncrWithExpiry(ctx, attemptsKey(key), window)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Attempt counted in process")
+		count = local.incr(attemptsKey(key), window, now)
+	}
+
+	if count > int64(limit) {
+		Lock(ctx, key, lockout)
+		// Attempts start from zero again once the lock expires
+		local.delete(attemptsKey(key))
+		if _, err := valkey.Valkey_GlobalVar.Delete(ctx, attemptsKey(key)); err != nil {
+			log.Warn().Err(err).Str("key", key).Msg("Error resetting attempts")
+		}
+		log.Warn().Str("key", key).Int64("attempts", count).Dur("lockout", lockout).Msg("Too many attempts, locked out")
+		return Decision{Allowed: false, Reason: "too many attempts", RetryAfter: lockout}
+	}
+
+	// A concurrent attempt may have locked the key and cleared the attempts
+	// between the lock check and the count
+	if decision := Locked(ctx, key); !decision.Allowed {
+		return decision
+	}
+
+	return Decision{Allowed: true, Remaining: int64(limit) - count}
+}
+
+// Cap counts an attempt on key and rejects those beyond limit until the end of
+// the current window, without locking the key out, so that a shared key
+// cannot be kept locked by a single abuser. Windows are fixed, starting on a
+// multiple of window. A limit of 0 leaves the key uncapped. When Valkey is
+// unavailable the attempts are counted in the process.
+func Cap(ctx context.Context, key string, limit int, window time.Duration) Decision {
+	if limit <= 0 {
+		return Decision{Allowed: true}
+	}
+
+	now := time.Now()
+	start := now.Truncate(window)
+	capKey := fmt.Sprintf("%s:cap:%s:%d", keyPrefix, key, start.Unix())
+	count, err := valkey.Valkey_GlobalVar.IncrWithExpiry(ctx, capKey, window)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Attempt counted in process")
+		count = local.incr(capKey, window, now)
+	}
+
+	if count > int64(limit) {
+		return Decision{Allowed: false, Reason: "too many requests", RetryAfter: start.Add(window).Sub(now)}
+	}
+	return Decision{Allowed: true, Remaining: int64(limit) - count}
+}
+
+// Lock locks the key out for the duration, whatever its attempts, in the
+// process when Valkey is unavailable.
+func Lock(ctx context.Context, key string, duration time.Duration) {
+	if err := valkey.Valkey_GlobalVar.SetWithExpiry(ctx, lockKey(key), "1", duration); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Key locked out in process")
+		local.lock(lockKey(key), duration, time.Now())
+	}
+}
+
+// Release gives back an attempt counted on key, for an attempt that turned out
+// to be legitimate.
+func Release(ctx context.Context, key string) error {
+	local.decr(attemptsKey(key))
+	if _, err := valkey.Valkey_GlobalVar.DecrIfPositive(ctx, attemptsKey(key)); err != nil {
+		return fmt.Errorf("error releasing attempt of %s: %w", key, err)
+	}
+	return nil
+}
+
+// Attempts returns the attempts counted on key within the current window.
+func Attempts(ctx context.Context, key string) (int64, error) {
+	counters, err := valkey.Valkey_GlobalVar.GetCounters(ctx, attemptsKey(key))
+	if err != nil {
+		return 0, err
+	}
+	return counters[0], nil
+}
+
+// Reset clears the attempts and the lock of key. It reports whether the key
+// was locked out.
+func Reset(ctx context.Context, key string) (bool, error) {
+	locked := !Locked(ctx, key).Allowed
+
+	local.delete(attemptsKey(key), lockKey(key))
+	if _, err := valkey.Valkey_GlobalVar.Delete(ctx, attemptsKey(key), lockKey(key)); err != nil {
+		return false, fmt.Errorf("error resetting %s: %w", key, err)
+	}
+	return locked, nil
+}
@@ -0,0 +1,201 @@
+// Package rbac maps the roles of backoffice users to the permissions they
+// grant, each permission allowing an action on a module.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/functions"
+	"fyc/pkg/db"
+)
+
+// Backoffice modules
+const (
+	ModuleDashboard   = "dashboard"
+	ModuleZones       = "zones"
+	ModuleCameras     = "cameras"
+	ModuleSigns       = "signs"
+	ModuleClients     = "clients"
+	ModuleWebhooks    = "webhooks"
+	ModuleSettings    = "settings"
+	ModulePresentCars = "present_cars"
+	ModuleUsers       = "users"
+	ModuleRoles       = "roles"
+	ModuleAudit       = "audit"
+	ModuleDebug       = "debug"
+)
+
+// Actions on a module
+const (
+	ActionView   = "view"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionExport = "export"
+)
+
+var Modules = []string{
+	ModuleDashboard, ModuleZones, ModuleCameras, ModuleSigns, ModuleClients, ModuleWebhooks,
+	ModuleSettings, ModulePresentCars, ModuleUsers, ModuleRoles, ModuleAudit, ModuleDebug,
+}
+
+var Actions = []string{ActionView, ActionCreate, ActionUpdate, ActionDelete, ActionExport}
+
+// Default roles, Super-Admin being the role of the seeded admin user
+const (
+	RoleViewer     = "Viewer"
+	RoleOperator   = "Operator"
+	RoleAdmin      = "Admin"
+	RoleSuperAdmin = "Super-Admin"
+)
+
+var (
+	rolesMu   sync.RWMutex
+	roles     = map[string][]string{}
+	twoFactor = map[string]bool{}
+)
+
+func Permission(module string, action string) string {
+	return module + ":" + action
+}
+
+// AllPermissions returns every module:action pair.
+func AllPermissions() []string {
+	permissions := make([]string, 0, len(Modules)*len(Actions))
+	for _, module := range Modules {
+		for _, action := range Actions {
+			permissions = append(permissions, Permission(module, action))
+		}
+	}
+	return permissions
+}
+
+// ValidatePermissions checks that every permission is a known module:action.
+func ValidatePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		module, action, ok := strings.Cut(permission, ":")
+		if !ok || !functions.ContainsStr(Modules, module) || !functions.ContainsStr(Actions, action) {
+			return fmt.Errorf("invalid permission %s, expected module:action with module in %v and action in %v", permission, Modules, Actions)
+		}
+	}
+	return nil
+}
+
+// defaultRoles returns the system roles seeded at startup.
+func defaultRoles() []db.Role {
+	viewer := []string{
+		Permission(ModuleDashboard, ActionView),
+		Permission(ModuleZones, ActionView),
+		Permission(ModuleCameras, ActionView),
+		Permission(ModuleSigns, ActionView),
+		Permission(ModulePresentCars, ActionView),
+		Permission(ModuleSettings, ActionView),
+	}
+
+	operator := append([]string{}, viewer...)
+	operator = append(operator,
+		Permission(ModuleZones, ActionUpdate),
+		Permission(ModuleZones, ActionExport),
+		Permission(ModuleCameras, ActionUpdate),
+		Permission(ModuleCameras, ActionExport),
+		Permission(ModuleSigns, ActionUpdate),
+		Permission(ModuleSigns, ActionExport),
+		Permission(ModulePresentCars, ActionExport),
+		Permission(ModuleClients, ActionView),
+		Permission(ModuleWebhooks, ActionView),
+	)
+
+	return []db.Role{
+		{Name: RoleViewer, Description: "Read-only access to the car park", Permissions: viewer},
+		{Name: RoleOperator, Description: "Day to day operation of zones, cameras and signs", Permissions: operator},
+		{Name: RoleAdmin, Description: "Full access", Permissions: AllPermissions()},
+		{Name: RoleSuperAdmin, Description: "Full access, role of the default admin user", Permissions: AllPermissions()},
+	}
+}
+
+// Seed stores the system roles, overwriting their permissions so that they
+// follow the modules added over time.
+func Seed(ctx context.Context) error {
+	for _, role := range defaultRoles() {
+		if err := db.SaveSystemRole(ctx, &role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load caches the permissions of every role, to be called after each change.
+func Load(ctx context.Context) error {
+	stored, err := db.GetRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string][]string, len(stored))
+	loadedTwoFactor := make(map[string]bool, len(stored))
+	for _, role := range stored {
+		loaded[role.Name] = role.Permissions
+		loadedTwoFactor[role.Name] = role.RequireTwoFactor
+	}
+
+	rolesMu.Lock()
+	roles = loaded
+	twoFactor = loadedTwoFactor
+	rolesMu.Unlock()
+
+	log.Debug().Int("Roles", len(loaded)).Msg("Role permissions loaded")
+	return nil
+}
+
+// RoleExists reports whether the role is known.
+func RoleExists(role string) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+
+	_, ok := roles[role]
+	return ok
+}
+
+// Permissions returns the permissions granted by the role.
+func Permissions(role string) []string {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+
+	return append([]string{}, roles[role]...)
+}
+
+// Grants reports whether the role grants every one of the permissions, so that
+// its users may hand them out.
+func Grants(role string, permissions []string) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+
+	for _, permission := range permissions {
+		if !functions.ContainsStr(roles[role], permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// Allowed reports whether the role grants the action on the module.
+func Allowed(role string, module string, action string) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+
+	return functions.ContainsStr(roles[role], Permission(module, action))
+}
+
+// RequiresTwoFactor reports whether the users of the role must use two-factor
+// authentication.
+func RequiresTwoFactor(role string) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+
+	return twoFactor[role]
+}
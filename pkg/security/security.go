@@ -0,0 +1,110 @@
+// Package security records the requests rejected by the security checks and
+// keeps daily counters of them.
+package security
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/pkg/db"
+	"fyc/pkg/valkey"
+)
+
+const (
+	keyPrefix = "fyc:security"
+
+	// SourceCamera is the source of the events pushed on /cam
+	SourceCamera = "cam"
+
+	// Daily counters are kept for this long
+	counterRetention = 35 * 24 * time.Hour
+	// At most this many rejections of an IP are written to the security log
+	// per minute, the counters still counting all of them
+	logPerMinute = 10
+)
+
+// Reasons counted for every source
+var Reasons = []string{
+	db.SecurityUnknownIP,
+	db.SecurityIPMismatch,
+	db.SecurityUnknownCamera,
+	db.SecurityAuthMissing,
+	db.SecurityAuthFailed,
+	db.SecuritySignatureInvalid,
+	db.SecurityBodyTooLarge,
+}
+
+func counterKey(source string, reason string, day string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", keyPrefix, source, reason, day)
+}
+
+func floodKey(clientIP string, now time.Time) string {
+	return fmt.Sprintf("%s:flood:%s:%d", keyPrefix, clientIP, now.Unix()/60)
+}
+
+// Record counts the rejected request and writes it to the security log,
+// unless its client already had too many rejections written this minute.
+func Record(ctx context.Context, entry db.SecurityLog) {
+	now := time.Now().UTC()
+
+	log.Warn().Str("Source", entry.Source).Str("IP", entry.ClientIP).Str("Camera IP", entry.CameraIP).
+		Str("Reason", entry.Reason).Str("Detail", entry.Detail).Msg("Request rejected")
+
+	if _, err := valkey.Valkey_GlobalVar.IncrWithExpiry(ctx, counterKey(entry.Source, entry.Reason, now.Format("2006-01-02")), counterRetention); err != nil {
+		log.Warn().Err(err).Msg("Security counter skipped")
+	}
+
+	count, err := valkey.Valkey_GlobalVar.IncrWithExpiry(ctx, floodKey(entry.ClientIP, now), time.Minute)
+	if err != nil {
+		log.Warn().Err(err).Msg("Security log throttling skipped")
+	} else if count > logPerMinute {
+		return
+	}
+
+	if err := db.CreateSecurityLog(ctx, &entry); err != nil {
+		log.Err(err).Str("IP", entry.ClientIP).Msg("Error recording security log")
+	}
+}
+
+// DayCounters are the rejections of a day by reason
+type DayCounters struct {
+	Day     string           `json:"day"`
+	Total   int64            `json:"total"`
+	Reasons map[string]int64 `json:"reasons"`
+}
+
+// Counters returns the rejections of the source over the last days, today
+// first.
+func Counters(ctx context.Context, source string, days int) ([]DayCounters, error) {
+	now := time.Now().UTC()
+
+	keys := make([]string, 0, days*len(Reasons))
+	for d := 0; d < days; d++ {
+		day := now.AddDate(0, 0, -d).Format("2006-01-02")
+		for _, reason := range Reasons {
+			keys = append(keys, counterKey(source, reason, day))
+		}
+	}
+
+	values, err := valkey.Valkey_GlobalVar.GetCounters(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := make([]DayCounters, days)
+	for d := range counters {
+		counters[d] = DayCounters{
+			Day:     now.AddDate(0, 0, -d).Format("2006-01-02"),
+			Reasons: make(map[string]int64, len(Reasons)),
+		}
+		for r, reason := range Reasons {
+			value := values[d*len(Reasons)+r]
+			counters[d].Reasons[reason] = value
+			counters[d].Total += value
+		}
+	}
+	return counters, nil
+}
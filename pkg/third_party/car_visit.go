@@ -0,0 +1,122 @@
+package third_party
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/db"
+)
+
+// Maximum number of reads used to build the zone journey of a car
+const maxJourneyReads = 50
+
+// CarVisit holds the details of the current visit of a car. Each field is only
+// filled when enabled for the client.
+type CarVisit struct {
+	EntryTime    string        `json:"entry_time,omitempty"`
+	DwellSeconds *int64        `json:"dwell_seconds,omitempty"`
+	LastCameraID *int          `json:"last_camera_id,omitempty"`
+	LastCamera   string        `json:"last_camera,omitempty"`
+	LastSeen     string        `json:"last_seen,omitempty"`
+	Journey      []JourneyStep `json:"journey,omitempty"`
+}
+
+type JourneyStep struct {
+	ZoneID   int    `json:"zone_id"`
+	ZoneName string `json:"zone_name"`
+	Time     string `json:"time"`
+}
+
+// getCarVisit builds the visit details of the present car allowed for the client.
+func getCarVisit(ctx context.Context, clientID string, car db.PresentCar, language string) CarVisit {
+	var visit CarVisit
+
+	client, exists := db.GetClientDetails(clientID)
+	if !exists {
+		return visit
+	}
+
+	entry := car.EntryDate
+	if entry == "" {
+		entry = car.TransactionDate
+	}
+
+	if client.ShowEntry {
+		visit.EntryTime = entry
+	}
+
+	if client.ShowDwell {
+		if entryTime, err := config.FormatDate(entry); err == nil {
+			dwell := int64(time.Now().UTC().Sub(entryTime).Seconds())
+			if dwell < 0 {
+				dwell = 0
+			}
+			visit.DwellSeconds = &dwell
+		}
+	}
+
+	if client.ShowCamera {
+		cameraID := car.CameraID
+		visit.LastCameraID = &cameraID
+		visit.LastSeen = car.TransactionDate
+		if camera, err := db.GetCameraByID(ctx, car.CameraID); err == nil {
+			visit.LastCamera = camera.CamName
+		} else {
+			log.Warn().Err(err).Int("Camera ID", car.CameraID).Msg("Error retrieving last camera of car")
+		}
+	}
+
+	if client.ShowJourney {
+		journey, err := getCarJourney(ctx, car.LPN, entry, language)
+		if err != nil {
+			log.Warn().Err(err).Str("LPN", car.LPN).Msg("Error retrieving car journey")
+		}
+		visit.Journey = journey
+	}
+
+	return visit
+}
+
+// getCarJourney returns the zones the car went through since its entry, oldest
+// first, consecutive reads in the same zone being merged.
+func getCarJourney(ctx context.Context, lpn string, since string, language string) ([]JourneyStep, error) {
+	reads, err := db.GetPresentCarJourney(ctx, lpn, since, maxJourneyReads)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneNames := make(map[int]string)
+	var journey []JourneyStep
+	for _, read := range reads {
+		if read.CurrZoneID == nil {
+			continue
+		}
+
+		zoneID := *read.CurrZoneID
+		if len(journey) > 0 && journey[len(journey)-1].ZoneID == zoneID {
+			continue
+		}
+
+		name, ok := zoneNames[zoneID]
+		if !ok {
+			if zone, err := db.GetZoneByID(ctx, zoneID); err == nil {
+				if zoneName, found := zone.Name[language]; found {
+					name = fmt.Sprint(zoneName)
+				}
+			}
+			zoneNames[zoneID] = name
+		}
+
+		journey = append(journey, JourneyStep{
+			ZoneID:   zoneID,
+			ZoneName: name,
+			Time:     read.TransactionDate,
+		})
+	}
+
+	return journey, nil
+}
@@ -0,0 +1,21 @@
+package third_party
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"fyc/pkg/apierror"
+)
+
+// GetErrorCatalog godoc
+//
+//	@Summary		List the v2 error codes
+//	@Description	List the stable error codes of the v2 API with their key, HTTP status and localized messages. Error responses of the v2 API have the form {"success": false, "error": {"code", "key", "message", "detail"}}, the message being localized from the language parameter or the Accept-Language header.
+//	@Tags			Third Party
+//	@Produce		json
+//	@Success		200	{object}	apierror.Response{data=[]apierror.CatalogEntry}
+//	@Router			/v2/errors [get]
+func GetErrorCatalog(c *gin.Context) {
+	apierror.Respond(c, http.StatusOK, apierror.Catalog())
+}
@@ -1,21 +1,82 @@
 package third_party
 
 import (
+	"context"
 	"fmt"
+	"fyc/config"
+	"fyc/middleware"
 	"fyc/pkg/db"
+	"fyc/pkg/platematch"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
-func isClientExist(clientList map[string]db.ClientDetails, clientID string) (bool, *db.ClientDetails) {
-	if clientData, exists := clientList[clientID]; exists {
+func isClientExist(clientID string) (bool, *db.ClientDetails) {
+	if clientData, exists := db.GetClientDetails(clientID); exists {
 		return true, &clientData
 	}
 	return false, nil
 }
 
+// GetClientContext returns the fuzzy logic flag and client ID of the client
+// authenticated by the third-party middleware.
+func GetClientContext(c *gin.Context) (bool, string, error) {
+	clientID := c.GetString(middleware.ContextClientID)
+	if clientID == "" {
+		return Extract_token_data(c.GetHeader("Authorization"))
+	}
+
+	return c.GetBool(middleware.ContextFuzzyLogic), clientID, nil
+}
+
+type scoredCar struct {
+	Car   db.PresentCar
+	Score float64
+}
+
+// findFuzzyCars ranks the present cars parked in one of the zones, any zone
+// when nil, whose plate may be a misread of lpn, best score first. Candidates
+// are preselected in the database on their zone and the length and trigram
+// similarity of their normalized plate, up to the configured number of cars
+// scored.
+func findFuzzyCars(ctx context.Context, lpn string, zoneIDs []int) ([]scoredCar, error) {
+	cfg := config.Configvar.Fuzzy
+	query := platematch.Normalize(lpn)
+	length := utf8.RuneCountInString(query)
+	if length == 0 {
+		return nil, nil
+	}
+
+	candidates, err := db.GetPresentCarCandidates(ctx, query, length-cfg.MaxLengthDiff, length+cfg.MaxLengthDiff, cfg.MinSimilarity, zoneIDs, cfg.MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []scoredCar
+	for _, car := range candidates {
+		score := platematch.Score(query, car.LPN, cfg.MinScore)
+		if score > 0 && score >= cfg.MinScore {
+			matches = append(matches, scoredCar{Car: car, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	if cfg.MaxResults > 0 && len(matches) > cfg.MaxResults {
+		matches = matches[:cfg.MaxResults]
+	}
+
+	log.Debug().Str("LPN", lpn).Int("Candidates", len(candidates)).Int("Matches", len(matches)).Msg("Fuzzy plate search")
+	return matches, nil
+}
+
 func Extract_token_data(authHeader string) (bool, string, error) {
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
@@ -0,0 +1,316 @@
+package third_party
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/middleware"
+	"fyc/pkg/apierror"
+	"fyc/pkg/db"
+	"fyc/pkg/platematch"
+	"fyc/pkg/ratelimit"
+	"fyc/pkg/valkey"
+)
+
+// Kiosks identify themselves with this header, along with their client IP
+const HeaderDeviceID = "X-Device-ID"
+
+const (
+	candidateKeyPrefix = "fyc:kiosk:candidate:"
+	maxDeviceIDLength  = 64
+)
+
+type KioskCandidate struct {
+	CandidateID string `json:"candidate_id"`
+	MaskedPlate string `json:"masked_plate"`
+	ZoneName    string `json:"zone_name"`
+	PictureName string `json:"picture_name"`
+}
+
+type KioskSearchResponse struct {
+	Candidates []KioskCandidate `json:"candidates"`
+	// Refine is set when too many plates match, more characters must be typed
+	Refine bool `json:"refine"`
+}
+
+type KioskConfirmRequest struct {
+	CandidateID string `json:"candidate_id" binding:"required"`
+}
+
+// kioskCandidate is kept in Valkey until confirmed, the plate never leaves the server
+type kioskCandidate struct {
+	ClientID string `json:"client_id"`
+	Device   string `json:"device"`
+	LPN      string `json:"lpn"`
+	Masked   string `json:"masked"`
+}
+
+// KioskSearch godoc
+//
+//	@Summary		Search a car by partial plate
+//	@Description	Kiosk search with part of the license plate. Matching plates are returned masked with their zone picture and a candidate ID to confirm with /kiosk/confirm. When too many plates match, refine is set and no candidate is returned. Searches and failed confirmations are limited per client, per client IP and per device (X-Device-ID header along with the client IP), and the device or IP is locked out once its limit is exceeded, while the client is only refused until the end of the window.
+//	@Tags			Third Party
+//	@Produce		json
+//	@Param			partial_plate	query	string	true	"Part of the license plate"
+//	@Param			language		query	string	false	"Language"	default(en)
+//	@Param			X-Device-ID		header	string	false	"Kiosk device identifier"
+//	@Security		BearerAuth3rdParty
+//	@Success		200	{object}	KioskSearchResponse
+//	@Failure		429	{object}	map[string]interface{}
+//	@Router			/kiosk/search [get]
+func KioskSearch(c *gin.Context) {
+	ctx := c.Request.Context()
+	clientID := c.GetString(middleware.ContextClientID)
+	device := kioskDevice(c)
+	language := apierror.Language(c)
+	cfg := config.Configvar.Kiosk
+
+	partial := platematch.Normalize(c.Query("partial_plate"))
+	if utf8.RuneCountInString(partial) < cfg.MinLength {
+		apierror.Abort(c, http.StatusBadRequest, apierror.InvalidParameter, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("Please provide at least %d characters of the license plate", cfg.MinLength),
+			"code":    -5,
+		})
+		return
+	}
+
+	if !kioskAllowed(c, clientID, device, true) {
+		return
+	}
+
+	// One more car than the candidates returned is enough to ask to refine
+	matches, err := db.GetPresentCarsByPartialPlate(ctx, partial, middleware.AllowedZones(c), cfg.MaxCandidates+1)
+	if err != nil {
+		log.Err(err).Str("Client ID", clientID).Msg("Error retrieving present cars for kiosk search")
+		apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	response := KioskSearchResponse{Candidates: []KioskCandidate{}}
+	if len(matches) > cfg.MaxCandidates {
+		log.Info().Str("Client ID", clientID).Str("Device", device).Int("Matches", len(matches)).Msg("Kiosk search too broad")
+		response.Refine = true
+		apierror.Respond(c, http.StatusOK, response)
+		return
+	}
+
+	for _, car := range matches {
+		candidate, err := newKioskCandidate(ctx, clientID, device, car, partial, language)
+		if err != nil {
+			log.Err(err).Str("Client ID", clientID).Msg("Error storing kiosk candidate")
+			apierror.Abort(c, http.StatusServiceUnavailable, apierror.ServiceUnavailable, gin.H{
+				"success": false,
+				"code":    -500,
+				"message": "Kiosk search is not available",
+			})
+			return
+		}
+		response.Candidates = append(response.Candidates, *candidate)
+	}
+
+	log.Info().Str("Client ID", clientID).Str("Device", device).Int("Candidates", len(response.Candidates)).Msg("Kiosk search")
+	apierror.Respond(c, http.StatusOK, response)
+}
+
+// KioskConfirm godoc
+//
+//	@Summary		Confirm a kiosk search candidate
+//	@Description	Confirm the candidate chosen by the kiosk user and get the location of the car, the plate staying masked. A candidate can be confirmed once, only by the device that searched it and until it expires.
+//	@Tags			Third Party
+//	@Accept			json
+//	@Produce		json
+//	@Param			confirm		body	KioskConfirmRequest	true	"Candidate to confirm"
+//	@Param			language	query	string				false	"Language"	default(en)
+//	@Param			X-Device-ID	header	string				false	"Kiosk device identifier"
+//	@Security		BearerAuth3rdParty
+//	@Success		200	{object}	CarLocation
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		429	{object}	map[string]interface{}
+//	@Router			/kiosk/confirm [post]
+func KioskConfirm(c *gin.Context) {
+	ctx := c.Request.Context()
+	clientID := c.GetString(middleware.ContextClientID)
+	device := kioskDevice(c)
+	language := apierror.Language(c)
+
+	var request KioskConfirmRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.InvalidRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	if !kioskAllowed(c, clientID, device, false) {
+		return
+	}
+
+	value, found, err := valkey.Valkey_GlobalVar.GetAndDelete(ctx, candidateKeyPrefix+request.CandidateID)
+	if err != nil {
+		log.Err(err).Str("Client ID", clientID).Msg("Error retrieving kiosk candidate")
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.ServiceUnavailable, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "Kiosk search is not available",
+		})
+		return
+	}
+
+	var candidate kioskCandidate
+	if found {
+		found = json.Unmarshal([]byte(value), &candidate) == nil && candidate.ClientID == clientID && candidate.Device == device
+	}
+
+	var location *CarLocation
+	if found {
+		location, err = kioskLocation(ctx, c, clientID, candidate, language)
+		if err != nil {
+			log.Warn().Err(err).Str("Client ID", clientID).Msg("Kiosk candidate car not found")
+		}
+	}
+
+	if location == nil {
+		// Unknown candidates count as attempts against the device
+		log.Warn().Str("Client ID", clientID).Str("Device", device).Msg("Invalid kiosk candidate")
+		if kioskAllowed(c, clientID, device, true) {
+			apierror.Abort(c, http.StatusNotFound, apierror.CandidateNotFound, gin.H{
+				"success": false,
+				"message": "Candidate not found or expired, please search again",
+				"code":    -4,
+			})
+		}
+		return
+	}
+
+	log.Info().Str("Client ID", clientID).Str("Device", device).Str("Zone", location.SpotID).Msg("Kiosk candidate confirmed")
+	apierror.Respond(c, http.StatusOK, location)
+}
+
+// kioskDevice identifies the kiosk by its client IP and device ID, so that a
+// device ID chosen by the caller cannot escape the limits of its IP.
+func kioskDevice(c *gin.Context) string {
+	device := strings.TrimSpace(c.GetHeader(HeaderDeviceID))
+	if device == "" {
+		return c.ClientIP()
+	}
+	if len(device) > maxDeviceIDLength {
+		device = device[:maxDeviceIDLength]
+	}
+	return c.ClientIP() + "/" + device
+}
+
+// kioskAllowed checks the lockout of the device and of its client IP and the
+// cap of the client, counting an attempt when count is set, and answers 429
+// when rejected.
+func kioskAllowed(c *gin.Context, clientID string, device string, count bool) bool {
+	cfg := config.Configvar.Kiosk
+	window := time.Duration(cfg.Window) * time.Second
+	lockout := time.Duration(cfg.Lockout) * time.Second
+	ctx := c.Request.Context()
+
+	deviceLimit, ipLimit, clientLimit := cfg.DeviceLimit, cfg.IPLimit, cfg.ClientLimit
+	if !count {
+		deviceLimit, ipLimit, clientLimit = 0, 0, 0
+	}
+
+	decision := ratelimit.Throttle(ctx, "kiosk:"+clientID+":"+device, deviceLimit, window, lockout)
+	if decision.Allowed {
+		decision = ratelimit.Throttle(ctx, "kiosk:"+clientID+":ip:"+c.ClientIP(), ipLimit, window, lockout)
+	}
+	if decision.Allowed {
+		// Capped only, a lockout of the client would let a single kiosk lock
+		// all of them out
+		decision = ratelimit.Cap(ctx, "kiosk:"+clientID, clientLimit, window)
+	}
+	if decision.Allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	log.Warn().Str("Client ID", clientID).Str("Device", device).Int("Retry After", retryAfter).Msg("Kiosk request rejected")
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	apierror.Abort(c, http.StatusTooManyRequests, apierror.TooManyAttempts, gin.H{
+		"success": false,
+		"code":    -9,
+		"message": "Too many requests, " + decision.Reason,
+	})
+	return false
+}
+
+func newKioskCandidate(ctx context.Context, clientID string, device string, car db.PresentCar, partial string, language string) (*KioskCandidate, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(buf)
+
+	masked := platematch.Mask(car.LPN, partial)
+	value, err := json.Marshal(kioskCandidate{ClientID: clientID, Device: device, LPN: car.LPN, Masked: masked})
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(config.Configvar.Kiosk.CandidateTTL) * time.Second
+	if err := valkey.Valkey_GlobalVar.SetWithExpiry(ctx, candidateKeyPrefix+id, string(value), ttl); err != nil {
+		return nil, err
+	}
+
+	candidate := &KioskCandidate{CandidateID: id, MaskedPlate: masked}
+	if zone, err := db.GetZoneByID(ctx, *car.CurrZoneID); err == nil {
+		candidate.ZoneName = fmt.Sprint(zone.Name[language])
+	}
+	if zoneImage, err := db.GetZoneImageByZONEIDLang(ctx, *car.CurrZoneID, language); err == nil {
+		candidate.PictureName = fmt.Sprint(zoneImage.ID)
+	}
+	return candidate, nil
+}
+
+// kioskLocation returns the current location of the confirmed car, nil when it
+// left or moved to a zone the client may not see.
+func kioskLocation(ctx context.Context, c *gin.Context, clientID string, candidate kioskCandidate, language string) (*CarLocation, error) {
+	car, err := db.GetPresentCarByLPN(ctx, candidate.LPN)
+	if err != nil {
+		return nil, err
+	}
+	if car.CurrZoneID == nil || !middleware.ZoneAllowed(c, *car.CurrZoneID) {
+		return nil, nil
+	}
+
+	spotID := *car.CurrZoneID
+	location := &CarLocation{
+		SpotID:       fmt.Sprint(spotID),
+		LicensePlate: candidate.Masked,
+		CarVisit:     getCarVisit(ctx, clientID, *car, language),
+	}
+	if zone, err := db.GetZoneByID(ctx, spotID); err == nil {
+		location.ZoneName = fmt.Sprint(zone.Name[language])
+	}
+	if zoneImage, err := db.GetZoneImageByZONEIDLang(ctx, spotID, language); err == nil {
+		location.PictureName = fmt.Sprint(zoneImage.ID)
+	}
+	return location, nil
+}
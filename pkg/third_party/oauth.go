@@ -0,0 +1,339 @@
+package third_party
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/middleware"
+	"fyc/pkg/db"
+)
+
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Zones     []int  `json:"zones,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+}
+
+// OAuthToken godoc
+//
+//	@Summary		OAuth2 token endpoint
+//	@Description	Issue an access token with the client_credentials grant, or rotate a refresh token with the refresh_token grant (RFC 6749). Client credentials are accepted with HTTP basic authentication or in the form body.
+//	@Tags			Third Party
+//	@Accept			application/x-www-form-urlencoded
+//	@Produce		json
+//	@Param			grant_type		formData	string	true	"client_credentials or refresh_token"
+//	@Param			client_id		formData	string	false	"Client ID (when not using basic authentication)"
+//	@Param			client_secret	formData	string	false	"Client Secret (when not using basic authentication)"
+//	@Param			scope			formData	string	false	"Requested scope"
+//	@Param			refresh_token	formData	string	false	"Refresh token (refresh_token grant)"
+//	@Success		200				{object}	OAuthTokenResponse
+//	@Failure		400				{object}	OAuthErrorResponse
+//	@Failure		401				{object}	OAuthErrorResponse
+//	@Router			/oauth/token [post]
+func OAuthToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	client, ok := authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	grantType := c.PostForm("grant_type")
+	switch grantType {
+	case "client_credentials":
+		if client.ClientGrantType != "" && client.ClientGrantType != grantType {
+			log.Warn().Str("ClientID", client.ClientID).Str("GrantType", grantType).Msg("Grant type not allowed for client")
+			oauthError(c, http.StatusBadRequest, "unauthorized_client", "The client is not allowed to use this grant type")
+			return
+		}
+
+		scope, ok := middleware.GrantedScope(*client, c.PostForm("scope"))
+		if !ok {
+			log.Warn().Str("ClientID", client.ClientID).Str("Scope", c.PostForm("scope")).Msg("Scope not allowed for client")
+			oauthError(c, http.StatusBadRequest, "invalid_scope", "The requested scope is not allowed for the client")
+			return
+		}
+
+		response, err := issueOAuthTokens(ctx, client, scope)
+		if err != nil {
+			log.Err(err).Str("ClientID", client.ClientID).Msg("Failed to issue token")
+			oauthError(c, http.StatusInternalServerError, "server_error", "Could not issue token")
+			return
+		}
+
+		log.Info().Str("ClientID", client.ClientID).Msg("OAuth access token issued")
+		c.JSON(http.StatusOK, response)
+
+	case "refresh_token":
+		refreshToken := c.PostForm("refresh_token")
+		if refreshToken == "" {
+			oauthError(c, http.StatusBadRequest, "invalid_request", "refresh_token is required")
+			return
+		}
+
+		stored, err := db.GetOAuthTokenByHash(ctx, middleware.HashToken(refreshToken))
+		if err != nil {
+			log.Err(err).Str("ClientID", client.ClientID).Msg("Error retrieving refresh token")
+			oauthError(c, http.StatusInternalServerError, "server_error", "Could not verify refresh token")
+			return
+		}
+
+		if stored == nil || stored.TokenType != db.TokenTypeRefresh || stored.ClientID != client.ClientID || !stored.IsActive() {
+			log.Warn().Str("ClientID", client.ClientID).Msg("Invalid refresh token")
+			oauthError(c, http.StatusBadRequest, "invalid_grant", "The refresh token is invalid, expired or revoked")
+			return
+		}
+
+		// Refresh tokens are single use: the used one is revoked and a new one issued
+		if _, err := db.RevokeOAuthToken(ctx, client.ClientID, stored.TokenHash); err != nil {
+			log.Err(err).Str("ClientID", client.ClientID).Msg("Error revoking used refresh token")
+			oauthError(c, http.StatusInternalServerError, "server_error", "Could not rotate refresh token")
+			return
+		}
+
+		response, err := issueOAuthTokens(ctx, client, stored.Scope)
+		if err != nil {
+			log.Err(err).Str("ClientID", client.ClientID).Msg("Failed to issue token")
+			oauthError(c, http.StatusInternalServerError, "server_error", "Could not issue token")
+			return
+		}
+
+		log.Info().Str("ClientID", client.ClientID).Msg("OAuth access token refreshed")
+		c.JSON(http.StatusOK, response)
+
+	case "":
+		oauthError(c, http.StatusBadRequest, "invalid_request", "grant_type is required")
+
+	default:
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Supported grant types are client_credentials and refresh_token")
+	}
+}
+
+// OAuthRevoke godoc
+//
+//	@Summary		OAuth2 token revocation
+//	@Description	Revoke an access or refresh token issued to the authenticated client (RFC 7009)
+//	@Tags			Third Party
+//	@Accept			application/x-www-form-urlencoded
+//	@Produce		json
+//	@Param			token			formData	string	true	"Token to revoke"
+//	@Param			token_type_hint	formData	string	false	"access_token or refresh_token"
+//	@Param			client_id		formData	string	false	"Client ID (when not using basic authentication)"
+//	@Param			client_secret	formData	string	false	"Client Secret (when not using basic authentication)"
+//	@Success		200
+//	@Failure		400	{object}	OAuthErrorResponse
+//	@Failure		401	{object}	OAuthErrorResponse
+//	@Router			/oauth/revoke [post]
+func OAuthRevoke(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	client, ok := authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	// Unknown tokens or tokens of other clients are ignored, as required by RFC 7009
+	rows, err := db.RevokeOAuthToken(ctx, client.ClientID, middleware.HashToken(token))
+	if err != nil {
+		log.Err(err).Str("ClientID", client.ClientID).Msg("Error revoking token")
+		oauthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "Could not revoke token")
+		return
+	}
+
+	log.Info().Str("ClientID", client.ClientID).Int64("Revoked", rows).Msg("OAuth token revocation")
+	c.Status(http.StatusOK)
+}
+
+// OAuthIntrospect godoc
+//
+//	@Summary		OAuth2 token introspection
+//	@Description	Return the state of a token issued to the authenticated client (RFC 7662)
+//	@Tags			Third Party
+//	@Accept			application/x-www-form-urlencoded
+//	@Produce		json
+//	@Param			token			formData	string	true	"Token to introspect"
+//	@Param			client_id		formData	string	false	"Client ID (when not using basic authentication)"
+//	@Param			client_secret	formData	string	false	"Client Secret (when not using basic authentication)"
+//	@Success		200				{object}	IntrospectionResponse
+//	@Failure		401				{object}	OAuthErrorResponse
+//	@Router			/oauth/introspect [post]
+func OAuthIntrospect(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	client, ok := authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	stored, err := db.GetOAuthTokenByHash(ctx, middleware.HashToken(token))
+	if err != nil {
+		log.Err(err).Str("ClientID", client.ClientID).Msg("Error retrieving token for introspection")
+		oauthError(c, http.StatusInternalServerError, "server_error", "Could not introspect token")
+		return
+	}
+
+	if stored == nil || stored.ClientID != client.ClientID || !stored.IsActive() {
+		c.JSON(http.StatusOK, IntrospectionResponse{Active: false})
+		return
+	}
+
+	response := IntrospectionResponse{
+		Active:    true,
+		ClientID:  stored.ClientID,
+		Scope:     stored.Scope,
+		Zones:     client.AllowedZones,
+		TokenType: stored.TokenType,
+		Sub:       stored.ClientID,
+	}
+	if exp, err := config.FormatDate(stored.ExpiresAt); err == nil {
+		response.Exp = exp.Unix()
+	}
+	if iat, err := config.FormatDate(stored.IssuedAt); err == nil {
+		response.Iat = iat.Unix()
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// issueOAuthTokens creates and stores an access token, and a refresh token when enabled.
+func issueOAuthTokens(ctx context.Context, client *db.ClientDetails, scope string) (*OAuthTokenResponse, error) {
+	issuedAt := time.Now().UTC()
+	accessTTL := config.Configvar.OAuth.AccessTTL
+	expiresAt := issuedAt.Add(time.Duration(accessTTL) * time.Second)
+
+	accessToken, format, err := middleware.GenerateOAuthAccessToken(client.ClientID, client.FuzzyLogic, scope, client.AllowedZones, issuedAt, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.CreateOAuthToken(ctx, &db.OAuthToken{
+		TokenHash: middleware.HashToken(accessToken),
+		ClientID:  client.ClientID,
+		TokenType: db.TokenTypeAccess,
+		Format:    format,
+		Scope:     scope,
+		IssuedAt:  issuedAt.Format(time.DateTime),
+		ExpiresAt: expiresAt.Format(time.DateTime),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   accessTTL,
+		Scope:       scope,
+	}
+
+	if config.Configvar.OAuth.IssueRefresh == "true" {
+		refreshToken, err := middleware.GenerateOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+
+		err = db.CreateOAuthToken(ctx, &db.OAuthToken{
+			TokenHash: middleware.HashToken(refreshToken),
+			ClientID:  client.ClientID,
+			TokenType: db.TokenTypeRefresh,
+			Format:    "opaque",
+			Scope:     scope,
+			IssuedAt:  issuedAt.Format(time.DateTime),
+			ExpiresAt: issuedAt.Add(time.Duration(config.Configvar.OAuth.RefreshTTL) * time.Second).Format(time.DateTime),
+		})
+		if err != nil {
+			return nil, err
+		}
+		response.RefreshToken = refreshToken
+	}
+
+	return response, nil
+}
+
+// authenticateClient authenticates the client with HTTP basic authentication or
+// the client_id/client_secret form fields, and writes the OAuth error otherwise.
+func authenticateClient(c *gin.Context) (*db.ClientDetails, bool) {
+	clientID, clientSecret, usedBasic := c.Request.BasicAuth()
+	if usedBasic {
+		// RFC 6749 2.3.1: credentials are form-urlencoded before basic encoding
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+
+	if clientID == "" || clientSecret == "" {
+		oauthClientError(c, usedBasic, "Client authentication is required")
+		return nil, false
+	}
+
+	exists, client := isClientExist(clientID)
+	if !exists || !db.VerifyClientSecret(c.Request.Context(), client, clientSecret) {
+		log.Warn().Str("ClientID", clientID).Msg("Invalid ClientID or ClientSecret")
+		oauthClientError(c, usedBasic, "Invalid client credentials")
+		return nil, false
+	}
+
+	if !client.ClientActive {
+		log.Warn().Str("ClientID", clientID).Msg("Client is disabled")
+		oauthClientError(c, usedBasic, "Client is disabled")
+		return nil, false
+	}
+
+	return client, true
+}
+
+func oauthClientError(c *gin.Context, usedBasic bool, description string) {
+	if usedBasic {
+		c.Header("WWW-Authenticate", `Basic realm="fyc"`)
+	}
+	oauthError(c, http.StatusUnauthorized, "invalid_client", description)
+}
+
+func oauthError(c *gin.Context, status int, code string, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(status, OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
@@ -0,0 +1,107 @@
+package third_party
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/middleware"
+	"fyc/pkg/apierror"
+	"fyc/pkg/db"
+	"fyc/pkg/imaging"
+)
+
+// GetPictureBinary godoc
+//
+//	@Summary		Get a picture as an image file
+//	@Description	Get the zone picture as binary data. The format (JPEG, PNG or WebP) is negotiated with the Accept header or forced with the extension of the picture name, and the picture can be resized to the requested width. WebP is only served for pictures stored as WebP, at their original width, and a format that cannot be produced is answered 406. Responses carry an ETag and can be revalidated with If-None-Match.
+//	@Tags			Third Party
+//	@Produce		image/jpeg, image/png, image/webp
+//	@Param			picture_name	path	string	true	"Picture Name, optionally with a .jpeg, .png or .webp extension"
+//	@Param			picture_size	query	string	false	"Picture Size 'big or small'"	default(small)
+//	@Param			width			query	int		false	"Width in pixels, the picture is never enlarged"
+//	@Security		BearerAuth3rdParty
+//	@Success		200	{file}	string	"Picture"
+//	@Success		304	"Not Modified"
+//	@Router			/picture/{picture_name} [get]
+func GetPictureBinary(c *gin.Context) {
+	ctx := c.Request.Context()
+	pictureName := c.Param("picture_name")
+	imageSize := c.DefaultQuery("picture_size", "small")
+
+	format := imaging.FormatFromName(pictureName)
+	if dot := strings.LastIndex(pictureName, "."); dot >= 0 {
+		pictureName = pictureName[:dot]
+	}
+
+	id, err := strconv.Atoi(pictureName)
+	if err != nil {
+		log.Err(err).Str("id", pictureName).Msg("Invalid Picture ID format")
+		apierror.Abort(c, http.StatusBadRequest, apierror.InvalidParameter, gin.H{
+			"success": false,
+			"message": "ID must be a valid integer",
+			"code":    -5,
+		})
+		return
+	}
+
+	width := 0
+	if widthStr := c.Query("width"); widthStr != "" {
+		if width, err = strconv.Atoi(widthStr); err != nil || width < 0 {
+			apierror.Abort(c, http.StatusBadRequest, apierror.InvalidParameter, gin.H{
+				"success": false,
+				"message": "width must be a positive integer",
+				"code":    -5,
+			})
+			return
+		}
+	}
+
+	zoneImg, err := db.GetZoneImageVersionByID(ctx, id)
+	if err == nil && zoneImg.ZoneID != nil && !middleware.ZoneAllowed(c, *zoneImg.ZoneID) {
+		err = fmt.Errorf("zone %d not allowed for the client", *zoneImg.ZoneID)
+	}
+	if err != nil {
+		log.Warn().Err(err).Int("Picture ID", id).Msg("Error retrieving zone image")
+		apierror.Abort(c, http.StatusNotFound, apierror.PictureNotFound, gin.H{
+			"success": false,
+			"message": "Image not found",
+			"code":    -4,
+		})
+		return
+	}
+
+	// Resizing starts from the big picture for a better quality
+	large := (imageSize == "big" || width > 0) && zoneImg.HasLarge
+	if !zoneImg.HasSmall {
+		large = true
+	}
+
+	err = imaging.Serve(c, zoneImg.Version(large), func() (string, error) {
+		return db.GetZoneImageData(ctx, id, large)
+	}, imaging.Options{Width: width, Format: format})
+	if errors.Is(err, imaging.ErrNotAcceptable) {
+		apierror.Abort(c, http.StatusNotAcceptable, apierror.NotAcceptable, gin.H{
+			"success": false,
+			"message": "The picture can be served as image/jpeg, image/png or, when stored as WebP at its original width, image/webp",
+			"code":    -5,
+		})
+		return
+	}
+	if err != nil {
+		log.Err(err).Int("Picture ID", id).Msg("Error serving zone image")
+		apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "Image data is invalid.",
+		})
+		return
+	}
+
+	log.Info().Int("Picture ID", id).Int("Width", width).Msg("Picture served")
+}
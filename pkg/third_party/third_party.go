@@ -1,7 +1,8 @@
 package third_party
 
 import (
-	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 
 	"fyc/config"
 	"fyc/middleware"
+	"fyc/pkg/apierror"
 	"fyc/pkg/db"
 )
 
@@ -26,10 +28,12 @@ type TokenRespose struct {
 	ExpiresIn   int    `json:"expires_in"`
 }
 type CarLocation struct {
-	ZoneName     string `json:"zone_name"`
-	SpotID       string `json:"spot_id"`
-	PictureName  string `json:"picture_name"`
-	LicensePlate string `json:"license_plate"`
+	ZoneName     string  `json:"zone_name"`
+	SpotID       string  `json:"spot_id"`
+	PictureName  string  `json:"picture_name"`
+	LicensePlate string  `json:"license_plate"`
+	Score        float64 `json:"score,omitempty"`
+	CarVisit
 }
 type FindMyCarResponse struct {
 	ResponseCode int           `json:"response_code"`
@@ -50,15 +54,14 @@ type PictureResponse struct {
 // @Success		200				{object}	TokenRespose
 // @Router			/token [post]
 func GetToken(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	var tokenPref = config.Configvar.App.TokenPref3rdParty
 	var TokenRequester TokenRequester
 	var (
-		ClientID     string
-		ClientSecret string
-		GrantType    string
-		IsEnabled    bool
-		FuzzyLogic   bool
+		ClientID   string
+		GrantType  string
+		IsEnabled  bool
+		FuzzyLogic bool
 	)
 
 	if err := c.ShouldBind(&TokenRequester); err != nil {
@@ -78,14 +81,13 @@ func GetToken(c *gin.Context) {
 		}
 	}()
 
-	exists, clientDetails := isClientExist(db.ClientDataList, TokenRequester.ClientID)
-	log.Debug().Str("Client ID", clientDetails.ClientID).Bool("Fuzzic Logic", clientDetails.FuzzyLogic).Bool("Client Status", clientDetails.ClientActive).Msg("Fetch Client Data ")
+	exists, clientDetails := isClientExist(TokenRequester.ClientID)
 
 	if !exists {
-		log.Warn().Str("ClientID", ClientID).Msg("Client Not Found")
+		log.Warn().Str("ClientID", TokenRequester.ClientID).Msg("Client Not Found")
 	} else {
+		log.Debug().Str("Client ID", clientDetails.ClientID).Bool("Fuzzic Logic", clientDetails.FuzzyLogic).Bool("Client Status", clientDetails.ClientActive).Msg("Fetch Client Data ")
 		ClientID = clientDetails.ClientID
-		ClientSecret = clientDetails.ClientSecret
 		GrantType = clientDetails.ClientGrantType
 		IsEnabled = clientDetails.ClientActive
 		FuzzyLogic = clientDetails.FuzzyLogic
@@ -114,8 +116,8 @@ func GetToken(c *gin.Context) {
 	}
 
 	// 401 Done - Unauthorized
-	if TokenRequester.ClientID != ClientID || TokenRequester.ClientSecret != ClientSecret {
-		log.Warn().Str("ClientID", TokenRequester.ClientID).Str("ClientSecret", TokenRequester.ClientSecret).Msg("Invalid ClientID or ClientSecret")
+	if !exists || TokenRequester.ClientID != ClientID || !db.VerifyClientSecret(ctx, clientDetails, TokenRequester.ClientSecret) {
+		log.Warn().Str("ClientID", TokenRequester.ClientID).Msg("Invalid ClientID or ClientSecret")
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"code":    -1,
@@ -146,7 +148,8 @@ func GetToken(c *gin.Context) {
 		return
 	}
 
-	token, err := middleware.GenerateTokenThirdParty(TokenRequester.ClientID, TokenRequester.ClientSecret, TokenRequester.GrantType, FuzzyLogic)
+	scope, _ := middleware.GrantedScope(*clientDetails, "")
+	token, err := middleware.GenerateTokenThirdParty(TokenRequester.ClientID, TokenRequester.GrantType, FuzzyLogic, scope, clientDetails.AllowedZones)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to generate token")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -195,7 +198,7 @@ func GetToken(c *gin.Context) {
 }
 
 // @Summary		Find a car by license plate
-// @Description	Find a car using the license plate number
+// @Description	Find a car using the license plate number. Clients with fuzzy logic get the closest plates ranked by score, taking into account OCR misreads, missing characters and Arabic/Latin equivalents. Entry time, dwell time, last camera and zone journey are returned when enabled for the client.
 // @Tags			Third Party
 // @Accept			json
 // @Produce		json
@@ -206,13 +209,12 @@ func GetToken(c *gin.Context) {
 // @Router			/findmycar [get]
 func FindMyCar(c *gin.Context) {
 	var carResponses []CarLocation
-	authHeader := c.GetHeader("Authorization")
 
-	fuzzy_logic, ClientId, err := Extract_token_data(authHeader)
+	fuzzy_logic, ClientId, err := GetClientContext(c)
 	if err != nil {
 		log.Err(err).Msg("Error Getting Fuzzy Logic")
 
-		c.JSON(http.StatusBadRequest, gin.H{
+		apierror.Abort(c, http.StatusBadRequest, apierror.Unauthorized, gin.H{
 			"success": false,
 			"message": "Invalid authorization header format",
 			"code":    -1,
@@ -222,7 +224,7 @@ func FindMyCar(c *gin.Context) {
 
 	defer func() {
 		if r := recover(); r != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+			apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
 				"success": false,
 				"code":    -500,
 				"message": "An unexpected error occurred. Please try again later.",
@@ -232,15 +234,14 @@ func FindMyCar(c *gin.Context) {
 
 	//fuzzy_logic := c.DefaultQuery("fuzzy_logic", "false")
 	licensePlate := c.Query("license_plate")
-	lang := c.DefaultQuery("language", "en")
-	var language = strings.ToLower(lang)
-	ctx := context.Background()
+	language := apierror.Language(c)
+	ctx := c.Request.Context()
 
 	log.Info().Str("Language Provided ", language).Msg("Request Get Picture ")
 	log.Debug().Str("Licence Plate", licensePlate).Msg("Find Licence Plate data in progress")
 
 	if licensePlate == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
+		apierror.Abort(c, http.StatusBadRequest, apierror.MissingParameter, gin.H{
 			"success": false,
 			"message": "Please provide a license plate number",
 			"code":    -5,
@@ -253,10 +254,14 @@ func FindMyCar(c *gin.Context) {
 		log.Info().Bool("Fuzzy Logic", fuzzy_logic).Str("Client ID", ClientId).Msg("Accepetd Request with ")
 
 		car, err := db.GetPresentCarByLPN(ctx, licensePlate)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Info().Str("license_plate", licensePlate).Msg("No car found with the provided license plate")
+			respondCars(c, []CarLocation{})
+			return
+		}
 		if err != nil {
-
-			log.Warn().Str("Error : ", err.Error()).Str("license_plate", licensePlate).Msg("Error retrieving car by LPN")
-			c.JSON(http.StatusOK, []CarLocation{})
+			log.Error().Str("Error : ", err.Error()).Str("license_plate", licensePlate).Msg("Error retrieving car by LPN")
+			respondCarsError(c)
 			return
 		}
 
@@ -267,13 +272,19 @@ func FindMyCar(c *gin.Context) {
 		spotID := *car.CurrZoneID
 		licensePlate = car.LPN
 
+		if !middleware.ZoneAllowed(c, spotID) {
+			log.Info().Str("License Plate", licensePlate).Int("Zone ID", spotID).Msg("Car parked in a zone not allowed for the client")
+			respondCars(c, []CarLocation{})
+			return
+		}
+
 		log.Debug().Str("Spot ID", fmt.Sprint(spotID)).Str("License Plate", licensePlate).Msg("Zone Found")
 
 		// Retrieve the zone image by ID and language
 		zoneImage, err := db.GetZoneImageByZONEIDLang(ctx, spotID, language)
 		if err != nil {
 			log.Warn().Str("Error : ", err.Error()).Str("Language Provided", language).Int("Car Detail ID", *car.CarDetailsID).Msg("Error retrieving zone image")
-			c.JSON(http.StatusOK, []CarLocation{})
+			respondCars(c, []CarLocation{})
 			return
 		}
 
@@ -281,7 +292,7 @@ func FindMyCar(c *gin.Context) {
 		zoneData, err := db.GetZoneByID(ctx, spotID)
 		if err != nil {
 			log.Warn().Str("Error: ", err.Error()).Int("Zone ID", *car.CurrZoneID).Msg("Error retrieving zone")
-			c.JSON(http.StatusOK, []CarLocation{})
+			respondCars(c, []CarLocation{})
 			return
 		}
 
@@ -303,35 +314,41 @@ func FindMyCar(c *gin.Context) {
 			LicensePlate: licensePlate,
 			SpotID:       fmt.Sprint(spotID),
 			PictureName:  fmt.Sprint(zoneImage.ID),
+			CarVisit:     getCarVisit(ctx, ClientId, *car, language),
 		}
 
 		carResponses = append(carResponses, response)
 
-		c.JSON(http.StatusOK, carResponses)
+		respondCars(c, carResponses)
 	}
 
 	////////////////////////////////////////////////////// TRUE
 	if fuzzy_logic {
 		log.Info().Bool("Fuzzy Logic", fuzzy_logic).Str("Client ID", ClientId).Msg("Accepetd Request with ")
-		var cars []db.PresentCar
-		cars, err := db.GetPresentCarByLPNs(ctx, licensePlate)
+		// Cars of the zones not allowed are left out before the best matches
+		// are kept
+		matches, err := findFuzzyCars(ctx, licensePlate, middleware.AllowedZones(c))
 		if err != nil {
-			log.Warn().Str("Error", err.Error()).Str("license_plate", licensePlate).Msg("Error retrieving car by LPN")
-			c.JSON(http.StatusOK, []CarLocation{})
+			log.Error().Str("Error", err.Error()).Str("license_plate", licensePlate).Msg("Error retrieving car by LPN")
+			respondCarsError(c)
 			return
 		}
 
 		// Check if any car was found
-		if len(cars) == 0 {
+		if len(matches) == 0 {
 			log.Warn().Str("license_plate", licensePlate).Msg("No car found with the provided license plate")
-			c.JSON(http.StatusOK, []CarLocation{})
+			respondCars(c, []CarLocation{})
 			return
 		}
 
 		var carResponses []CarLocation
 
-		for _, car := range cars {
-			log.Debug().Int("zone", *car.CurrZoneID).Msg("Last Zone ID")
+		for _, match := range matches {
+			car := match.Car
+			if car.CurrZoneID == nil || !middleware.ZoneAllowed(c, *car.CurrZoneID) {
+				continue
+			}
+			log.Debug().Int("zone", *car.CurrZoneID).Float64("score", match.Score).Msg("Last Zone ID")
 			log.Debug().Interface("car_data", car).Msg("Present Data")
 
 			spotID := *car.CurrZoneID
@@ -364,6 +381,8 @@ func FindMyCar(c *gin.Context) {
 				zoneName = "Unknown"
 			}
 
+			visit := getCarVisit(ctx, ClientId, car, language)
+
 			for _, zoneImage := range zoneImages {
 				log.Debug().Str("Found Picture for Car with license plate", licensePlate).Str("Picture Name", fmt.Sprint(zoneImage.ID))
 
@@ -373,6 +392,8 @@ func FindMyCar(c *gin.Context) {
 					LicensePlate: licensePlate,
 					SpotID:       fmt.Sprint(spotID),
 					PictureName:  fmt.Sprint(zoneImage.ID),
+					Score:        match.Score,
+					CarVisit:     visit,
 				}
 
 				// Append the response for each image to the list of car responses
@@ -381,8 +402,28 @@ func FindMyCar(c *gin.Context) {
 		}
 
 		// Return all responses for all cars and their images
-		c.JSON(http.StatusOK, carResponses)
+		respondCars(c, carResponses)
+	}
+}
+
+// respondCars answers the cars found. v1 answers an empty list when no car is
+// found, v2 answers 404.
+func respondCars(c *gin.Context, cars []CarLocation) {
+	if len(cars) == 0 && apierror.IsV2(c) {
+		apierror.Fail(c, http.StatusNotFound, apierror.CarNotFound, "")
+		return
 	}
+	apierror.Respond(c, http.StatusOK, cars)
+}
+
+// respondCarsError answers a search that failed, not to be mistaken for a car
+// not found.
+func respondCarsError(c *gin.Context) {
+	apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
+		"success": false,
+		"code":    -500,
+		"message": "An unexpected error occurred. Please try again later.",
+	})
 }
 
 // @Summary		Get a picture by picture name
@@ -402,11 +443,11 @@ func GetPicture(c *gin.Context) {
 
 	//log.Info().Str("Language Provided ", lang).Msg("Request Get Picture ")
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	defer func() {
 		if r := recover(); r != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+			apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
 				"success": false,
 				"code":    -500,
 				"message": "An unexpected error occurred. Please try again later.",
@@ -415,7 +456,7 @@ func GetPicture(c *gin.Context) {
 	}()
 
 	if pictureName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
+		apierror.Abort(c, http.StatusBadRequest, apierror.MissingParameter, gin.H{
 			"success": false,
 			"message": "Please provide a picture Name",
 			"code":    -5,
@@ -430,7 +471,7 @@ func GetPicture(c *gin.Context) {
 
 		if err != nil {
 			log.Err(err).Str("id", pictureName).Msg("Invalid Picture ID format")
-			c.JSON(http.StatusBadRequest, gin.H{
+			apierror.Abort(c, http.StatusBadRequest, apierror.InvalidParameter, gin.H{
 				"success": false,
 				"message": "ID must be a valid integer",
 				"code":    -5,
@@ -440,9 +481,12 @@ func GetPicture(c *gin.Context) {
 
 		// Fetch the image for the given zone ID and language
 		zoneImg, err := db.GetZoneImageByID(ctx, id)
+		if err == nil && zoneImg.ZoneID != nil && !middleware.ZoneAllowed(c, *zoneImg.ZoneID) {
+			err = fmt.Errorf("zone %d not allowed for the client", *zoneImg.ZoneID)
+		}
 		if err != nil {
-			log.Warn().Str("zoneImg ID ", pictureName).Str("language", zoneImg.Language).Msgf("Error retrieving zone image by ID and language: %v", err)
-			c.JSON(http.StatusNotFound, gin.H{
+			log.Warn().Str("zoneImg ID ", pictureName).Msgf("Error retrieving zone image by ID and language: %v", err)
+			apierror.Abort(c, http.StatusNotFound, apierror.PictureNotFound, gin.H{
 				"success": false,
 				"message": "Image not found for the specified language",
 				"code":    -4,
@@ -455,7 +499,7 @@ func GetPicture(c *gin.Context) {
 		case "small":
 			if zoneImg.ImageSm != "" {
 				log.Info().Str("Small Image", pictureName).Str("Language", zoneImg.Language).Int("Zone ID", id).Msg("Small Image fetched successfully")
-				c.JSON(http.StatusOK, PictureResponse{
+				apierror.Respond(c, http.StatusOK, PictureResponse{
 					ImageData: zoneImg.ImageSm,
 				})
 				return
@@ -463,7 +507,7 @@ func GetPicture(c *gin.Context) {
 		case "big":
 			if zoneImg.ImageLg != "" {
 				log.Info().Str("Big Image", pictureName).Str("Language", zoneImg.Language).Int("Zone ID", id).Msg("Big Image fetched successfully")
-				c.JSON(http.StatusOK, PictureResponse{
+				apierror.Respond(c, http.StatusOK, PictureResponse{
 					ImageData: zoneImg.ImageLg,
 				})
 				return
@@ -472,7 +516,7 @@ func GetPicture(c *gin.Context) {
 			// Default to small image if size is not specified correctly
 			if zoneImg.ImageSm != "" {
 				log.Info().Str("Small Image", pictureName).Str("Language", zoneImg.Language).Int("Zone ID", id).Msg("Default Small Image fetched successfully")
-				c.JSON(http.StatusOK, PictureResponse{
+				apierror.Respond(c, http.StatusOK, PictureResponse{
 					ImageData: zoneImg.ImageSm,
 				})
 				return
@@ -481,7 +525,7 @@ func GetPicture(c *gin.Context) {
 
 		// If the image size doesn't match or is missing
 		log.Warn().Str("zoneImg", pictureName).Str("language", zoneImg.Language).Msg("Image not found for the requested size")
-		c.JSON(http.StatusNotFound, gin.H{
+		apierror.Abort(c, http.StatusNotFound, apierror.PictureNotFound, gin.H{
 			"success": false,
 			"message": "Image not found for the requested size",
 			"code":    -4,
@@ -497,12 +541,11 @@ func GetPicture(c *gin.Context) {
 // @Security	BearerAuth3rdParty
 // @Router		/getsettings [get]
 func Getsettings(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	FuzzyLogicValue, _, err := Extract_token_data(authHeader)
+	FuzzyLogicValue, _, err := GetClientContext(c)
 	if err != nil {
 		log.Err(err).Msg("Error Getting Fuzzy Logic")
 
-		c.JSON(http.StatusBadRequest, gin.H{
+		apierror.Abort(c, http.StatusBadRequest, apierror.Unauthorized, gin.H{
 			"success": false,
 			"message": "Invalid authorization header format",
 			"code":    -1,
@@ -514,7 +557,7 @@ func Getsettings(c *gin.Context) {
 		if r := recover(); r != nil {
 			log.Warn().Interface("Error ", r).Msg("An unexpected error occurred.")
 
-			c.JSON(http.StatusInternalServerError, gin.H{
+			apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
 				"success": false,
 				"code":    -500,
 				"message": "An unexpected error occurred. Please try again later.",
@@ -522,11 +565,11 @@ func Getsettings(c *gin.Context) {
 		}
 	}()
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	settings, err := db.GetAllSettingsThirdParty(ctx)
 	if err != nil {
 		log.Warn().Str("Error ", err.Error()).Msg("Error retrieving Settings fromqsdq db")
-		c.JSON(http.StatusNotFound, gin.H{
+		apierror.Abort(c, http.StatusNotFound, apierror.SettingsNotFound, gin.H{
 			"success": false,
 			"message": "Settings not found",
 			"code":    -4,
@@ -545,7 +588,7 @@ func Getsettings(c *gin.Context) {
 
 	log.Info().Int("CarPark ID", settings.CarParkID).Bool("FuzzyLogic", FuzzyLogicValue).Msg("Settings fetched successfully")
 
-	c.JSON(http.StatusOK, SettingsResponse3rdParty{
+	apierror.Respond(c, http.StatusOK, SettingsResponse3rdParty{
 		CarParkID:          settings.CarParkID,
 		CarParkName:        settings.CarParkName,
 		AppLogo:            settings.AppLogo,
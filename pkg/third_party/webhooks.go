@@ -0,0 +1,179 @@
+package third_party
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"fyc/middleware"
+	"fyc/pkg/apierror"
+	"fyc/pkg/db"
+	"fyc/pkg/webhooks"
+)
+
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+}
+
+// WebhookCreated is returned once on creation, with the secret used to sign the deliveries.
+type WebhookCreated struct {
+	db.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook godoc
+//
+//	@Summary		Subscribe to webhooks
+//	@Description	Register a URL, whose host must resolve to public addresses only, to be notified of vehicle entries (vehicle.entry), zone changes (vehicle.zone_changed), exits (vehicle.exit) and full zones (zone.full). Each POST carries the X-FYC-Event, X-FYC-Delivery and X-FYC-Timestamp headers and X-FYC-Signature, "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot and the raw body, keyed with the secret returned once by this call. Any non 2xx answer is retried with exponential backoff.
+//	@Tags			Third Party
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook	body	WebhookRequest	true	"URL and event types"
+//	@Security		BearerAuth3rdParty
+//	@Success		201	{object}	WebhookCreated
+//	@Router			/webhooks [post]
+func CreateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	clientID := c.GetString(middleware.ContextClientID)
+
+	var request WebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.InvalidRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	if err := webhooks.ValidateURL(ctx, request.URL); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.InvalidParameter, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	if err := db.ValidateWebhookEvents(request.Events); err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.InvalidParameter, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"code":    -5,
+		})
+		return
+	}
+
+	secret, err := db.GenerateClientSecret()
+	if err != nil {
+		log.Err(err).Str("Client ID", clientID).Msg("Error generating webhook secret")
+		apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	subscription := db.WebhookSubscription{
+		ClientID: clientID,
+		URL:      request.URL,
+		Events:   request.Events,
+		Secret:   secret,
+	}
+	if err := db.CreateWebhookSubscription(ctx, &subscription); err != nil {
+		log.Err(err).Str("Client ID", clientID).Msg("Error creating webhook subscription")
+		apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
+			"success": false,
+			"code":    -7,
+			"message": "Could not create the webhook subscription",
+		})
+		return
+	}
+
+	apierror.Respond(c, http.StatusCreated, WebhookCreated{WebhookSubscription: subscription, Secret: secret})
+}
+
+// GetWebhooks godoc
+//
+//	@Summary		List webhook subscriptions
+//	@Description	List the webhook subscriptions of the client
+//	@Tags			Third Party
+//	@Produce		json
+//	@Security		BearerAuth3rdParty
+//	@Success		200	{array}	db.WebhookSubscription
+//	@Router			/webhooks [get]
+func GetWebhooks(c *gin.Context) {
+	ctx := c.Request.Context()
+	clientID := c.GetString(middleware.ContextClientID)
+
+	subscriptions, err := db.GetWebhookSubscriptions(ctx, clientID)
+	if err != nil {
+		log.Err(err).Str("Client ID", clientID).Msg("Error retrieving webhook subscriptions")
+		apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if subscriptions == nil {
+		subscriptions = []db.WebhookSubscription{}
+	}
+	apierror.Respond(c, http.StatusOK, subscriptions)
+}
+
+// DeleteWebhook godoc
+//
+//	@Summary		Delete a webhook subscription
+//	@Description	Delete a webhook subscription of the client, its pending deliveries end in the dead letters
+//	@Tags			Third Party
+//	@Produce		json
+//	@Param			id	path	int	true	"Subscription ID"
+//	@Security		BearerAuth3rdParty
+//	@Success		200	{object}	map[string]interface{}
+//	@Router			/webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	clientID := c.GetString(middleware.ContextClientID)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.InvalidParameter, gin.H{
+			"success": false,
+			"message": "ID must be a valid integer",
+			"code":    -5,
+		})
+		return
+	}
+
+	rowsAffected, err := db.DeleteWebhookSubscription(ctx, clientID, id)
+	if err != nil {
+		log.Err(err).Str("Client ID", clientID).Int("Subscription ID", id).Msg("Error deleting webhook subscription")
+		apierror.Abort(c, http.StatusInternalServerError, apierror.InternalError, gin.H{
+			"success": false,
+			"code":    -500,
+			"message": "An unexpected error occurred. Please try again later.",
+		})
+		return
+	}
+
+	if rowsAffected == 0 {
+		apierror.Abort(c, http.StatusNotFound, apierror.WebhookNotFound, gin.H{
+			"success": false,
+			"message": "Webhook subscription not found",
+			"code":    -4,
+		})
+		return
+	}
+
+	log.Info().Str("Client ID", clientID).Int("Subscription ID", id).Msg("Webhook subscription deleted")
+	apierror.Respond(c, http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook subscription deleted",
+	})
+}
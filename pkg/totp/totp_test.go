@@ -0,0 +1,161 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 appendix B, SHA-1, truncated to the 6 digits used here
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, vector := range rfcVectors {
+		step := Step(time.Unix(vector.unix, 0))
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatalf("Code(%d): %v", vector.unix, err)
+		}
+		if code != vector.code {
+			t.Errorf("Code(%d) = %s, want %s", vector.unix, code, vector.code)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	code, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", Step(time.Unix(59, 0)))
+	if err != nil || code != "287082" {
+		t.Errorf("Code with lowercase secret = %q, %v, want 287082", code, err)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code with invalid secret: expected error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, vector := range rfcVectors {
+		now := time.Unix(vector.unix, 0)
+		step, ok := Validate(rfcSecret, vector.code, now)
+		if !ok || step != Step(now) {
+			t.Errorf("Validate(%s at %d) = %d, %v, want %d, true", vector.code, vector.unix, step, ok, Step(now))
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		ok     bool
+	}{
+		{"two periods before", -2, false},
+		{"previous period", -1, true},
+		{"current period", 0, true},
+		{"next period", 1, true},
+		{"two periods after", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			step, ok := Validate(rfcSecret, code, now)
+			if ok != tt.ok {
+				t.Fatalf("Validate = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("Validate step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+	}{
+		{"spaces", " 287 082 ", true},
+		{"wrong code", "287083", false},
+		{"too short", "28708", false},
+		{"too long", "2870820", false},
+		{"eight digits", "94287082", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(rfcSecret, tt.code, now); ok != tt.ok {
+				t.Errorf("Validate(%q) = %v, want %v", tt.code, ok, tt.ok)
+			}
+		})
+	}
+}
+
+// A code is refused the second time by the caller recording its step, so the
+// same code must always validate to the same step within its validity.
+func TestValidateReuse(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := Code(rfcSecret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, ok := Validate(rfcSecret, code, now)
+	if !ok {
+		t.Fatal("Validate: first use refused")
+	}
+
+	for _, later := range []time.Duration{0, 10 * time.Second, period * time.Second} {
+		step, ok := Validate(rfcSecret, code, now.Add(later))
+		if !ok || step != first {
+			t.Errorf("Validate %v later = %d, %v, want %d, true", later, step, ok, first)
+		}
+	}
+
+	next, err := Code(rfcSecret, Step(now)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step, ok := Validate(rfcSecret, next, now); !ok || step == first {
+		t.Errorf("Validate next code = %d, %v, want a step other than %d", step, ok, first)
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("GenerateRecoveryCodes returned %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+	for _, code := range codes {
+		if got := NormalizeRecoveryCode(" " + code[:3] + " " + code[3:] + " "); got != code {
+			t.Errorf("NormalizeRecoveryCode = %q, want %q", got, code)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package valkey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/valkey-io/valkey-go"
+)
+
+// Valkey_GlobalVar is the shared, long-lived Valkey client used by the whole application.
+var Valkey_GlobalVar = &ValkeyStrct{}
+
+// InitValkey connects the shared client. A failed connection is retried by the health check.
+func InitValkey() {
+	log.Debug().Msg("------------------------------- # CONNECT TO VALKEY # ------------------------------")
+	Valkey_GlobalVar.Valkey_Connect()
+}
+
+// Ping checks that the Valkey server answers on the current client.
+func (v *ValkeyStrct) Ping(ctx context.Context) error {
+	client := v.getClient()
+	if client == nil {
+		return fmt.Errorf("valkey client is not initialized")
+	}
+
+	return client.Do(ctx, client.B().Ping().Build()).Error()
+}
+
+// HealthCheck pings the server every interval and reconnects when the ping fails,
+// until ctx is cancelled.
+func (v *ValkeyStrct) HealthCheck(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, interval)
+			err := v.Ping(pingCtx)
+			cancel()
+
+			if err != nil {
+				log.Warn().Err(err).Msg("Valkey health check failed, reconnecting")
+				v.Valkey_Connect()
+			}
+		}
+	}
+}
+
+// Subscribe listens on channel and calls handler for every message received,
+// resubscribing after connection errors until ctx is cancelled.
+func (v *ValkeyStrct) Subscribe(ctx context.Context, channel string, handler func(message string)) {
+	retryDelay := 5 * time.Second
+
+	for {
+		client := v.getClient()
+		if client != nil {
+			log.Info().Str("channel", channel).Msg("Subscribed to Valkey channel")
+
+			err := client.Receive(ctx, client.B().Subscribe().Channel(channel).Build(), func(msg valkey.PubSubMessage) {
+				handler(msg.Message)
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn().Err(err).Str("channel", channel).Msg("Valkey subscription interrupted")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package valkey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+// IncrWithExpiry increments the counter stored at key and refreshes its expiry,
+// returning the new value.
+func (v *ValkeyStrct) IncrWithExpiry(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	client := v.getClient()
+	if client == nil {
+		return 0, fmt.Errorf("valkey client is not initialized")
+	}
+
+	results := client.DoMulti(ctx,
+		client.B().Incr().Key(key).Build(),
+		client.B().Expire().Key(key).Seconds(int64(ttl/time.Second)).Build(),
+	)
+
+	count, err := results[0].AsInt64()
+	if err != nil {
+		return 0, fmt.Errorf("error incrementing counter %s: %w", key, err)
+	}
+	if err := results[1].Error(); err != nil {
+		return count, fmt.Errorf("error setting expiry of counter %s: %w", key, err)
+	}
+
+	return count, nil
+}
+
+// GetCounters returns the values of the counters stored at keys, missing keys
+// being reported as 0.
+func (v *ValkeyStrct) GetCounters(ctx context.Context, keys ...string) ([]int64, error) {
+	client := v.getClient()
+	if client == nil {
+		return nil, fmt.Errorf("valkey client is not initialized")
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := client.Do(ctx, client.B().Mget().Key(keys...).Build()).ToArray()
+	if err != nil {
+		return nil, fmt.Errorf("error reading counters: %w", err)
+	}
+
+	counters := make([]int64, len(values))
+	for i, value := range values {
+		count, err := value.AsInt64()
+		if err != nil {
+			if valkey.IsValkeyNil(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading counter %s: %w", keys[i], err)
+		}
+		counters[i] = count
+	}
+
+	return counters, nil
+}
+
+// SetWithExpiry stores the value at key for ttl.
+func (v *ValkeyStrct) SetWithExpiry(ctx context.Context, key string, value string, ttl time.Duration) error {
+	client := v.getClient()
+	if client == nil {
+		return fmt.Errorf("valkey client is not initialized")
+	}
+
+	if err := client.Do(ctx, client.B().Set().Key(key).Value(value).Ex(ttl).Build()).Error(); err != nil {
+		return fmt.Errorf("error setting key %s: %w", key, err)
+	}
+	return nil
+}
+
+// SetIfAbsent stores the value at key for ttl unless the key exists, reporting
+// whether it was stored.
+func (v *ValkeyStrct) SetIfAbsent(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	client := v.getClient()
+	if client == nil {
+		return false, fmt.Errorf("valkey client is not initialized")
+	}
+
+	err := client.Do(ctx, client.B().Set().Key(key).Value(value).Nx().Ex(ttl).Build()).Error()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error setting key %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// GetAndDelete returns the value stored at key and deletes it, so that it can
+// be used only once. ok is false when the key does not exist.
+func (v *ValkeyStrct) GetAndDelete(ctx context.Context, key string) (string, bool, error) {
+	client := v.getClient()
+	if client == nil {
+		return "", false, fmt.Errorf("valkey client is not initialized")
+	}
+
+	value, err := client.Do(ctx, client.B().Getdel().Key(key).Build()).ToString()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("error reading key %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// TTL returns the remaining time to live of key, 0 when the key does not exist
+// or has no expiry.
+func (v *ValkeyStrct) TTL(ctx context.Context, key string) (time.Duration, error) {
+	client := v.getClient()
+	if client == nil {
+		return 0, fmt.Errorf("valkey client is not initialized")
+	}
+
+	ms, err := client.Do(ctx, client.B().Pttl().Key(key).Build()).AsInt64()
+	if err != nil {
+		return 0, fmt.Errorf("error reading expiry of key %s: %w", key, err)
+	}
+	if ms < 0 {
+		return 0, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Delete removes the keys, returning the number of keys that existed.
+func (v *ValkeyStrct) Delete(ctx context.Context, keys ...string) (int64, error) {
+	client := v.getClient()
+	if client == nil {
+		return 0, fmt.Errorf("valkey client is not initialized")
+	}
+
+	deleted, err := client.Do(ctx, client.B().Del().Key(keys...).Build()).AsInt64()
+	if err != nil {
+		return 0, fmt.Errorf("error deleting keys: %w", err)
+	}
+	return deleted, nil
+}
+
+// Decrements the counter only while positive, so that a counter expired
+// meanwhile is not recreated without expiry
+var decrPositive = valkey.NewLuaScript(`
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+if count > 0 then
+	return redis.call('DECR', KEYS[1])
+end
+return 0`)
+
+// DecrIfPositive decrements the counter stored at key, keeping its expiry,
+// unless it is missing or not positive. It returns the new value.
+func (v *ValkeyStrct) DecrIfPositive(ctx context.Context, key string) (int64, error) {
+	client := v.getClient()
+	if client == nil {
+		return 0, fmt.Errorf("valkey client is not initialized")
+	}
+
+	count, err := decrPositive.Exec(ctx, client, []string{key}, nil).AsInt64()
+	if err != nil {
+		return 0, fmt.Errorf("error decrementing counter %s: %w", key, err)
+	}
+	return count, nil
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"fyc/config"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/valkey-io/valkey-go"
 )
 
 type ValkeyStrct struct {
+	mu     sync.RWMutex
 	client valkey.Client
 }
 
@@ -25,37 +27,76 @@ func (v *ValkeyStrct) Valkey_Connect() {
 		return
 	}
 
+	v.mu.Lock()
+	old := v.client
 	v.client = client
+	v.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
 	log.Info().Str("Host", LinkConnect).Msg("- - - - - - - CONNECTED TO VALKEY SERVER - - - - - - - -")
 }
 
 func (v *ValkeyStrct) Valkey_Close() {
-	if v.client != nil {
-		v.client.Close()
+	v.mu.Lock()
+	client := v.client
+	v.client = nil
+	v.mu.Unlock()
+
+	if client != nil {
+		client.Close()
 		log.Info().Msg("Disconnected from Valkey server")
 	}
 }
-func (v *ValkeyStrct) PublishMessage(ctx context.Context, val interface{}) {
-	channel := config.Configvar.Valkey.Channel
+
+// getClient returns the current client, or nil when not connected.
+func (v *ValkeyStrct) getClient() valkey.Client {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.client
+}
+
+// PublishMessage publishes val as JSON on the configured channel and returns
+// the number of subscribers that received it.
+func (v *ValkeyStrct) PublishMessage(ctx context.Context, val interface{}) (int64, error) {
+	return v.PublishTo(ctx, config.Configvar.Valkey.Channel, val)
+}
+
+// PublishTo publishes val as JSON on the given channel and returns the number
+// of subscribers that received it.
+func (v *ValkeyStrct) PublishTo(ctx context.Context, channel string, val interface{}) (int64, error) {
+	client := v.getClient()
+	if client == nil {
+		return 0, fmt.Errorf("valkey client is not initialized")
+	}
 
 	data, err := json.Marshal(val)
 	if err != nil {
 		log.Err(err).Msg("Error marshaling data to JSON:")
-		return
+		return 0, fmt.Errorf("error marshaling data to JSON: %w", err)
 	}
 
-	err = v.client.Do(ctx, v.client.B().Publish().Channel(channel).Message(string(data)).Build()).Error()
+	receivers, err := client.Do(ctx, client.B().Publish().Channel(channel).Message(string(data)).Build()).AsInt64()
 	if err != nil {
 		log.Err(err).Msgf("Error publishing to channel : %s  / Erorr :", channel)
+		return 0, fmt.Errorf("error publishing to channel %s: %w", channel, err)
 	}
 
-	log.Info().Str("channel", channel).Msg("Successfully published message")
+	log.Info().Str("channel", channel).Int64("receivers", receivers).Msg("Successfully published message")
+	return receivers, nil
 }
 
 func (v *ValkeyStrct) Valkey_Setter_Data(ctx context.Context, key string, value string) bool {
 	log.Debug().Msg("------------- Setting Data into Valkey DB ----------")
 
-	resp, err := v.client.Do(ctx, v.client.B().Set().Key(key).Value(value).Build()).ToString()
+	client := v.getClient()
+	if client == nil {
+		log.Error().Msg("Valkey client is not initialized. Call Valkey_Connect first.")
+		return false
+	}
+
+	resp, err := client.Do(ctx, client.B().Set().Key(key).Value(value).Build()).ToString()
 	if err != nil {
 		log.Error().Err(err).Msgf("Error setting value %s to Key %s", value, key)
 		return false
@@ -74,7 +115,13 @@ func (v *ValkeyStrct) Valkey_Getter_Data(ctx context.Context, key string) (int64
 
 	log.Debug().Msg("------------- Retrieving Data from Valkey DB ----------")
 
-	val, err := v.client.Do(ctx, v.client.B().Get().Key(key).Build()).AsInt64()
+	client := v.getClient()
+	if client == nil {
+		log.Error().Msg("Valkey client is not initialized. Call Valkey_Connect first.")
+		return 0, false
+	}
+
+	val, err := client.Do(ctx, client.B().Get().Key(key).Build()).AsInt64()
 	if err != nil {
 		log.Error().Err(err).Msgf("Error retrieving value for Key %s", key)
 		return 0, false
@@ -87,12 +134,13 @@ func (v *ValkeyStrct) Valkey_Getter_Data(ctx context.Context, key string) (int64
 func (v *ValkeyStrct) Valkey_Incr_Data(ctx context.Context, key string) bool {
 	log.Debug().Msg("------------- Increment Data in Valkey DB ----------")
 
-	if v.client == nil {
+	client := v.getClient()
+	if client == nil {
 		log.Error().Msg("Valkey client is not initialized. Call Valkey_Connect first.")
 		return false
 	}
 
-	val, err := v.client.Do(ctx, v.client.B().Incr().Key(key).Build()).AsInt64()
+	val, err := client.Do(ctx, client.B().Incr().Key(key).Build()).AsInt64()
 	if err != nil {
 		log.Error().Err(err).Msgf("Error incrementing value for Key %s", key)
 		return false
@@ -105,7 +153,14 @@ func (v *ValkeyStrct) Valkey_Incr_Data(ctx context.Context, key string) bool {
 func (v *ValkeyStrct) Valkey_Decr_Data(ctx context.Context, key string) bool {
 
 	log.Debug().Msg("------------- Decremant Data into Valkey DB ----------")
-	val, err := v.client.Do(ctx, v.client.B().Decr().Key(key).Build()).AsInt64()
+
+	client := v.getClient()
+	if client == nil {
+		log.Error().Msg("Valkey client is not initialized. Call Valkey_Connect first.")
+		return false
+	}
+
+	val, err := client.Do(ctx, client.B().Decr().Key(key).Build()).AsInt64()
 	if err != nil {
 		log.Error().Err(err).Msgf("Error Decre new value to Key %s", key)
 		return false
@@ -0,0 +1,115 @@
+package valkey
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+type StreamEntry struct {
+	ID     string
+	Fields map[string]string
+}
+
+// StreamAdd appends an entry to the stream, trimming it to about maxLen entries
+// when maxLen is positive, and returns the ID assigned by the server.
+func (v *ValkeyStrct) StreamAdd(ctx context.Context, stream string, maxLen int64, fields map[string]string) (string, error) {
+	client := v.getClient()
+	if client == nil {
+		return "", fmt.Errorf("valkey client is not initialized")
+	}
+
+	key := client.B().Xadd().Key(stream)
+	var fv valkey.Completed
+	if maxLen > 0 {
+		cmd := key.Maxlen().Almost().Threshold(strconv.FormatInt(maxLen, 10)).Id("*").FieldValue()
+		for field, value := range fields {
+			cmd = cmd.FieldValue(field, value)
+		}
+		fv = cmd.Build()
+	} else {
+		cmd := key.Id("*").FieldValue()
+		for field, value := range fields {
+			cmd = cmd.FieldValue(field, value)
+		}
+		fv = cmd.Build()
+	}
+
+	id, err := client.Do(ctx, fv).ToString()
+	if err != nil {
+		return "", fmt.Errorf("error adding entry to stream %s: %w", stream, err)
+	}
+	return id, nil
+}
+
+// StreamCreateGroup creates a consumer group on the stream, creating the stream
+// if needed. An already existing group is not an error.
+func (v *ValkeyStrct) StreamCreateGroup(ctx context.Context, stream string, group string, startID string) error {
+	client := v.getClient()
+	if client == nil {
+		return fmt.Errorf("valkey client is not initialized")
+	}
+
+	err := client.Do(ctx, client.B().XgroupCreate().Key(stream).Group(group).Id(startID).Mkstream().Build()).Error()
+	if err != nil && !valkey.IsValkeyBusyGroup(err) {
+		return fmt.Errorf("error creating group %s on stream %s: %w", group, stream, err)
+	}
+	return nil
+}
+
+// StreamRange returns up to count entries of the stream between start and end (inclusive).
+func (v *ValkeyStrct) StreamRange(ctx context.Context, stream string, start string, end string, count int64) ([]StreamEntry, error) {
+	client := v.getClient()
+	if client == nil {
+		return nil, fmt.Errorf("valkey client is not initialized")
+	}
+
+	entries, err := client.Do(ctx, client.B().Xrange().Key(stream).Start(start).End(end).Count(count).Build()).AsXRange()
+	if err != nil {
+		return nil, fmt.Errorf("error reading stream %s: %w", stream, err)
+	}
+
+	return toStreamEntries(entries), nil
+}
+
+// StreamReadGroup reads up to count entries for the consumer of the group, blocking
+// at most block milliseconds. Use ">" as id for new entries or "0" for pending ones.
+func (v *ValkeyStrct) StreamReadGroup(ctx context.Context, stream string, group string, consumer string, id string, count int64, block int64) ([]StreamEntry, error) {
+	client := v.getClient()
+	if client == nil {
+		return nil, fmt.Errorf("valkey client is not initialized")
+	}
+
+	res, err := client.Do(ctx, client.B().Xreadgroup().Group(group, consumer).Count(count).Block(block).Streams().Key(stream).Id(id).Build()).AsXRead()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading group %s on stream %s: %w", group, stream, err)
+	}
+
+	return toStreamEntries(res[stream]), nil
+}
+
+// StreamAck acknowledges processed entries of the group.
+func (v *ValkeyStrct) StreamAck(ctx context.Context, stream string, group string, ids ...string) error {
+	client := v.getClient()
+	if client == nil {
+		return fmt.Errorf("valkey client is not initialized")
+	}
+
+	if err := client.Do(ctx, client.B().Xack().Key(stream).Group(group).Id(ids...).Build()).Error(); err != nil {
+		return fmt.Errorf("error acknowledging entries on stream %s: %w", stream, err)
+	}
+	return nil
+}
+
+func toStreamEntries(entries []valkey.XRangeEntry) []StreamEntry {
+	result := make([]StreamEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, StreamEntry{ID: entry.ID, Fields: entry.FieldValues})
+	}
+	return result
+}
@@ -0,0 +1,212 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/pkg/db"
+)
+
+const (
+	HeaderEvent     = "X-FYC-Event"
+	HeaderDelivery  = "X-FYC-Delivery"
+	HeaderTimestamp = "X-FYC-Timestamp"
+	HeaderSignature = "X-FYC-Signature"
+)
+
+// Due deliveries loaded per poll of the worker
+const batchSize = 100
+
+var httpClient = newHTTPClient()
+
+// Sign returns the signature of a body sent at the given unix timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryDelay returns the delay before the next attempt after the given number
+// of failed attempts, doubling from the configured delay up to its maximum.
+func RetryDelay(attempts int) time.Duration {
+	delay := time.Duration(config.Configvar.Webhook.RetryDelay) * time.Second
+	maxDelay := time.Duration(config.Configvar.Webhook.MaxRetryDelay) * time.Second
+
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func runWorker(ctx context.Context) {
+	interval := time.Duration(config.Configvar.Webhook.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue claims the due deliveries and sends them with a bounded number of
+// concurrent requests. The claim outlasts the requests of the batch, so that
+// another instance only takes the deliveries over once this one stopped.
+func deliverDue(ctx context.Context) {
+	now := time.Now().UTC()
+	lockedUntil := now.Add(claimDuration()).Format(time.DateTime)
+
+	deliveries, err := db.ClaimDueWebhookDeliveries(ctx, now.Format(time.DateTime), lockedUntil, batchSize)
+	if err != nil {
+		log.Err(err).Msg("Error loading due webhook deliveries")
+		return
+	}
+
+	workers := config.Configvar.Webhook.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(delivery *db.WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			Deliver(ctx, delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+}
+
+// claimDuration is the time needed to send a full batch, every request taking
+// at most the timeout.
+func claimDuration() time.Duration {
+	workers := max(config.Configvar.Webhook.Workers, 1)
+	requests := (batchSize + workers - 1) / workers
+	return time.Duration(requests*max(config.Configvar.Webhook.Timeout, 1))*time.Second + time.Minute
+}
+
+// Deliver makes one attempt to post the delivery to its subscription and
+// records the outcome, scheduling a retry or moving it to the dead letters.
+func Deliver(ctx context.Context, delivery *db.WebhookDelivery) {
+	subscription, err := db.GetWebhookSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		log.Err(err).Int("Delivery ID", delivery.ID).Msg("Error loading webhook subscription")
+		return
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttempt = now.Format(time.DateTime)
+	delivery.LockedUntil = ""
+
+	if subscription == nil || !subscription.IsEnabled {
+		delivery.Status = db.DeliveryDead
+		delivery.NextAttempt = ""
+		delivery.LastError = "subscription deleted or disabled"
+	} else if delivery.ResponseCode, err = post(ctx, subscription, delivery, now); err == nil {
+		delivery.Status = db.DeliveryDelivered
+		delivery.NextAttempt = ""
+		delivery.LastError = ""
+		delivery.DeliveredAt = now.Format(time.DateTime)
+		log.Info().Int("Delivery ID", delivery.ID).Str("Client ID", delivery.ClientID).Str("type", delivery.EventType).Int("attempt", delivery.Attempts).Msg("Webhook delivered")
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= config.Configvar.Webhook.MaxAttempts {
+			delivery.Status = db.DeliveryDead
+			delivery.NextAttempt = ""
+			log.Warn().Err(err).Int("Delivery ID", delivery.ID).Str("Client ID", delivery.ClientID).Int("attempt", delivery.Attempts).Msg("Webhook delivery failed, moved to dead letters")
+		} else {
+			delivery.Status = db.DeliveryPending
+			delivery.NextAttempt = now.Add(RetryDelay(delivery.Attempts)).Format(time.DateTime)
+			log.Warn().Err(err).Int("Delivery ID", delivery.ID).Str("Client ID", delivery.ClientID).Int("attempt", delivery.Attempts).Str("next_attempt", delivery.NextAttempt).Msg("Webhook delivery failed, retry scheduled")
+		}
+	}
+
+	if err := db.SaveWebhookAttempt(ctx, delivery); err != nil {
+		log.Err(err).Int("Delivery ID", delivery.ID).Msg("Error recording webhook delivery attempt")
+	}
+}
+
+func post(ctx context.Context, subscription *db.WebhookSubscription, delivery *db.WebhookDelivery, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.Configvar.Webhook.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, now.Unix(), delivery.Payload))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Replay queues a new delivery of the payload of a logged delivery, keeping
+// the original untouched in the log.
+func Replay(ctx context.Context, id int) (*db.WebhookDelivery, error) {
+	original, err := db.GetWebhookDeliveryByID(ctx, id)
+	if err != nil || original == nil {
+		return nil, err
+	}
+
+	replay := &db.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		ClientID:       original.ClientID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		ReplayOf:       &original.ID,
+	}
+	if err := db.CreateWebhookDelivery(ctx, replay); err != nil {
+		return nil, err
+	}
+
+	log.Info().Int("Delivery ID", replay.ID).Int("Replay Of", id).Str("Client ID", replay.ClientID).Msg("Webhook delivery replayed")
+	return replay, nil
+}
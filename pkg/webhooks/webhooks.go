@@ -0,0 +1,230 @@
+// Package webhooks turns the vehicle and capacity events of the stream into
+// entry, zone change, exit and zone full notifications, and delivers them to
+// the URLs registered by the third-party clients.
+//
+// Each request is signed with the secret of the subscription: the
+// X-FYC-Signature header holds "sha256=" followed by the hex HMAC-SHA256 of
+// the X-FYC-Timestamp header, a dot and the raw body. Failed deliveries are
+// retried with exponential backoff and end in the dead-letter store once all
+// attempts are exhausted.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"fyc/config"
+	"fyc/functions"
+	"fyc/pkg/db"
+	"fyc/pkg/events"
+	"fyc/pkg/valkey"
+)
+
+// Payload is the body posted to the subscribers. Data is the data of the
+// stream event the notification was derived from.
+type Payload struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt string          `json:"occurred_at"`
+	Data       json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+// Zones already notified as full are marked in Valkey, shared by the
+// instances, until they have free places again or for fullZoneTTL at most
+const (
+	fullZoneKeyPrefix = "fyc:webhooks:full:"
+	fullZoneTTL       = 24 * time.Hour
+)
+
+// Consumer and delivery worker started by Start
+var workers sync.WaitGroup
+
+// Start consumes the events stream with the webhook consumer group and runs
+// the delivery worker until ctx is cancelled.
+func Start(ctx context.Context) {
+	group := config.Configvar.Webhook.Group
+	stream := config.Configvar.Valkey.Stream
+
+	if err := valkey.Valkey_GlobalVar.StreamCreateGroup(ctx, stream, group, "$"); err != nil {
+		log.Err(err).Str("stream", stream).Str("group", group).Msg("Error creating webhook consumer group")
+	}
+
+	consumer, err := os.Hostname()
+	if err != nil || consumer == "" {
+		consumer = "fyc"
+	}
+
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		events.Consume(ctx, group, consumer, func(event events.Event) error {
+			return handleEvent(ctx, event)
+		})
+	}()
+	go func() {
+		defer workers.Done()
+		runWorker(ctx)
+	}()
+
+	log.Info().Str("group", group).Str("consumer", consumer).Msg("Webhook dispatcher started")
+}
+
+// Wait waits for the dispatcher to stop once the context of Start is
+// cancelled, or until ctx is done.
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func handleEvent(ctx context.Context, event events.Event) error {
+	var eventType string
+	var zones []int
+
+	switch event.Type {
+	case events.TypeVehicleMovement:
+		var movement events.VehicleMovement
+		if err := json.Unmarshal(event.Data, &movement); err != nil {
+			log.Warn().Err(err).Str("id", event.ID).Msg("Invalid vehicle movement event, skipped")
+			return nil
+		}
+
+		eventType = classifyMovement(movement)
+		for _, zone := range []*int{movement.CurrZoneID, movement.LastZoneID} {
+			if zone != nil {
+				zones = append(zones, *zone)
+			}
+		}
+
+	case events.TypeCapacityChange:
+		var change events.CapacityChange
+		if err := json.Unmarshal(event.Data, &change); err != nil {
+			log.Warn().Err(err).Str("id", event.ID).Msg("Invalid capacity change event, skipped")
+			return nil
+		}
+
+		full, err := becameFull(ctx, change)
+		if err != nil || !full {
+			return err
+		}
+		eventType = db.WebhookZoneFull
+		zones = []int{change.ZoneID}
+	}
+
+	if eventType == "" {
+		return nil
+	}
+
+	return enqueue(ctx, event, eventType, zones)
+}
+
+// classifyMovement derives the notification from a plate read. Exit zones
+// stand for the outside of the car park, so moving into one is an exit and
+// leaving one, or a plate seen for the first time, is an entry.
+func classifyMovement(movement events.VehicleMovement) string {
+	exitZones := config.Configvar.Webhook.ExitZones
+	isExit := func(zone *int) bool {
+		return zone != nil && functions.Contains(exitZones, *zone)
+	}
+
+	switch {
+	case isExit(movement.CurrZoneID):
+		return db.WebhookExit
+	case movement.FirstSeen || isExit(movement.LastZoneID):
+		return db.WebhookEntry
+	case movement.CurrZoneID != nil && (movement.LastZoneID == nil || *movement.CurrZoneID != *movement.LastZoneID):
+		return db.WebhookZoneChange
+	}
+	return ""
+}
+
+// becameFull reports whether the change filled the zone, so that a full zone
+// is notified once, whichever instance handles the change, and again only
+// after it had free places.
+func becameFull(ctx context.Context, change events.CapacityChange) (bool, error) {
+	key := fullZoneKeyPrefix + strconv.Itoa(change.ZoneID)
+
+	if change.FreeCapacity > 0 {
+		if _, err := valkey.Valkey_GlobalVar.Delete(ctx, key); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	return valkey.Valkey_GlobalVar.SetIfAbsent(ctx, key, "1", fullZoneTTL)
+}
+
+// enqueue creates a pending delivery for every enabled subscription of the
+// event type whose client is active and allowed to see one of the zones.
+func enqueue(ctx context.Context, event events.Event, eventType string, zones []int) error {
+	subscriptions, err := db.GetEnabledWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(Payload{
+		ID:         event.ID,
+		Type:       eventType,
+		OccurredAt: event.OccurredAt,
+		Data:       event.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling %s webhook payload: %w", eventType, err)
+	}
+
+	for _, subscription := range subscriptions {
+		if !functions.ContainsStr(subscription.Events, eventType) || !clientAllowed(subscription.ClientID, zones) {
+			continue
+		}
+
+		err := db.CreateWebhookDelivery(ctx, &db.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			ClientID:       subscription.ClientID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Payload:        payload,
+		})
+		if err != nil {
+			return err
+		}
+		log.Debug().Str("Client ID", subscription.ClientID).Int("Subscription ID", subscription.ID).Str("type", eventType).Msg("Webhook delivery queued")
+	}
+
+	return nil
+}
+
+func clientAllowed(clientID string, zones []int) bool {
+	client, exists := db.GetClientDetails(clientID)
+	if !exists || !client.ClientActive {
+		return false
+	}
+
+	if !functions.ContainsStr(client.Scopes, db.ScopeWebhooks) && len(client.Scopes) > 0 {
+		return false
+	}
+
+	if len(client.AllowedZones) == 0 {
+		return true
+	}
+	for _, zone := range zones {
+		if functions.Contains(client.AllowedZones, zone) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,14 +3,16 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func ClientCredsRoutes(r *gin.Engine) {
-	r.GET("/fyc/apikey", api.GetAllClientCredsApi)
-	r.PUT("/fyc/apikey", api.UpdateClientCredAPI)
-	r.POST("/fyc/apikey", api.AddClientCredAPI)
-	r.DELETE("/fyc/apikey", api.DeleteClientCredAPI)
+func ClientCredsRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/apikey", middleware.RequirePermission(rbac.ModuleClients, rbac.ActionView), api.GetAllClientCredsApi)
+	r.PUT("/fyc/apikey", middleware.RequirePermission(rbac.ModuleClients, rbac.ActionUpdate), middleware.Audit(rbac.ModuleClients, rbac.ActionUpdate, "client_id"), api.UpdateClientCredAPI)
+	r.POST("/fyc/apikey", middleware.RequirePermission(rbac.ModuleClients, rbac.ActionCreate), middleware.Audit(rbac.ModuleClients, rbac.ActionCreate, "client_id"), api.AddClientCredAPI)
+	r.DELETE("/fyc/apikey", middleware.RequirePermission(rbac.ModuleClients, rbac.ActionDelete), middleware.Audit(rbac.ModuleClients, rbac.ActionDelete, "id"), api.DeleteClientCredAPI)
 
 	//r.GET("/fyc/clientEnabled", api.GetClientEnabledAPI)
 	//r.GET("/fyc/clientsDeleted", api.GetClientDeletedAPI)
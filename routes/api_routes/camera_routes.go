@@ -3,14 +3,16 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func CameraRoutes(r *gin.Engine) {
-	r.GET("/fyc/cameras", api.GetCameraAPI)
-	r.POST("/fyc/cameras", api.CreateCameraAPI)
-	r.PUT("/fyc/cameras", api.UpdateCameraAPI)
-	r.DELETE("/fyc/cameras", api.DeleteCameraAPI)
+func CameraRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/cameras", middleware.RequirePermission(rbac.ModuleCameras, rbac.ActionView), api.GetCameraAPI)
+	r.POST("/fyc/cameras", middleware.RequirePermission(rbac.ModuleCameras, rbac.ActionCreate), middleware.Audit(rbac.ModuleCameras, rbac.ActionCreate, "id", "cam_id"), api.CreateCameraAPI)
+	r.PUT("/fyc/cameras", middleware.RequirePermission(rbac.ModuleCameras, rbac.ActionUpdate), middleware.Audit(rbac.ModuleCameras, rbac.ActionUpdate, "id", "cam_id"), api.UpdateCameraAPI)
+	r.DELETE("/fyc/cameras", middleware.RequirePermission(rbac.ModuleCameras, rbac.ActionDelete), middleware.Audit(rbac.ModuleCameras, rbac.ActionDelete, "id"), api.DeleteCameraAPI)
 
 	//r.GET("/fyc/camerasEnabled", api.GetCameraEnabledAPI)
 	//r.GET("/fyc/camerasDeleted", api.GetCameraDeletedAPI)
@@ -3,12 +3,14 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func CarDetailRoutes(r *gin.Engine) {
-	r.GET("/fyc/carDetails", api.GetCarDetailsAPI)
-	r.POST("/fyc/carDetails", api.CreateCarDetailAPI)
-	r.PUT("/fyc/carDetails", api.UpdateCarDetailByIdAPI)
-	r.DELETE("/fyc/carDetails", api.DeleteCarDetailAPI)
+func CarDetailRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/carDetails", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionView), api.GetCarDetailsAPI)
+	r.POST("/fyc/carDetails", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionCreate), api.CreateCarDetailAPI)
+	r.PUT("/fyc/carDetails", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionUpdate), api.UpdateCarDetailByIdAPI)
+	r.DELETE("/fyc/carDetails", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionDelete), api.DeleteCarDetailAPI)
 }
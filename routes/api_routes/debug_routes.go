@@ -3,10 +3,12 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/debug"
+	"fyc/pkg/rbac"
 )
 
-func DebugRoutes(r *gin.Engine) {
+func DebugRoutes(r *gin.RouterGroup) {
 
-	r.GET("/fyc/debug", debug.Debuger_api)
+	r.GET("/fyc/debug", middleware.RequirePermission(rbac.ModuleDebug, rbac.ActionView), debug.Debuger_api)
 }
@@ -3,12 +3,15 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/audit"
+	"fyc/pkg/rbac"
 )
 
-func ErrorRoutes(r *gin.Engine) {
-	r.GET("/fyc/errors", api.GetAllErrorCode)
-	r.POST("/fyc/errors", api.CreateErrorMessageAPI)
-	r.PUT("/fyc/errors", api.UpdateErrorMessageAPI)
-	r.DELETE("/fyc/errors", api.DeleteErrorMessageAPI)
+func ErrorRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/errors", middleware.RequirePermission(rbac.ModuleSettings, rbac.ActionView), api.GetAllErrorCode)
+	r.POST("/fyc/errors", middleware.RequirePermission(rbac.ModuleSettings, rbac.ActionCreate), middleware.AuditEntity(rbac.ModuleSettings, audit.EntityErrorMessage, rbac.ActionCreate, "code"), api.CreateErrorMessageAPI)
+	r.PUT("/fyc/errors", middleware.RequirePermission(rbac.ModuleSettings, rbac.ActionUpdate), middleware.AuditEntity(rbac.ModuleSettings, audit.EntityErrorMessage, rbac.ActionUpdate, "code"), api.UpdateErrorMessageAPI)
+	r.DELETE("/fyc/errors", middleware.RequirePermission(rbac.ModuleSettings, rbac.ActionDelete), middleware.AuditEntity(rbac.ModuleSettings, audit.EntityErrorMessage, rbac.ActionDelete, "code"), api.DeleteErrorMessageAPI)
 }
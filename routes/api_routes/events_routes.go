@@ -0,0 +1,13 @@
+package api_routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"fyc/middleware"
+	"fyc/pkg/api"
+	"fyc/pkg/rbac"
+)
+
+func EventsRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/events", middleware.RequirePermission(rbac.ModuleDashboard, rbac.ActionView), api.GetEventsAPI)
+}
@@ -3,13 +3,15 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func HistoryRoutes(r *gin.Engine) {
-	r.GET("/fyc/history", api.GetHistoryAPI)
-	r.GET("/fyc/history/:lpn", api.GetHistoryByLPNAPI)
-	r.POST("/fyc/history", api.CreateHistoryAPI)
-	r.PUT("/fyc/history/:id", api.UpdateHistoryAPI)
-	r.DELETE("/fyc/history/:id", api.DeleteHistoryAPI)
+func HistoryRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/history", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionView), api.GetHistoryAPI)
+	r.GET("/fyc/history/:lpn", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionView), api.GetHistoryByLPNAPI)
+	r.POST("/fyc/history", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionCreate), api.CreateHistoryAPI)
+	r.PUT("/fyc/history/:id", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionUpdate), api.UpdateHistoryAPI)
+	r.DELETE("/fyc/history/:id", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionDelete), api.DeleteHistoryAPI)
 }
@@ -3,15 +3,17 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func PresentCarRoutes(r *gin.Engine) {
-	r.GET("/fyc/presentcars", api.GetPresentCarsAPI)
-	r.POST("/fyc/presentcars", api.CreatePresentCarAPI)
-	r.PUT("/fyc/presentcars", api.UpdatePresentCarBylpnAPI)
-	r.PUT("/fyc/presentcars/:lpn", api.UpdatePresentCarByIdAPI)
-	r.DELETE("/fyc/presentcars/:id", api.DeletePresentCarAPI)
+func PresentCarRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/presentcars", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionView), api.GetPresentCarsAPI)
+	r.POST("/fyc/presentcars", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionCreate), api.CreatePresentCarAPI)
+	r.PUT("/fyc/presentcars", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionUpdate), api.UpdatePresentCarBylpnAPI)
+	r.PUT("/fyc/presentcars/:lpn", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionUpdate), api.UpdatePresentCarByIdAPI)
+	r.DELETE("/fyc/presentcars/:id", middleware.RequirePermission(rbac.ModulePresentCars, rbac.ActionDelete), api.DeletePresentCarAPI)
 
 	//r.GET("/fyc/presentcars/:lpn", api.GetPresentCarByLPNAPI)
 
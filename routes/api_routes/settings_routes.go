@@ -3,11 +3,13 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func SettingsRoutes(r *gin.Engine) {
-	r.GET("/fyc/settings", api.GetSettingsAPI)
-	r.POST("/fyc/settings", api.AddSettingsAPI)
-	r.PUT("/fyc/settings", api.UpdateSettingsAPI)
+func SettingsRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/settings", middleware.RequirePermission(rbac.ModuleSettings, rbac.ActionView), api.GetSettingsAPI)
+	r.POST("/fyc/settings", middleware.RequirePermission(rbac.ModuleSettings, rbac.ActionCreate), middleware.Audit(rbac.ModuleSettings, rbac.ActionCreate, "carpark_id"), api.AddSettingsAPI)
+	r.PUT("/fyc/settings", middleware.RequirePermission(rbac.ModuleSettings, rbac.ActionUpdate), middleware.Audit(rbac.ModuleSettings, rbac.ActionUpdate, "carpark_id"), api.UpdateSettingsAPI)
 }
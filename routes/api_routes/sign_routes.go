@@ -3,14 +3,16 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func SignRoutes(r *gin.Engine) {
-	r.GET("/fyc/sign", api.GetSignAPI)
-	r.POST("/fyc/sign", api.CreateSignAPI)
-	r.PUT("/fyc/sign", api.UpdateSignAPI)
-	r.DELETE("/fyc/sign", api.DeleteSignAPI)
+func SignRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/sign", middleware.RequirePermission(rbac.ModuleSigns, rbac.ActionView), api.GetSignAPI)
+	r.POST("/fyc/sign", middleware.RequirePermission(rbac.ModuleSigns, rbac.ActionCreate), middleware.Audit(rbac.ModuleSigns, rbac.ActionCreate, "id", "sign_id"), api.CreateSignAPI)
+	r.PUT("/fyc/sign", middleware.RequirePermission(rbac.ModuleSigns, rbac.ActionUpdate), middleware.Audit(rbac.ModuleSigns, rbac.ActionUpdate, "id", "sign_id"), api.UpdateSignAPI)
+	r.DELETE("/fyc/sign", middleware.RequirePermission(rbac.ModuleSigns, rbac.ActionDelete), middleware.Audit(rbac.ModuleSigns, rbac.ActionDelete, "id"), api.DeleteSignAPI)
 
 	//r.GET("/fyc/signEnabled", api.GetSignEnabledAPI)
 	//r.GET("/fyc/signDeleted", api.GetSignDeletedAPI)
@@ -3,12 +3,13 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func UserAuditRoutes(r *gin.Engine) {
-	r.GET("/fyc/UserAudit", api.GetUserAuditAPI)
-	r.POST("/fyc/UserAudit", api.CreateUserAuditAPI)
-	r.PUT("/fyc/UserAudit", api.UpdateUserAuditAPI)
-	r.DELETE("/fyc/UserAudit", api.DeleteUserAuditAPI)
+// UserAuditRoutes serves the legacy audit entries, read-only since changes are
+// recorded in the audit log
+func UserAuditRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/UserAudit", middleware.RequirePermission(rbac.ModuleAudit, rbac.ActionView), api.GetUserAuditAPI)
 }
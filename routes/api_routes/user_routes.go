@@ -3,14 +3,16 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func UserRoutes(r *gin.Engine) {
-	r.GET("/fyc/users", api.GetUsersAPI)
-	r.POST("/fyc/user", api.AddUserAPI)
-	r.PUT("/fyc/user", api.UpdateUserAPI)
-	r.DELETE("/fyc/user", api.DeleteUserCredAPI)
+func UserRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/users", middleware.RequirePermission(rbac.ModuleUsers, rbac.ActionView), api.GetUsersAPI)
+	r.POST("/fyc/user", middleware.RequirePermission(rbac.ModuleUsers, rbac.ActionCreate), middleware.Audit(rbac.ModuleUsers, rbac.ActionCreate, "username"), api.AddUserAPI)
+	r.PUT("/fyc/user", middleware.RequirePermission(rbac.ModuleUsers, rbac.ActionUpdate), middleware.Audit(rbac.ModuleUsers, rbac.ActionUpdate, "username"), api.UpdateUserAPI)
+	r.DELETE("/fyc/user", middleware.RequirePermission(rbac.ModuleUsers, rbac.ActionDelete), middleware.Audit(rbac.ModuleUsers, rbac.ActionDelete, "username"), api.DeleteUserCredAPI)
 
 	//r.GET("/fyc/userEnabled", api.GetUserEnabledAPI)
 	//r.GET("/fyc/userDeleted", api.GetUserDeletedAPI)
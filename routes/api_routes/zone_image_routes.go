@@ -3,13 +3,16 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/audit"
+	"fyc/pkg/rbac"
 )
 
-func ZoneImageRoutes(r *gin.Engine) {
-	r.GET("/fyc/zonesImages", api.GetAllImageZonesAPI)
+func ZoneImageRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/zonesImages", middleware.RequirePermission(rbac.ModuleZones, rbac.ActionView), api.GetAllImageZonesAPI)
 	//r.GET("/fyc/zonesImage", api.GetZoneImageByIDAPI)
-	r.POST("/fyc/zonesImage", api.CreateZoneImageAPI)
-	r.PUT("/fyc/zonesImage/:id", api.UpdateZoneImageByIdAPI)
-	r.DELETE("/fyc/zonesImage/:id", api.DeleteZoneImageAPI)
+	r.POST("/fyc/zonesImage", middleware.RequirePermission(rbac.ModuleZones, rbac.ActionCreate), middleware.AuditEntity(rbac.ModuleZones, audit.EntityZoneImage, rbac.ActionCreate, "id"), api.CreateZoneImageAPI)
+	r.PUT("/fyc/zonesImage/:id", middleware.RequirePermission(rbac.ModuleZones, rbac.ActionUpdate), middleware.AuditEntity(rbac.ModuleZones, audit.EntityZoneImage, rbac.ActionUpdate, "id"), api.UpdateZoneImageByIdAPI)
+	r.DELETE("/fyc/zonesImage/:id", middleware.RequirePermission(rbac.ModuleZones, rbac.ActionDelete), middleware.AuditEntity(rbac.ModuleZones, audit.EntityZoneImage, rbac.ActionDelete, "id"), api.DeleteZoneImageAPI)
 }
@@ -3,14 +3,16 @@ package api_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
 	"fyc/pkg/api"
+	"fyc/pkg/rbac"
 )
 
-func ZoneRoutes(r *gin.Engine) {
-	r.GET("/fyc/zones", api.GetZonesAPI)
-	r.POST("/fyc/zones", api.CreateZoneAPI)
-	r.PUT("/fyc/zones", api.UpdateZoneIdAPI)
-	r.DELETE("/fyc/zones", api.DeleteZoneAPI)
+func ZoneRoutes(r *gin.RouterGroup) {
+	r.GET("/fyc/zones", middleware.RequirePermission(rbac.ModuleZones, rbac.ActionView), api.GetZonesAPI)
+	r.POST("/fyc/zones", middleware.RequirePermission(rbac.ModuleZones, rbac.ActionCreate), middleware.Audit(rbac.ModuleZones, rbac.ActionCreate, "id", "zone_id"), api.CreateZoneAPI)
+	r.PUT("/fyc/zones", middleware.RequirePermission(rbac.ModuleZones, rbac.ActionUpdate), middleware.Audit(rbac.ModuleZones, rbac.ActionUpdate, "id", "zone_id"), api.UpdateZoneIdAPI)
+	r.DELETE("/fyc/zones", middleware.RequirePermission(rbac.ModuleZones, rbac.ActionDelete), middleware.Audit(rbac.ModuleZones, rbac.ActionDelete, "id"), api.DeleteZoneAPI)
 	//r.GET("/fyc/zoneName", api.GetZoneNameAPI)
 	//r.PUT("/fyc/zoneState", api.ChangeZoneStateAPI)
 }
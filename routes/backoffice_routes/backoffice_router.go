@@ -3,48 +3,116 @@ package backoffice_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
+	"fyc/pkg/audit"
 	"fyc/pkg/backoffice"
+	"fyc/pkg/rbac"
 )
 
+// can shortens the permission required by a route
+func can(module string, action string) gin.HandlerFunc {
+	return middleware.RequirePermission(module, action)
+}
+
+// audited records the changes made by a route in the audit log
+func audited(module string, action string, keys ...string) gin.HandlerFunc {
+	return middleware.Audit(module, action, keys...)
+}
+
+// auditedEntity records the changes made by a route to an entity of another
+// kind than its module in the audit log
+func auditedEntity(module string, entity string, action string, keys ...string) gin.HandlerFunc {
+	return middleware.AuditEntity(module, entity, action, keys...)
+}
+
 func BackOfficeRouter(router *gin.RouterGroup) {
 
 	// Debug routes
-	router.GET("/backoffice/debug", backoffice.Debuger_BackOffice)
+	router.GET("/backoffice/debug", can(rbac.ModuleDebug, rbac.ActionView), backoffice.Debuger_BackOffice)
 
 	// Dashboard routes
-	router.GET("/backoffice/get_dashboard_data", backoffice.GetDashboardData)
+	router.GET("/backoffice/get_dashboard_data", can(rbac.ModuleDashboard, rbac.ActionView), backoffice.GetDashboardData)
 
 	// Zones routes
-	router.GET("/backoffice/get_zones", backoffice.GetZonesAPI)
-	router.GET("/backoffice/get_zones_names", backoffice.GetZonesNames)
-	router.POST("/backoffice/add_zone", backoffice.CreateZone)
-	router.PUT("/backoffice/update_zone", backoffice.UpdateZoneDataAPI)
-	router.DELETE("/backoffice/delete_zone", backoffice.DeleteZoneDataAPI)
+	router.GET("/backoffice/get_zones", can(rbac.ModuleZones, rbac.ActionView), backoffice.GetZonesAPI)
+	router.GET("/backoffice/get_zones_names", can(rbac.ModuleZones, rbac.ActionView), backoffice.GetZonesNames)
+	router.POST("/backoffice/add_zone", can(rbac.ModuleZones, rbac.ActionCreate), audited(rbac.ModuleZones, rbac.ActionCreate, "zone_id"), backoffice.CreateZone)
+	router.PUT("/backoffice/update_zone", can(rbac.ModuleZones, rbac.ActionUpdate), audited(rbac.ModuleZones, rbac.ActionUpdate, "zone_id"), backoffice.UpdateZoneDataAPI)
+	router.DELETE("/backoffice/delete_zone", can(rbac.ModuleZones, rbac.ActionDelete), audited(rbac.ModuleZones, rbac.ActionDelete, "zone_id"), backoffice.DeleteZoneDataAPI)
 
 	// Camera routes
-	router.GET("/backoffice/getCameras", backoffice.GetCameraDataAPI)
-	router.POST("/backoffice/addCamera", backoffice.AddCameraDataAPI)
-	router.PUT("/backoffice/updateCamera", backoffice.UpdateCameraDataAPI)
-	router.DELETE("/backoffice/deleteCameras", backoffice.DeleteCameraDataAPI)
+	router.GET("/backoffice/getCameras", can(rbac.ModuleCameras, rbac.ActionView), backoffice.GetCameraDataAPI)
+	router.POST("/backoffice/addCamera", can(rbac.ModuleCameras, rbac.ActionCreate), audited(rbac.ModuleCameras, rbac.ActionCreate, "camera_id", "cam_id"), backoffice.AddCameraDataAPI)
+	router.PUT("/backoffice/updateCamera", can(rbac.ModuleCameras, rbac.ActionUpdate), audited(rbac.ModuleCameras, rbac.ActionUpdate, "camera_id", "cam_id"), backoffice.UpdateCameraDataAPI)
+	router.POST("/backoffice/rotateCameraSecret", can(rbac.ModuleCameras, rbac.ActionUpdate), audited(rbac.ModuleCameras, rbac.ActionUpdate, "camera_id"), backoffice.RotateCameraSecretAPI)
+	router.DELETE("/backoffice/deleteCameras", can(rbac.ModuleCameras, rbac.ActionDelete), audited(rbac.ModuleCameras, rbac.ActionDelete, "camera_id"), backoffice.DeleteCameraDataAPI)
 
 	// Signes routes
-	router.GET("/backoffice/getSign", backoffice.GetSignDataAPI)
-	router.POST("/backoffice/addSign", backoffice.CreateSignDataAPI)
-	router.PUT("/backoffice/updateSign", backoffice.UpdateSignDataAPI)
-	router.DELETE("/backoffice/deleteSign", backoffice.DeleteSignDataAPI)
+	router.GET("/backoffice/getSign", can(rbac.ModuleSigns, rbac.ActionView), backoffice.GetSignDataAPI)
+	router.POST("/backoffice/addSign", can(rbac.ModuleSigns, rbac.ActionCreate), audited(rbac.ModuleSigns, rbac.ActionCreate, "sign_id"), backoffice.CreateSignDataAPI)
+	router.PUT("/backoffice/updateSign", can(rbac.ModuleSigns, rbac.ActionUpdate), audited(rbac.ModuleSigns, rbac.ActionUpdate, "sign_id"), backoffice.UpdateSignDataAPI)
+	router.DELETE("/backoffice/deleteSign", can(rbac.ModuleSigns, rbac.ActionDelete), audited(rbac.ModuleSigns, rbac.ActionDelete, "sign_id"), backoffice.DeleteSignDataAPI)
+	router.GET("/backoffice/getSignStatus", can(rbac.ModuleSigns, rbac.ActionView), backoffice.GetSignStatusDataAPI)
+	router.POST("/backoffice/refreshSign", can(rbac.ModuleSigns, rbac.ActionUpdate), audited(rbac.ModuleSigns, rbac.ActionUpdate, "sign_id"), backoffice.RefreshSignDataAPI)
 
 	// Client routes
-	router.GET("/backoffice/get_clients", backoffice.GetClients)
-	router.POST("/backoffice/addClient", backoffice.AddClientAPI)
-	router.PUT("/backoffice/updateClient", backoffice.UpdateClientAPI)
-	router.DELETE("/backoffice/deleteClient", backoffice.DeleteClientAPI)
+	router.GET("/backoffice/get_clients", can(rbac.ModuleClients, rbac.ActionView), backoffice.GetClients)
+	router.POST("/backoffice/addClient", can(rbac.ModuleClients, rbac.ActionCreate), audited(rbac.ModuleClients, rbac.ActionCreate, "client_id"), backoffice.AddClientAPI)
+	router.PUT("/backoffice/updateClient", can(rbac.ModuleClients, rbac.ActionUpdate), audited(rbac.ModuleClients, rbac.ActionUpdate, "client_id"), backoffice.UpdateClientAPI)
+	router.DELETE("/backoffice/deleteClient", can(rbac.ModuleClients, rbac.ActionDelete), audited(rbac.ModuleClients, rbac.ActionDelete, "client_id"), backoffice.DeleteClientAPI)
+	router.POST("/backoffice/rotateClientSecret", can(rbac.ModuleClients, rbac.ActionUpdate), audited(rbac.ModuleClients, rbac.ActionUpdate, "client_id"), backoffice.RotateClientSecretAPI)
+	router.GET("/backoffice/getClientUsage", can(rbac.ModuleClients, rbac.ActionView), backoffice.GetClientUsageAPI)
+
+	// Webhook routes
+	router.GET("/backoffice/getWebhooks", can(rbac.ModuleWebhooks, rbac.ActionView), backoffice.GetWebhooksAPI)
+	router.DELETE("/backoffice/deleteWebhook", can(rbac.ModuleWebhooks, rbac.ActionDelete), auditedEntity(rbac.ModuleWebhooks, audit.EntityWebhook, rbac.ActionDelete, "id"), backoffice.DeleteWebhookAPI)
+	router.GET("/backoffice/getWebhookDeliveries", can(rbac.ModuleWebhooks, rbac.ActionView), backoffice.GetWebhookDeliveriesAPI)
+	router.POST("/backoffice/replayWebhookDelivery", can(rbac.ModuleWebhooks, rbac.ActionUpdate), auditedEntity(rbac.ModuleWebhooks, audit.EntityWebhookDelivery, rbac.ActionUpdate, "id"), backoffice.ReplayWebhookDeliveryAPI)
+
+	// Password routes, changing its own password needs no permission
+	router.GET("/backoffice/passwordPolicy", backoffice.GetPasswordPolicyAPI)
+	router.POST("/backoffice/changePassword", backoffice.ChangePasswordAPI)
+	router.POST("/backoffice/resetPassword", can(rbac.ModuleUsers, rbac.ActionUpdate), audited(rbac.ModuleUsers, rbac.ActionUpdate, "username"), backoffice.ResetPasswordAPI)
+
+	// Session routes, managing its own sessions needs no permission
+	router.POST("/backoffice/logout", backoffice.LogoutAPI)
+	router.GET("/backoffice/mySessions", backoffice.GetMySessionsAPI)
+	router.DELETE("/backoffice/mySessions", backoffice.RevokeMySessionAPI)
+	router.GET("/backoffice/userSessions", can(rbac.ModuleUsers, rbac.ActionView), backoffice.GetUserSessionsAPI)
+	router.DELETE("/backoffice/userSessions", can(rbac.ModuleUsers, rbac.ActionUpdate), auditedEntity(rbac.ModuleUsers, audit.EntitySessions, rbac.ActionUpdate, "username"), backoffice.RevokeUserSessionsAPI)
+
+	// Two-factor routes, managing its own two-factor authentication needs no permission
+	router.GET("/backoffice/twoFactor", backoffice.GetTwoFactorAPI)
+	router.POST("/backoffice/enrollTwoFactor", backoffice.EnrollTwoFactorAPI)
+	router.POST("/backoffice/confirmTwoFactor", backoffice.ConfirmTwoFactorAPI)
+	router.POST("/backoffice/recoveryCodes", backoffice.RegenerateRecoveryCodesAPI)
+	router.POST("/backoffice/disableTwoFactor", backoffice.DisableTwoFactorAPI)
+	router.DELETE("/backoffice/userTwoFactor", can(rbac.ModuleUsers, rbac.ActionUpdate), audited(rbac.ModuleUsers, rbac.ActionUpdate, "username"), backoffice.ResetUserTwoFactorAPI)
+
+	// Login lockout routes
+	router.GET("/backoffice/loginLockout", can(rbac.ModuleUsers, rbac.ActionView), backoffice.GetLoginLockoutAPI)
+	router.DELETE("/backoffice/loginLockout", can(rbac.ModuleUsers, rbac.ActionUpdate), auditedEntity(rbac.ModuleUsers, audit.EntityLoginLockout, rbac.ActionUpdate, "username", "ip"), backoffice.UnlockLoginAPI)
+
+	// Role routes
+	router.GET("/backoffice/getPermissions", backoffice.GetPermissionsAPI)
+	router.GET("/backoffice/getRoles", can(rbac.ModuleRoles, rbac.ActionView), backoffice.GetRolesAPI)
+	router.POST("/backoffice/addRole", can(rbac.ModuleRoles, rbac.ActionCreate), audited(rbac.ModuleRoles, rbac.ActionCreate, "name"), backoffice.AddRoleAPI)
+	router.PUT("/backoffice/updateRole", can(rbac.ModuleRoles, rbac.ActionUpdate), audited(rbac.ModuleRoles, rbac.ActionUpdate, "name"), backoffice.UpdateRoleAPI)
+	router.DELETE("/backoffice/deleteRole", can(rbac.ModuleRoles, rbac.ActionDelete), audited(rbac.ModuleRoles, rbac.ActionDelete, "name"), backoffice.DeleteRoleAPI)
+	router.PUT("/backoffice/roleTwoFactor", can(rbac.ModuleRoles, rbac.ActionUpdate), audited(rbac.ModuleRoles, rbac.ActionUpdate, "name"), backoffice.SetRoleTwoFactorAPI)
+	router.POST("/backoffice/assignRole", can(rbac.ModuleUsers, rbac.ActionUpdate), audited(rbac.ModuleUsers, rbac.ActionUpdate, "username"), backoffice.AssignRoleAPI)
+
+	// Audit routes
+	router.GET("/backoffice/getAuditLog", can(rbac.ModuleAudit, rbac.ActionView), backoffice.GetAuditLogAPI)
+	router.GET("/backoffice/getAuthLog", can(rbac.ModuleAudit, rbac.ActionView), backoffice.GetAuthLogAPI)
+	router.GET("/backoffice/getSecurityLog", can(rbac.ModuleAudit, rbac.ActionView), backoffice.GetSecurityLogAPI)
 
 	// Settings routes
-	router.GET("/backoffice/getSettings", backoffice.GetSettingsDataAPI)
-	router.PUT("/backoffice/updateSettings", backoffice.UpdateSettingsDataAPI)
+	router.GET("/backoffice/getSettings", can(rbac.ModuleSettings, rbac.ActionView), backoffice.GetSettingsDataAPI)
+	router.PUT("/backoffice/updateSettings", can(rbac.ModuleSettings, rbac.ActionUpdate), audited(rbac.ModuleSettings, rbac.ActionUpdate, "carpark_id"), backoffice.UpdateSettingsDataAPI)
 
 	// Present car routes
-	router.GET("/backoffice/get_present_car", backoffice.GetAllPresentTransactionsDataAPI)
-	router.GET("/backoffice/get_present_car_id", backoffice.GetAllPresentTransactionsDataIDAPI)
+	router.GET("/backoffice/get_present_car", can(rbac.ModulePresentCars, rbac.ActionView), backoffice.GetAllPresentTransactionsDataAPI)
+	router.GET("/backoffice/get_present_car_id", can(rbac.ModulePresentCars, rbac.ActionView), backoffice.GetAllPresentTransactionsDataIDAPI)
 
 }
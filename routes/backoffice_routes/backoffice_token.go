@@ -9,4 +9,6 @@ import (
 func BackOfficeToken(r *gin.Engine) {
 	// LOGIN
 	r.POST("/backoffice/login", backoffice.Login)
+	r.POST("/backoffice/loginTwoFactor", backoffice.LoginTwoFactorAPI)
+	r.POST("/backoffice/refresh", backoffice.RefreshTokenAPI)
 }
@@ -2,6 +2,7 @@ package backoffice_routes
 
 import (
 	"fyc/pkg/export"
+	"fyc/pkg/rbac"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,10 +10,11 @@ import (
 func ExportBackoffice(router *gin.RouterGroup) {
 
 	// Export routes
-	router.POST("/backoffice/export_zone", export.ExportZone)
-	router.POST("/backoffice/export_client", export.ExportClient)
-	router.POST("/backoffice/export_camera", export.ExportCamera)
-	router.POST("/backoffice/export_sign", export.ExportSign)
-	router.POST("/backoffice/export_cars", export.ExportCars)
+	router.POST("/backoffice/export_zone", can(rbac.ModuleZones, rbac.ActionExport), export.ExportZone)
+	router.POST("/backoffice/export_client", can(rbac.ModuleClients, rbac.ActionExport), export.ExportClient)
+	router.POST("/backoffice/export_camera", can(rbac.ModuleCameras, rbac.ActionExport), export.ExportCamera)
+	router.POST("/backoffice/export_sign", can(rbac.ModuleSigns, rbac.ActionExport), export.ExportSign)
+	router.POST("/backoffice/export_cars", can(rbac.ModulePresentCars, rbac.ActionExport), export.ExportCars)
+	router.GET("/backoffice/export_audit", can(rbac.ModuleAudit, rbac.ActionExport), export.ExportAudit)
 
 }
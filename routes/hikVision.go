@@ -7,5 +7,5 @@ import (
 )
 
 func HikVisionRoutes(r *gin.Engine) {
-	r.POST("/cam", hikvision.HikvisionHandler)
+	r.POST("/cam", hikvision.CameraGuard(), hikvision.HikvisionHandler)
 }
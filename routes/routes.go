@@ -1,7 +1,6 @@
 package routes
 
 import (
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	swaggerFiles "github.com/swaggo/files"
@@ -9,6 +8,7 @@ import (
 
 	cnf "fyc/config"
 	"fyc/middleware"
+	"fyc/pkg/apierror"
 	"fyc/routes/api_routes"
 	"fyc/routes/backoffice_routes"
 	"fyc/routes/third_party_routes"
@@ -21,30 +21,45 @@ func SetupRouter() *gin.Engine {
 	}
 
 	router := gin.Default()
+
+	// Proxy headers are only read from the configured proxies
+	if err := router.SetTrustedProxies(cnf.Configvar.Server.TrustedProxies); err != nil {
+		log.Error().Err(err).Strs("Trusted Proxies", cnf.Configvar.Server.TrustedProxies).Msg("Invalid trusted proxies, proxy headers ignored")
+		_ = router.SetTrustedProxies(nil)
+	}
+	router.RemoteIPHeaders = cnf.Configvar.Server.RemoteIPHeaders
 	//router.Use(config.CustomErrorHandler())
 
-	// CORS configuration
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
+	if cnf.Configvar.Server.SecurityHeaders {
+		router.Use(middleware.SecurityHeaders())
+	}
+	// CORS policies of the route groups
+	router.Use(middleware.CORS())
+	// Queries are cancelled with the request, or once it timed out
+	router.Use(middleware.RequestTimeout())
 
 	log.Debug().Msg("--------------------------  START ROUTING  ----------------------")
 	authorizedBackOffice := router.Group("/")
 	authorizedBackOffice.Use(middleware.TokenMiddlewareBackOffice())
 
+	// Third-party clients may be required to present a client certificate
+	thirdPartyPublic := router.Group("/", middleware.RequireClientCert())
+
 	authorzedThirdParty := router.Group("/")
-	authorzedThirdParty.Use(middleware.TokenMiddlewareThirdParty())
+	authorzedThirdParty.Use(middleware.RequireClientCert(), middleware.TokenMiddlewareThirdParty(), middleware.BindClientCert(), middleware.RateLimitThirdParty())
+
+	// v2 serves the third-party routes with the uniform error envelope
+	v2 := router.Group("/v2", apierror.V2(), middleware.RequireClientCert())
+	authorizedThirdPartyV2 := v2.Group("/")
+	authorizedThirdPartyV2.Use(middleware.TokenMiddlewareThirdParty(), middleware.BindClientCert(), middleware.RateLimitThirdParty())
 
 	//rout := router.Group("/api")
 
 	HikVisionRoutes(router) // CAMERA ROUTES ------------------------------------
 
-	third_party_routes.ThirdPartyToken(router)               // Token Generator FOR THIRD PARTY ------------------
+	third_party_routes.ThirdPartyToken(thirdPartyPublic)     // Token Generator FOR THIRD PARTY ------------------
 	third_party_routes.ThirdPartyRoutes(authorzedThirdParty) // THIRD PARTY ROUTES -------------------------------
+	third_party_routes.ThirdPartyRoutesV2(v2, authorizedThirdPartyV2)
 
 	backoffice_routes.BackOfficeToken(router)                // Token Generator FOR BACKOFFICE ------------------
 	backoffice_routes.BackOfficeRouter(authorizedBackOffice) // BACKOFFICE ROUTES --------------------------------
@@ -52,19 +67,28 @@ func SetupRouter() *gin.Engine {
 
 	PkaRoutes(router) // PKA ROUTES --------------------------------
 
-	api_routes.DebugRoutes(router) // DEBUG ROUTES -------------------------------------
-	api_routes.ZoneImageRoutes(router)
-	api_routes.CameraRoutes(router)
-	api_routes.ZoneRoutes(router)
-	api_routes.PresentCarRoutes(router)
-	api_routes.CarDetailRoutes(router)
-	api_routes.ClientCredsRoutes(router)
-	api_routes.SignRoutes(router)
-	api_routes.UserAuditRoutes(router)
-	api_routes.UserRoutes(router)
-	api_routes.SettingsRoutes(router)
-	api_routes.HistoryRoutes(router)
-	api_routes.ErrorRoutes(router)
+	// Internal management API, authenticated with a backoffice or service token
+	if cnf.Configvar.InternalAPI.Enabled {
+		internalAPI := router.Group("/")
+		internalAPI.Use(middleware.TokenMiddlewareInternalAPI())
+
+		api_routes.DebugRoutes(internalAPI)
+		api_routes.ZoneImageRoutes(internalAPI)
+		api_routes.CameraRoutes(internalAPI)
+		api_routes.ZoneRoutes(internalAPI)
+		api_routes.PresentCarRoutes(internalAPI)
+		api_routes.CarDetailRoutes(internalAPI)
+		api_routes.ClientCredsRoutes(internalAPI)
+		api_routes.SignRoutes(internalAPI)
+		api_routes.UserAuditRoutes(internalAPI)
+		api_routes.UserRoutes(internalAPI)
+		api_routes.SettingsRoutes(internalAPI)
+		api_routes.HistoryRoutes(internalAPI)
+		api_routes.ErrorRoutes(internalAPI)
+		api_routes.EventsRoutes(internalAPI)
+	} else {
+		log.Info().Msg("Internal /fyc API disabled")
+	}
 
 	//log.Debug().Msg("--------------------------  END ROUTING  ---------------------- ")
 
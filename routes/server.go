@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	cnf "fyc/config"
+)
+
+// NewServer returns the HTTP server of the handler. When a client CA is
+// configured the client certificates presented are verified against it,
+// RequireClientCert requiring them from the third-party clients only.
+func NewServer(handler http.Handler) (*http.Server, error) {
+	server := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cnf.Configvar.Server.Host, cnf.Configvar.Server.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if !TLSEnabled() {
+		return server, nil
+	}
+
+	server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile := cnf.Configvar.Server.TLSClientCAFile; caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading client CA %s: %w", caFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in client CA %s", caFile)
+		}
+		server.TLSConfig.ClientCAs = pool
+		server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return server, nil
+}
+
+// TLSEnabled reports whether the server is served over HTTPS.
+func TLSEnabled() bool {
+	return cnf.Configvar.Server.TLSCertFile != ""
+}
+
+// Serve serves HTTP or HTTPS, as configured, until the server is closed.
+func Serve(server *http.Server) error {
+	if TLSEnabled() {
+		return server.ListenAndServeTLS(cnf.Configvar.Server.TLSCertFile, cnf.Configvar.Server.TLSKeyFile)
+	}
+	return server.ListenAndServe()
+}
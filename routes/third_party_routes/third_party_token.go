@@ -6,7 +6,10 @@ import (
 	"fyc/pkg/third_party"
 )
 
-func ThirdPartyToken(r *gin.Engine) {
+func ThirdPartyToken(r *gin.RouterGroup) {
 	r.POST("/token", third_party.GetToken)
+	r.POST("/oauth/token", third_party.OAuthToken)
+	r.POST("/oauth/revoke", third_party.OAuthRevoke)
+	r.POST("/oauth/introspect", third_party.OAuthIntrospect)
 	//r.POST("/fyc/v1/Auth/token", third_party.TokenHandler)
 }
@@ -3,6 +3,8 @@ package third_party_routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"fyc/middleware"
+	"fyc/pkg/db"
 	"fyc/pkg/third_party"
 )
 
@@ -11,8 +13,23 @@ import (
 // @description	API documentation for main endpoints.
 func ThirdPartyRoutes(r *gin.RouterGroup) {
 	//r.POST("/token", third_party.GetToken)
-	r.GET("/findmycar", third_party.FindMyCar)
-	r.GET("/getpicture", third_party.GetPicture)
-	r.GET("/getsettings", third_party.Getsettings)
+	r.GET("/findmycar", middleware.RequireScope(db.ScopeFindMyCar), third_party.FindMyCar)
+	r.GET("/getpicture", middleware.RequireScope(db.ScopeGetPicture), third_party.GetPicture)
+	r.GET("/picture/:picture_name", middleware.RequireScope(db.ScopeGetPicture), third_party.GetPictureBinary)
+	r.GET("/getsettings", middleware.RequireScope(db.ScopeGetSettings), third_party.Getsettings)
+
+	r.GET("/kiosk/search", middleware.RequireScope(db.ScopeKiosk), third_party.KioskSearch)
+	r.POST("/kiosk/confirm", middleware.RequireScope(db.ScopeKiosk), third_party.KioskConfirm)
+
+	r.POST("/webhooks", middleware.RequireScope(db.ScopeWebhooks), third_party.CreateWebhook)
+	r.GET("/webhooks", middleware.RequireScope(db.ScopeWebhooks), third_party.GetWebhooks)
+	r.DELETE("/webhooks/:id", middleware.RequireScope(db.ScopeWebhooks), third_party.DeleteWebhook)
 	//r.POST("/fyc/v1/Auth/token", third_party.TokenHandler)
 }
+
+// ThirdPartyRoutesV2 serves the third-party routes under /v2, answering the
+// uniform envelope of pkg/apierror.
+func ThirdPartyRoutesV2(public *gin.RouterGroup, authorized *gin.RouterGroup) {
+	public.GET("/errors", third_party.GetErrorCatalog)
+	ThirdPartyRoutes(authorized)
+}